@@ -1,6 +1,11 @@
 package main
 
-import "github.com/afandimsr/go-gin-api/internal/bootstrap"
+import (
+	// Time zones of user profiles are validated, the alpine image has no zoneinfo
+	_ "time/tzdata"
+
+	"github.com/afandimsr/go-gin-api/internal/bootstrap"
+)
 
 // @title           Go Gin API
 // @version         1.0
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/database"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/external"
+	userRepo "github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/mysql/repository"
+	userPostgresRepo "github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/postgres/repository"
+	userSQLServerRepo "github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqlserver/repository"
+	userUC "github.com/afandimsr/go-gin-api/internal/usecase/user"
+)
+
+// Migrates every local user without a keycloak_id to Keycloak.
+//
+//	go run ./cmd/keycloak-migrate -dry-run
+//	go run ./cmd/keycloak-migrate -batch-size 200 -concurrency 4 -report report.json
+//	go run ./cmd/keycloak-migrate -after <last_id from a previous report>
+func main() {
+	batchSize := flag.Int("batch-size", 100, "number of users loaded per batch")
+	concurrency := flag.Int("concurrency", 4, "maximum concurrent Keycloak requests")
+	dryRun := flag.Bool("dry-run", false, "list the users that would be migrated without calling Keycloak")
+	afterID := flag.String("after", "", "resume after this user id (last_id of a previous run)")
+	requirePasswordUpdate := flag.Bool("require-password-update", false, "do not import bcrypt hashes, force a password update on first login instead")
+	reportPath := flag.String("report", "", "write the JSON report to this file instead of stdout")
+	flag.Parse()
+
+	cfg := config.Load()
+	if cfg.Keycloak.URL == "" {
+		log.Fatal("KEYCLOAK_URL is required")
+	}
+
+	db, err := database.NewDatabase(cfg.DB)
+	if err != nil {
+		log.Fatalf("failed to open db: %v", err)
+	}
+	defer db.Close()
+
+	var repository user.UserRepository
+	switch cfg.DB.Driver {
+	case "postgres":
+		repository = userPostgresRepo.NewUserRepo(db)
+	case "mysql":
+		repository = userRepo.NewUserRepo(db)
+	case "sqlserver":
+		repository = userSQLServerRepo.NewUserRepo(db)
+	default:
+		log.Fatalf("unsupported db driver: %s", cfg.DB.Driver)
+	}
+
+	usecase := userUC.New(repository, userUC.Deps{Keycloak: external.NewKeycloakService(cfg.Keycloak, cfg.External)})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	report, err := usecase.MigrateUsersToKeycloak(ctx, userUC.KeycloakMigrationOptions{
+		BatchSize:             *batchSize,
+		Concurrency:           *concurrency,
+		DryRun:                *dryRun,
+		AfterID:               *afterID,
+		RequirePasswordUpdate: *requirePasswordUpdate,
+	})
+	if err != nil {
+		log.Printf("migration stopped: %v (resume with -after %s)", err, report.LastID)
+	}
+
+	out := os.Stdout
+	if *reportPath != "" {
+		f, ferr := os.Create(*reportPath)
+		if ferr != nil {
+			log.Fatalf("failed to create report: %v", ferr)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(report); encErr != nil {
+		log.Fatalf("failed to write report: %v", encErr)
+	}
+
+	if err != nil || len(report.Failed) > 0 {
+		os.Exit(1)
+	}
+}
@@ -28,6 +28,9 @@ func main() {
 	case "mysql":
 		seeder.SeedRolesMysql(db)
 		seeder.SeedAdminUserMysql(db)
+	case "sqlserver":
+		seeder.SeedRolesSQLServer(db)
+		seeder.SeedAdminUserSQLServer(db)
 	default:
 		log.Fatalf("unsupported db driver: %s", cfg.DB.Driver)
 	}
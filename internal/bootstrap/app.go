@@ -5,23 +5,39 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
 	_ "github.com/afandimsr/go-gin-api/docs"
 	"github.com/afandimsr/go-gin-api/internal/config"
 	"github.com/afandimsr/go-gin-api/internal/database"
+	fileDelivery "github.com/afandimsr/go-gin-api/internal/delivery/http/handler/file"
+	storageDelivery "github.com/afandimsr/go-gin-api/internal/delivery/http/handler/storage"
 	handler "github.com/afandimsr/go-gin-api/internal/delivery/http/handler/user"
 	"github.com/afandimsr/go-gin-api/internal/delivery/http/middleware"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
 	"github.com/afandimsr/go-gin-api/internal/infrastructure/apm"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/authprovider"
 	"github.com/afandimsr/go-gin-api/internal/infrastructure/external"
 	userRepo "github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/mysql/repository"
 	userPostgresRepo "github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/postgres/repository"
-	s3infra "github.com/afandimsr/go-gin-api/internal/infrastructure/storage/s3"
+	userSQLServerRepo "github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqlserver/repository"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqltx"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/scanner/clamav"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/quarantine"
+	"github.com/afandimsr/go-gin-api/internal/pkg/cursor"
+	"github.com/afandimsr/go-gin-api/internal/pkg/filecheck"
 	"github.com/afandimsr/go-gin-api/internal/pkg/jwt"
 	"github.com/afandimsr/go-gin-api/internal/pkg/oidc"
+	"github.com/afandimsr/go-gin-api/internal/pkg/scheduler"
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+	"github.com/afandimsr/go-gin-api/internal/pkg/thumbnail"
+	"github.com/afandimsr/go-gin-api/internal/seeder"
+	fileUC "github.com/afandimsr/go-gin-api/internal/usecase/file"
 	userUC "github.com/afandimsr/go-gin-api/internal/usecase/user"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
@@ -36,6 +52,7 @@ func Run() {
 
 	// set jwt secret
 	jwt.SetSecret(cfg.JWTSecret)
+	cursor.SetSecret(cfg.CursorSecret)
 
 	// initialize database
 	db, err := database.NewDatabase(cfg.DB)
@@ -46,32 +63,56 @@ func Run() {
 	// initialize storage service
 	ctx := context.Background()
 
-	publicS3, err := s3infra.New(ctx, cfg.S3["public"])
+	signer := signedurl.New(cfg.Storage.SigningSecret, cfg.Storage.URL)
+	servedBuckets := map[string]storageDelivery.Bucket{}
+
+	publicStorage, served, err := newStorage(ctx, "public", cfg.S3["public"], signer)
 	if err != nil {
-		log.Fatal("failed init public s3:", err)
+		log.Fatal("failed init public storage:", err)
+	}
+	if served {
+		servedBuckets["public"] = storageDelivery.Bucket{Storage: publicStorage, Public: true}
 	}
 
-	// privateS3, err := s3infra.New(ctx, cfg.S3["private"])
-	// if err != nil {
-	// 	log.Fatal("failed init private s3:", err)
-	// }
+	privateStorage, served, err := newStorage(ctx, "private", cfg.S3["private"], signer)
+	if err != nil {
+		log.Fatal("failed init private storage:", err)
+	}
+	if served {
+		servedBuckets["private"] = storageDelivery.Bucket{Storage: privateStorage}
+	}
 
-	publicStorage := s3infra.NewUploader(
-		publicS3,
-		cfg.S3["public"].Bucket,
+	// Content checks of uploads, rejected uploads are kept in the private bucket
+	var scanner filecheck.Scanner
+	if cfg.ClamAV.Address != "" {
+		client, err := clamav.New(cfg.ClamAV.Address, cfg.ClamAV.Timeout)
+		if err != nil {
+			log.Fatal("failed init clamav:", err)
+		}
+		if err := client.Ping(ctx); err != nil {
+			log.Printf("Warning: clamd is unreachable, uploads will fail until it is: %v", err)
+		}
+		scanner = client
+	}
+	maxDimensions := filecheck.MaxDimensions(cfg.Files.MaxImageWidth, cfg.Files.MaxImageHeight)
+	fileChecks := filecheck.New(
+		filecheck.Extensions(cfg.Files.AllowedExtensions...),
+		filecheck.Types(cfg.Files.AllowedTypes...),
+		maxDimensions,
+		filecheck.Scan(scanner),
 	)
+	avatarChecks := filecheck.New(
+		filecheck.Types(thumbnail.ContentTypeJPEG, thumbnail.ContentTypePNG, thumbnail.ContentTypeGIF, thumbnail.ContentTypeWebP),
+		maxDimensions,
+		filecheck.Scan(scanner),
+	)
+	uploadQuarantine := quarantine.New(privateStorage)
 
-	// privateStorage := storageUC.New(
-	// 	privateS3,
-	// 	cfg.S3["private"].Bucket,
-	// )
-
-	// inject to usecase layer
-	_ = publicStorage
-	// _ = privateStorage
-
-	authClient := external.NewAuthClient(cfg.ClientAuthURL)
-	keycloakService := external.NewKeycloakService(cfg.Keycloak)
+	authService, err := authprovider.FromConfig(cfg.Auth, cfg.External)
+	if err != nil {
+		log.Fatal(err)
+	}
+	keycloakService := external.NewKeycloakService(cfg.Keycloak, cfg.External)
 
 	// OIDC Provider (optional depending on config)
 	var oidcProvider *oidc.OIDCProvider
@@ -85,20 +126,114 @@ func Run() {
 		}
 	}
 
-	var userHandler *handler.UserHandler
+	// Social login providers; Keycloak is reachable as /auth/keycloak/login too
+	socialProviders := oidc.NewRegistry()
+	if oidcProvider != nil {
+		socialProviders.Register(oidcProvider)
+	}
+	for name, providerCfg := range cfg.OAuth.Providers {
+		redirectURL := fmt.Sprintf("%s/auth/%s/callback", strings.TrimRight(cfg.OAuth.RedirectBaseURL, "/"), name)
+		p, err := oidc.NewProvider(context.Background(), name, providerCfg, redirectURL)
+		if err != nil {
+			log.Printf("Warning: failed to initialize login provider %s: %v", name, err)
+			continue
+		}
+		socialProviders.Register(p)
+	}
+	log.Printf("Social login providers: %v", socialProviders.Names())
+
+	var userRepository user.UserRepository
+	var sessionRepository user.SessionRepository
+	var identityRepository user.IdentityRepository
+	var attributeRepository user.AttributeRepository
+	var fileRepository file.Repository
+	var uploadSessionRepository file.UploadSessionRepository
+	var usageRepository file.UsageRepository
 	switch cfg.DB.Driver {
 	case "mysql":
-		userRepository := userRepo.NewUserRepo(db)
-		userUsecase := userUC.New(userRepository, authClient, keycloakService)
-		userHandler = handler.New(userUsecase, oidcProvider)
+		userRepository = userRepo.NewUserRepo(db)
+		sessionRepository = userRepo.NewSessionRepo(db)
+		identityRepository = userRepo.NewIdentityRepo(db)
+		attributeRepository = userRepo.NewAttributeRepo(db)
+		fileRepository = userRepo.NewFileRepo(db)
+		uploadSessionRepository = userRepo.NewUploadSessionRepo(db)
+		usageRepository = userRepo.NewUsageRepo(db)
 	case "postgres":
-		userRepository := userPostgresRepo.NewUserRepo(db)
-		userUsecase := userUC.New(userRepository, authClient, keycloakService)
-		userHandler = handler.New(userUsecase, oidcProvider)
+		userRepository = userPostgresRepo.NewUserRepo(db)
+		sessionRepository = userPostgresRepo.NewSessionRepo(db)
+		identityRepository = userPostgresRepo.NewIdentityRepo(db)
+		attributeRepository = userPostgresRepo.NewAttributeRepo(db)
+		fileRepository = userPostgresRepo.NewFileRepo(db)
+		uploadSessionRepository = userPostgresRepo.NewUploadSessionRepo(db)
+		usageRepository = userPostgresRepo.NewUsageRepo(db)
+	case "sqlserver":
+		userRepository = userSQLServerRepo.NewUserRepo(db)
+		sessionRepository = userSQLServerRepo.NewSessionRepo(db)
+		identityRepository = userSQLServerRepo.NewIdentityRepo(db)
+		attributeRepository = userSQLServerRepo.NewAttributeRepo(db)
+		fileRepository = userSQLServerRepo.NewFileRepo(db)
+		uploadSessionRepository = userSQLServerRepo.NewUploadSessionRepo(db)
+		usageRepository = userSQLServerRepo.NewUsageRepo(db)
 	default:
 		log.Fatal("Unsupported database driver: " + cfg.DB.Driver)
 	}
 
+	userUsecase := userUC.New(userRepository, userUC.Deps{
+		AuthService:   authService,
+		Keycloak:      keycloakService,
+		Sessions:      sessionRepository,
+		Identities:    identityRepository,
+		Attributes:    attributeRepository,
+		AvatarStorage: publicStorage,
+		AvatarChecks:  avatarChecks,
+		Quarantine:    uploadQuarantine,
+		Tx:            sqltx.NewManager(db),
+	})
+	userHandler := handler.New(userUsecase, oidcProvider, socialProviders, cfg.OAuth.FrontendCallbackURL)
+	storageHandler := storageDelivery.New(signer, servedBuckets, cfg.Storage.MaxPutSize)
+
+	fileUsecase := fileUC.New(fileRepository, uploadSessionRepository, usageRepository, privateStorage, fileChecks, uploadQuarantine, fileUC.Options{
+		MaxSize:       cfg.Files.MaxSize,
+		ReadRoles:     cfg.Files.ReadRoles,
+		URLExpiry:     cfg.Files.URLExpiry,
+		MaxUploadSize: cfg.Files.MaxUploadSize,
+		PartSize:      cfg.Files.PartSize,
+		SessionTTL:    cfg.Files.SessionTTL,
+		Quota:         cfg.Files.Quota,
+	})
+	fileHandler := fileDelivery.New(fileUsecase)
+
+	// Purge of soft-deleted users (optional)
+	if cfg.UserPurge.RetentionDays > 0 {
+		retention := time.Duration(cfg.UserPurge.RetentionDays) * 24 * time.Hour
+		scheduler.Every(ctx, "user-purge", cfg.UserPurge.Interval, func(ctx context.Context) error {
+			_, err := userUsecase.PurgeDeleted(ctx, retention)
+			return err
+		})
+	}
+
+	// Scheduled user deactivations
+	scheduler.Every(ctx, "user-deactivation", cfg.UserDeactivation.Interval, func(ctx context.Context) error {
+		_, err := userUsecase.DeactivateDue(ctx)
+		return err
+	})
+
+	// Abort of the upload sessions left uncompleted
+	scheduler.Every(ctx, "upload-cleanup", cfg.Files.CleanupInterval, func(ctx context.Context) error {
+		_, err := fileUsecase.CleanupExpiredUploads(ctx)
+		return err
+	})
+
+	// Keycloak admin event poller (optional)
+	if cfg.Keycloak.URL != "" && cfg.Keycloak.AdminEventsPollSeconds > 0 {
+		since := time.Now().Add(-cfg.Keycloak.AdminEventsLookback)
+		scheduler.Every(ctx, "keycloak-admin-events", time.Duration(cfg.Keycloak.AdminEventsPollSeconds)*time.Second, func(ctx context.Context) error {
+			next, err := userUsecase.SyncKeycloakEvents(ctx, since)
+			since = next
+			return err
+		})
+	}
+
 	// initialize APM
 	apm.Init(cfg)
 
@@ -114,27 +249,25 @@ func Run() {
 		middleware.ErrorHandler(cfg),
 	)
 
-	RegisterRoutes(r, userHandler, keycloakService)
+	RegisterRoutes(r, userHandler, storageHandler, fileHandler, keycloakService, sessionRepository, userRepository)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Ensure roles exist
-	seedRoles(db)
+	seedRoles(db, cfg.DB.Driver)
 
 	log.Println("Running on port", cfg.AppPort)
 	r.Run(":" + cfg.AppPort)
 }
 
-func seedRoles(db *sql.DB) {
-	roles := []string{"USER", "ADMIN"}
-	for _, role := range roles {
-		var id string
-		err := db.QueryRow("SELECT id FROM roles WHERE name = ?", role).Scan(&id)
-		if err != nil {
-			log.Printf("[Seed] Role %s missing, creating...", role)
-			_, err = db.Exec("INSERT INTO roles (id, name) VALUES (?, ?)", uuid.New().String(), role)
-			if err != nil {
-				log.Printf("[Seed] Failed to create role %s: %v", role, err)
-			}
-		}
+// seedRoles creates the missing default roles with the seeder of the driver,
+// the placeholders of the queries depending on it.
+func seedRoles(db *sql.DB, driver string) {
+	switch driver {
+	case "mysql":
+		seeder.SeedRolesMysql(db)
+	case "postgres":
+		seeder.SeedRolesPostgres(db)
+	case "sqlserver":
+		seeder.SeedRolesSQLServer(db)
 	}
 }
@@ -2,6 +2,8 @@ package bootstrap
 
 import (
 	httpDelivery "github.com/afandimsr/go-gin-api/internal/delivery/http"
+	fileDelivery "github.com/afandimsr/go-gin-api/internal/delivery/http/handler/file"
+	storageDelivery "github.com/afandimsr/go-gin-api/internal/delivery/http/handler/storage"
 	handler "github.com/afandimsr/go-gin-api/internal/delivery/http/handler/user"
 	"github.com/afandimsr/go-gin-api/internal/domain/user"
 	"github.com/gin-gonic/gin"
@@ -10,7 +12,11 @@ import (
 func RegisterRoutes(
 	r *gin.Engine,
 	userHandler *handler.UserHandler,
+	storageHandler *storageDelivery.StorageHandler,
+	fileHandler *fileDelivery.FileHandler,
 	ks user.KeycloakService,
+	sessions user.SessionRepository,
+	userRepo user.UserRepository,
 ) {
-	httpDelivery.RegisterRoutes(r, userHandler, ks)
+	httpDelivery.RegisterRoutes(r, userHandler, storageHandler, fileHandler, ks, sessions, userRepo)
 }
@@ -0,0 +1,33 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/local"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/memory"
+	s3infra "github.com/afandimsr/go-gin-api/internal/infrastructure/storage/s3"
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+)
+
+// newStorage creates the backend of the bucket name according to its driver.
+// served tells whether the bucket is served by the storage routes of the API.
+func newStorage(ctx context.Context, name string, cfg config.S3Config, signer *signedurl.Signer) (store storage.IS3Service, served bool, err error) {
+	switch cfg.Driver {
+	case config.S3DriverS3, "":
+		client, err := s3infra.New(ctx, cfg)
+		if err != nil {
+			return nil, false, err
+		}
+		return s3infra.NewUploader(client, cfg.Bucket, cfg.ObjectBaseURL()), false, nil
+	case config.S3DriverLocal:
+		store, err := local.New(name, cfg.Path, cfg.PublicURL, signer)
+		return store, true, err
+	case config.S3DriverMemory:
+		return memory.New(name, cfg.PublicURL, signer), true, nil
+	default:
+		return nil, false, fmt.Errorf("unknown storage driver: %s", cfg.Driver)
+	}
+}
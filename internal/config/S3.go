@@ -1,10 +1,45 @@
 package config
 
+import "strings"
+
+// Storage drivers of a bucket. The local and memory drivers serve their
+// objects and presigned URLs through the API, see StorageConfig.
+const (
+	S3DriverS3     = "s3"
+	S3DriverLocal  = "local"
+	S3DriverMemory = "memory"
+)
+
 type S3Config struct {
+	Driver    string `mapstructure:"driver"`
 	Endpoint  string `mapstructure:"endpoint"`
 	Region    string `mapstructure:"region"`
 	AccessKey string `mapstructure:"access_key"`
 	SecretKey string `mapstructure:"secret_key"`
 	Bucket    string `mapstructure:"bucket"`
 	UseSSL    bool   `mapstructure:"use_ssl"`
+	// PublicURL is the base URL objects are served from (CDN, reverse proxy),
+	// {endpoint}/{bucket} when empty
+	PublicURL string `mapstructure:"public_url"`
+	// Path is the root directory of the local driver
+	Path string `mapstructure:"path"`
+}
+
+// StorageConfig configures the API routes serving the buckets of the local
+// and memory drivers.
+type StorageConfig struct {
+	// URL is the public base of the routes, objects are at {URL}/{bucket}/{key}
+	URL string
+	// SigningSecret signs the emulated presigned URLs
+	SigningSecret string
+	// MaxPutSize bounds the objects uploaded through an emulated presigned URL
+	MaxPutSize int64
+}
+
+// ObjectBaseURL returns the URL prefix of the objects of the bucket.
+func (c S3Config) ObjectBaseURL() string {
+	if c.PublicURL != "" {
+		return strings.TrimRight(c.PublicURL, "/")
+	}
+	return strings.TrimRight(c.Endpoint, "/") + "/" + c.Bucket
 }
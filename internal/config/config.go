@@ -2,6 +2,9 @@ package config
 
 import (
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -12,13 +15,22 @@ type Config struct {
 	AppPort            string
 	AppEnv             string
 	JWTSecret          string
+	CursorSecret       string
 	ClientAuthURL      string
 	CorsAllowedOrigins string
 
-	DB         DBConfig
-	Keycloak   KeycloakConfig
-	S3         map[string]S3Config `mapstructure:"s3"`
-	ElasticApm ElasticApmConfig
+	DB               DBConfig
+	Keycloak         KeycloakConfig
+	External         ExternalHTTPConfig
+	Auth             AuthConfig
+	UserPurge        UserPurgeConfig
+	UserDeactivation UserDeactivationConfig
+	OAuth            OAuthConfig
+	S3               map[string]S3Config `mapstructure:"s3"`
+	Storage          StorageConfig
+	Files            FilesConfig
+	ClamAV           ClamAVConfig
+	ElasticApm       ElasticApmConfig
 }
 
 type DBConfig struct {
@@ -40,6 +52,131 @@ type KeycloakConfig struct {
 	ClientSecret  string
 	AdminUser     string
 	AdminPassword string
+	// AdminEventsPollSeconds enables the admin event poller when greater than zero
+	AdminEventsPollSeconds int
+	// AdminEventsLookback is how far back the poller starts on boot, so events
+	// recorded while the service was down are still synced
+	AdminEventsLookback time.Duration
+}
+
+// ExternalHTTPConfig tunes the HTTP clients used for external services
+// (auth service, Keycloak). Each upstream gets its own circuit breaker.
+type ExternalHTTPConfig struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+// AuthConfig configures the password login provider chain. Providers lists the
+// enabled providers ("http", "ldap", "local") in the order they are tried.
+type AuthConfig struct {
+	Providers []string
+	HTTP      HTTPAuthProviderConfig
+	LDAP      LDAPAuthProviderConfig
+}
+
+// HTTPAuthProviderConfig maps the login request and response of an external auth service.
+// Response fields are dot-separated paths into the JSON body, e.g. "data.roles".
+type HTTPAuthProviderConfig struct {
+	URL           string
+	Method        string
+	ContentType   string // json | form
+	EmailField    string
+	PasswordField string
+	SuccessStatus int
+	SuccessField  string // optional, must be true when set
+	NameField     string // optional
+	RolesField    string // optional
+}
+
+type LDAPAuthProviderConfig struct {
+	URL            string
+	StartTLS       bool
+	BindDN         string
+	BindPassword   string
+	BaseDN         string
+	UserFilter     string // %s is replaced by the escaped email
+	NameAttribute  string
+	GroupAttribute string
+	// GroupRoleMap maps group DNs (case-insensitive) to local role names
+	GroupRoleMap map[string]string
+	Timeout      time.Duration
+}
+
+// UserPurgeConfig schedules the permanent deletion of soft-deleted users.
+type UserPurgeConfig struct {
+	// RetentionDays keeps deleted users restorable for this many days, 0 disables the purge
+	RetentionDays int
+	Interval      time.Duration
+}
+
+// UserDeactivationConfig controls how often scheduled user deactivations are applied.
+type UserDeactivationConfig struct {
+	Interval time.Duration
+}
+
+// OAuthConfig configures the social login providers. Providers is keyed by the
+// provider name used in /auth/{provider}/login.
+type OAuthConfig struct {
+	// RedirectBaseURL is the public API base, callbacks go to {base}/auth/{provider}/callback
+	RedirectBaseURL string
+	// FrontendCallbackURL receives the issued token as ?token=
+	FrontendCallbackURL string
+	Providers           map[string]OAuthProviderConfig
+}
+
+// OAuthProviderConfig describes an OIDC provider (IssuerURL set, endpoints are
+// discovered) or a plain OAuth2 provider with a userinfo endpoint. The "google"
+// and "github" presets only need the client credentials.
+type OAuthProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+	IssuerURL    string
+	AuthURL      string
+	TokenURL     string
+	UserInfoURL  string
+	Scopes       []string
+	// TrustEmail links accounts by email even when the provider does not mark it verified
+	TrustEmail bool
+}
+
+// FilesConfig controls the file attachments stored in the private bucket.
+type FilesConfig struct {
+	MaxSize int64
+	// ReadRoles may download the files of every user, owners always can
+	ReadRoles []string
+	// URLExpiry is the lifetime of the presigned download URLs
+	URLExpiry time.Duration
+	// MaxUploadSize bounds the files uploaded in parts through upload sessions
+	MaxUploadSize int64
+	PartSize      int64
+	// SessionTTL is the time an upload session may take to complete, expired
+	// sessions are aborted every CleanupInterval
+	SessionTTL      time.Duration
+	CleanupInterval time.Duration
+	// AllowedExtensions and AllowedTypes restrict the uploaded files by name
+	// and by the type detected from their content, "image/*" allowing every
+	// image type
+	AllowedExtensions []string
+	AllowedTypes      []string
+	// MaxImageWidth and MaxImageHeight bound the dimensions of uploaded images,
+	// files and avatars
+	MaxImageWidth  int
+	MaxImageHeight int
+	// Quota bounds the bytes stored by a user without a custom quota, 0 is
+	// unlimited
+	Quota int64
+}
+
+// ClamAVConfig locates the clamd scanning uploads for malware, none when
+// Address is empty.
+type ClamAVConfig struct {
+	// Address is "tcp://host:port" or "unix:///path/to/clamd.sock"
+	Address string
+	Timeout time.Duration
 }
 
 type ElasticApmConfig struct {
@@ -68,6 +205,7 @@ func Load() *Config {
 		AppPort:            getEnv("APP_PORT", "8080"),
 		AppEnv:             getEnv("APP_ENV", "development"),
 		JWTSecret:          getEnv("JWT_SECRET", "default-secret"),
+		CursorSecret:       getEnv("CURSOR_SECRET", getEnv("JWT_SECRET", "default-secret")),
 		ClientAuthURL:      getEnv("CLIENT_AUTH_URL", ""),
 		CorsAllowedOrigins: getEnv("CORS_ALLOWED_ORIGINS", "*"),
 
@@ -89,24 +227,104 @@ func Load() *Config {
 			ClientSecret:  getEnv("KEYCLOAK_CLIENT_SECRET", ""),
 			AdminUser:     getEnv("KEYCLOAK_ADMIN_USER", ""),
 			AdminPassword: getEnv("KEYCLOAK_ADMIN_PASSWORD", ""),
+
+			AdminEventsPollSeconds: getEnvInt("KEYCLOAK_ADMIN_EVENTS_POLL_SECONDS", 0),
+			AdminEventsLookback:    time.Duration(getEnvIntOrZero("KEYCLOAK_ADMIN_EVENTS_LOOKBACK_MINUTES", 60)) * time.Minute,
+		},
+		External: ExternalHTTPConfig{
+			Timeout:          time.Duration(getEnvInt("EXTERNAL_HTTP_TIMEOUT_MS", 5000)) * time.Millisecond,
+			MaxRetries:       getEnvIntOrZero("EXTERNAL_HTTP_MAX_RETRIES", 2),
+			BaseBackoff:      time.Duration(getEnvInt("EXTERNAL_HTTP_BACKOFF_MS", 100)) * time.Millisecond,
+			MaxBackoff:       time.Duration(getEnvInt("EXTERNAL_HTTP_MAX_BACKOFF_MS", 2000)) * time.Millisecond,
+			FailureThreshold: getEnvIntOrZero("EXTERNAL_CIRCUIT_FAILURE_THRESHOLD", 5),
+			OpenTimeout:      time.Duration(getEnvInt("EXTERNAL_CIRCUIT_OPEN_SECONDS", 30)) * time.Second,
+		},
+		Auth: AuthConfig{
+			Providers: getEnvList("AUTH_PROVIDERS", "http,local"),
+			HTTP: HTTPAuthProviderConfig{
+				URL:           getEnv("AUTH_HTTP_URL", legacyAuthURL()),
+				Method:        getEnv("AUTH_HTTP_METHOD", "POST"),
+				ContentType:   getEnv("AUTH_HTTP_CONTENT_TYPE", "json"),
+				EmailField:    getEnv("AUTH_HTTP_EMAIL_FIELD", "email"),
+				PasswordField: getEnv("AUTH_HTTP_PASSWORD_FIELD", "password"),
+				SuccessStatus: getEnvInt("AUTH_HTTP_SUCCESS_STATUS", 200),
+				SuccessField:  getEnv("AUTH_HTTP_SUCCESS_FIELD", ""),
+				NameField:     getEnv("AUTH_HTTP_NAME_FIELD", ""),
+				RolesField:    getEnv("AUTH_HTTP_ROLES_FIELD", ""),
+			},
+			LDAP: LDAPAuthProviderConfig{
+				URL:            getEnv("LDAP_URL", ""),
+				StartTLS:       getEnvBool("LDAP_START_TLS", false),
+				BindDN:         getEnv("LDAP_BIND_DN", ""),
+				BindPassword:   getEnv("LDAP_BIND_PASSWORD", ""),
+				BaseDN:         getEnv("LDAP_BASE_DN", ""),
+				UserFilter:     getEnv("LDAP_USER_FILTER", "(mail=%s)"),
+				NameAttribute:  getEnv("LDAP_NAME_ATTRIBUTE", "cn"),
+				GroupAttribute: getEnv("LDAP_GROUP_ATTRIBUTE", "memberOf"),
+				GroupRoleMap:   getEnvMap("LDAP_GROUP_ROLE_MAP"),
+				Timeout:        time.Duration(getEnvInt("LDAP_TIMEOUT_SECONDS", 5)) * time.Second,
+			},
+		},
+		UserPurge: UserPurgeConfig{
+			RetentionDays: getEnvInt("USER_PURGE_RETENTION_DAYS", 0),
+			Interval:      time.Duration(getEnvInt("USER_PURGE_INTERVAL_MINUTES", 60)) * time.Minute,
+		},
+		UserDeactivation: UserDeactivationConfig{
+			Interval: time.Duration(getEnvInt("USER_DEACTIVATION_INTERVAL_MINUTES", 5)) * time.Minute,
+		},
+		OAuth: OAuthConfig{
+			RedirectBaseURL:     getEnv("OAUTH_REDIRECT_BASE_URL", "http://localhost:"+getEnv("APP_PORT", "8080")+"/api/v1"),
+			FrontendCallbackURL: getEnv("OAUTH_FRONTEND_CALLBACK_URL", "http://localhost:5173/auth/callback"),
+			Providers:           loadOAuthProviders(),
 		},
 		S3: map[string]S3Config{
 			"public": {
+				Driver:    getEnv("S3_PUBLIC_DRIVER", S3DriverS3),
 				Endpoint:  getEnv("S3_PUBLIC_ENDPOINT", ""),
 				Region:    getEnv("S3_PUBLIC_REGION", ""),
 				AccessKey: getEnv("S3_PUBLIC_ACCESS_KEY", ""),
 				SecretKey: getEnv("S3_PUBLIC_SECRET_KEY", ""),
 				Bucket:    getEnv("S3_PUBLIC_BUCKET", ""),
 				UseSSL:    getEnvBool("S3_PUBLIC_USE_SSL", false),
+				PublicURL: getEnv("S3_PUBLIC_URL", ""),
+				Path:      getEnv("S3_PUBLIC_PATH", "./storage/public"),
+			},
+			"private": {
+				Driver:    getEnv("S3_PRIVATE_DRIVER", S3DriverS3),
+				Endpoint:  getEnv("S3_PRIVATE_ENDPOINT", ""),
+				Region:    getEnv("S3_PRIVATE_REGION", ""),
+				AccessKey: getEnv("S3_PRIVATE_ACCESS_KEY", ""),
+				SecretKey: getEnv("S3_PRIVATE_SECRET_KEY", ""),
+				Bucket:    getEnv("S3_PRIVATE_BUCKET", ""),
+				UseSSL:    getEnvBool("S3_PRIVATE_USE_SSL", false),
+				Path:      getEnv("S3_PRIVATE_PATH", "./storage/private"),
 			},
-			// "private": {
-			// 	Endpoint:  getEnv("S3_PRIVATE_ENDPOINT", ""),
-			// 	Region:    getEnv("S3_PRIVATE_REGION", ""),
-			// 	AccessKey: getEnv("S3_PRIVATE_ACCESS_KEY", ""),
-			// 	SecretKey: getEnv("S3_PRIVATE_SECRET_KEY", ""),
-			// 	Bucket:    getEnv("S3_PRIVATE_BUCKET", ""),
-			// 	UseSSL:    getEnvBool("S3_PRIVATE_USE_SSL", false),
-			// },
+		},
+		Files: FilesConfig{
+			MaxSize:   int64(getEnvInt("FILES_MAX_SIZE_MB", 2)) << 20,
+			ReadRoles: getEnvList("FILES_READ_ROLES", "ADMIN"),
+			URLExpiry: time.Duration(getEnvInt("FILES_URL_EXPIRY_SECONDS", 300)) * time.Second,
+
+			MaxUploadSize:   int64(getEnvInt("FILES_MAX_UPLOAD_SIZE_MB", 5120)) << 20,
+			PartSize:        int64(getEnvInt("FILES_PART_SIZE_MB", 8)) << 20,
+			SessionTTL:      time.Duration(getEnvInt("FILES_UPLOAD_SESSION_TTL_HOURS", 24)) * time.Hour,
+			CleanupInterval: time.Duration(getEnvInt("FILES_UPLOAD_CLEANUP_INTERVAL_MINUTES", 60)) * time.Minute,
+
+			AllowedExtensions: getEnvList("FILES_ALLOWED_EXTENSIONS", ".pdf,.png,.jpg,.jpeg,.gif,.webp,.txt,.csv,.docx,.xlsx,.pptx,.zip,.mp4"),
+			AllowedTypes:      getEnvList("FILES_ALLOWED_TYPES", "application/pdf,image/*,text/plain,application/zip,video/mp4"),
+			MaxImageWidth:     getEnvInt("FILES_MAX_IMAGE_WIDTH", 10000),
+			MaxImageHeight:    getEnvInt("FILES_MAX_IMAGE_HEIGHT", 10000),
+
+			Quota: int64(getEnvInt("FILES_QUOTA_MB", 0)) << 20,
+		},
+		ClamAV: ClamAVConfig{
+			Address: getEnv("CLAMAV_ADDRESS", ""),
+			Timeout: time.Duration(getEnvInt("CLAMAV_TIMEOUT_SECONDS", 60)) * time.Second,
+		},
+		Storage: StorageConfig{
+			URL:           getEnv("STORAGE_URL", "http://localhost:"+getEnv("APP_PORT", "8080")+"/api/v1/storage"),
+			SigningSecret: getEnv("STORAGE_SIGNING_SECRET", getEnv("JWT_SECRET", "default-secret")),
+			MaxPutSize:    int64(getEnvInt("STORAGE_MAX_PUT_SIZE_MB", 5120)) << 20,
 		},
 		ElasticApm: ElasticApmConfig{
 			ServerURL:        getEnv("ELASTIC_APM_SERVER_URL", ""),
@@ -136,6 +354,19 @@ func getEnvInt(key string, defaultVal int) int {
 	return defaultVal
 }
 
+// getEnvIntOrZero is getEnvInt for settings where 0 is meaningful: it returns
+// the value of key, 0 included, or defaultVal when it is unset or not an integer.
+func getEnvIntOrZero(key string, defaultVal int) int {
+	if !viper.IsSet(key) {
+		return defaultVal
+	}
+	val, err := strconv.Atoi(strings.TrimSpace(viper.GetString(key)))
+	if err != nil {
+		return defaultVal
+	}
+	return val
+}
+
 func getEnvBool(key string, defaultVal bool) bool {
 	if val := viper.GetBool(key); val != false {
 		return val
@@ -143,8 +374,85 @@ func getEnvBool(key string, defaultVal bool) bool {
 	return defaultVal
 }
 
+// legacyAuthURL keeps CLIENT_AUTH_URL working as the HTTP auth provider endpoint.
+func legacyAuthURL() string {
+	if base := getEnv("CLIENT_AUTH_URL", ""); base != "" {
+		return strings.TrimRight(base, "/") + "/login"
+	}
+	return ""
+}
+
+// getEnvList reads a comma-separated list, e.g. "http,ldap,local".
+func getEnvList(key string, defaultVal string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, defaultVal), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvMap reads "key:value" pairs separated by semicolons. The last colon of a
+// pair separates the key from the value, so keys may contain colons and commas.
+func getEnvMap(key string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(getEnv(key, ""), ";") {
+		i := strings.LastIndex(pair, ":")
+		if i <= 0 {
+			continue
+		}
+		m[strings.TrimSpace(pair[:i])] = strings.TrimSpace(pair[i+1:])
+	}
+	return m
+}
+
+// loadOAuthProviders reads OAUTH_PROVIDERS (e.g. "google,github") and the
+// OAUTH_<NAME>_* variables of each listed provider.
+func loadOAuthProviders() map[string]OAuthProviderConfig {
+	providers := make(map[string]OAuthProviderConfig)
+	for _, name := range getEnvList("OAUTH_PROVIDERS", "") {
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		providers[strings.ToLower(name)] = OAuthProviderConfig{
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			IssuerURL:    getEnv(prefix+"ISSUER_URL", ""),
+			AuthURL:      getEnv(prefix+"AUTH_URL", ""),
+			TokenURL:     getEnv(prefix+"TOKEN_URL", ""),
+			UserInfoURL:  getEnv(prefix+"USERINFO_URL", ""),
+			Scopes:       getEnvList(prefix+"SCOPES", ""),
+			TrustEmail:   getEnvBool(prefix+"TRUST_EMAIL", false),
+		}
+	}
+	return providers
+}
+
 func validate(cfg *Config) {
 	if cfg.DB.Name == "" {
 		log.Fatal("DB_NAME is required")
 	}
+
+	// 0 disables retries and the circuit breaker, negative values are mistakes
+	if cfg.External.MaxRetries < 0 {
+		log.Fatal("EXTERNAL_HTTP_MAX_RETRIES must not be negative")
+	}
+	if cfg.External.FailureThreshold < 0 {
+		log.Fatal("EXTERNAL_CIRCUIT_FAILURE_THRESHOLD must not be negative")
+	}
+	if cfg.Keycloak.AdminEventsPollSeconds < 0 {
+		log.Fatal("KEYCLOAK_ADMIN_EVENTS_POLL_SECONDS must not be negative")
+	}
+	if cfg.Keycloak.AdminEventsLookback < 0 {
+		log.Fatal("KEYCLOAK_ADMIN_EVENTS_LOOKBACK_MINUTES must not be negative")
+	}
+	// A scheduled job cannot tick on a non-positive interval
+	if cfg.UserPurge.Interval <= 0 {
+		log.Fatal("USER_PURGE_INTERVAL_MINUTES must be positive")
+	}
+	if cfg.UserDeactivation.Interval <= 0 {
+		log.Fatal("USER_DEACTIVATION_INTERVAL_MINUTES must be positive")
+	}
+	if cfg.Files.CleanupInterval <= 0 {
+		log.Fatal("FILES_UPLOAD_CLEANUP_INTERVAL_MINUTES must be positive")
+	}
 }
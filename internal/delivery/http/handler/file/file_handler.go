@@ -0,0 +1,247 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/afandimsr/go-gin-api/internal/delivery/http/handler/file/request"
+	"github.com/afandimsr/go-gin-api/internal/delivery/http/helper"
+	"github.com/afandimsr/go-gin-api/internal/delivery/http/response"
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/file"
+	"github.com/gin-gonic/gin"
+)
+
+type FileHandler struct {
+	usecase *uc.Usecase
+}
+
+func New(usecase *uc.Usecase) *FileHandler {
+	return &FileHandler{usecase: usecase}
+}
+
+// UploadFile godoc
+// @Summary      Upload a file
+// @Description  Stores the file in the private bucket, owned by the current user. Files failing the content checks are quarantined and rejected with 422, files exceeding the storage quota of the user with 403.
+// @Tags         Files
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData file true "File"
+// @Success      201 {object} response.FileResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      403 {object} response.ErrorSwaggerResponse
+// @Failure      413 {object} response.ErrorSwaggerResponse
+// @Failure      422 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /files [post]
+func (h *FileHandler) UploadFile(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Error(apperror.NewAppError(http.StatusRequestEntityTooLarge, apperror.FileTooLarge, "request body is too large", err))
+			return
+		}
+		c.Error(apperror.BadRequest("file is required", err))
+		return
+	}
+
+	body, err := fileHeader.Open()
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	defer body.Close()
+
+	f, err := h.usecase.Upload(c.Request.Context(), fileHeader.Filename, fileHeader.Header.Get("Content-Type"), fileHeader.Size, body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "file uploaded", f)
+}
+
+// GetFile godoc
+// @Summary      Get a file
+// @Description  Returns the file with a short-lived download URL. Only the owner and users of the authorized roles have access.
+// @Tags         Files
+// @Produce      json
+// @Param        id path string true "File ID"
+// @Success      200 {object} response.FileDownloadResponse
+// @Failure      403 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /files/{id} [get]
+func (h *FileHandler) GetFile(c *gin.Context) {
+	download, err := h.usecase.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The URL expires, do not let it outlive its validity in a cache
+	c.Header("Cache-Control", "no-store")
+	response.Success(c, http.StatusOK, "success", download)
+}
+
+// InitiateUpload godoc
+// @Summary      Start a resumable upload
+// @Description  Starts the upload of a file in parts of part_size bytes, the last part holding the remainder. The session expires at expires_at and reserves its size in the storage quota of the user until then.
+// @Tags         Files
+// @Accept       json
+// @Produce      json
+// @Param        body body request.InitiateUploadRequest true "File to upload"
+// @Success      201 {object} response.UploadSessionResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      403 {object} response.ErrorSwaggerResponse
+// @Failure      413 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /files/uploads [post]
+func (h *FileHandler) InitiateUpload(c *gin.Context) {
+	var req request.InitiateUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Validation(err).WithCode(apperror.ValidationError))
+		return
+	}
+
+	session, err := h.usecase.InitiateUpload(c.Request.Context(), req.Name, req.ContentType, req.Size, req.Checksum)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "upload started", session)
+}
+
+// GetUpload godoc
+// @Summary      Get a resumable upload
+// @Description  Returns the upload session with the parts uploaded so far, to resume an interrupted upload.
+// @Tags         Files
+// @Produce      json
+// @Param        id path string true "Upload session ID"
+// @Success      200 {object} response.UploadSessionResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /files/uploads/{id} [get]
+func (h *FileHandler) GetUpload(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	session, err := h.usecase.GetUpload(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success", session)
+}
+
+// UploadPart godoc
+// @Summary      Upload a part of a resumable upload
+// @Description  The body is the raw content of the part. Uploading a part again replaces it.
+// @Tags         Files
+// @Accept       octet-stream
+// @Produce      json
+// @Param        id     path   string true  "Upload session ID"
+// @Param        number path   int    true  "Part number, from 1"
+// @Param        X-Checksum-Sha256 header string false "SHA-256 of the part, hex-encoded"
+// @Success      200 {object} response.UploadedPartResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      409 {object} response.ErrorSwaggerResponse
+// @Failure      413 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /files/uploads/{id}/parts/{number} [put]
+func (h *FileHandler) UploadPart(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	number, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid part number", err).WithCode(apperror.ValidationError))
+		return
+	}
+
+	part, err := h.usecase.UploadPart(c.Request.Context(), id, number, c.GetHeader("X-Checksum-Sha256"), c.Request.Body)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "part uploaded", part)
+}
+
+// CompleteUpload godoc
+// @Summary      Complete a resumable upload
+// @Description  Assembles the parts into a file of the same id as the session, verifying the checksum given when the upload started.
+// @Tags         Files
+// @Produce      json
+// @Param        id path string true "Upload session ID"
+// @Success      201 {object} response.FileResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      409 {object} response.ErrorSwaggerResponse
+// @Failure      422 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /files/uploads/{id}/complete [post]
+func (h *FileHandler) CompleteUpload(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	f, err := h.usecase.CompleteUpload(c.Request.Context(), id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "file uploaded", f)
+}
+
+// AbortUpload godoc
+// @Summary      Abort a resumable upload
+// @Description  Cancels the upload and discards its parts.
+// @Tags         Files
+// @Produce      json
+// @Param        id path string true "Upload session ID"
+// @Success      200 {object} response.UploadAbortedResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      409 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /files/uploads/{id} [delete]
+func (h *FileHandler) AbortUpload(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.usecase.AbortUpload(c.Request.Context(), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "upload aborted", nil)
+}
+
+// formOverhead leaves room for the multipart encoding of an uploaded file.
+const formOverhead = 64 << 10
+
+// UploadBodyLimit returns the largest request body UploadFile accepts.
+func (h *FileHandler) UploadBodyLimit() int64 {
+	return h.usecase.MaxSize() + formOverhead
+}
+
+// PartBodyLimit returns the largest request body UploadPart accepts.
+func (h *FileHandler) PartBodyLimit() int64 {
+	return h.usecase.PartSize()
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/afandimsr/go-gin-api/internal/delivery/http/handler/file/request"
+	"github.com/afandimsr/go-gin-api/internal/delivery/http/helper"
+	"github.com/afandimsr/go-gin-api/internal/delivery/http/response"
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/gin-gonic/gin"
+)
+
+// GetMyStorage godoc
+// @Summary      Get my storage usage
+// @Description  Returns the bytes stored by the current user, the bytes reserved by their pending uploads and their quota (0 is unlimited).
+// @Tags         Me
+// @Produce      json
+// @Success      200 {object} response.StorageUsageResponse
+// @Failure      401 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /me/storage [get]
+func (h *FileHandler) GetMyStorage(c *gin.Context) {
+	usage, err := h.usecase.StorageUsage(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success", usage)
+}
+
+// GetStorageReport godoc
+// @Summary      Report storage usage
+// @Description  Lists the users storing files, largest usage first, with their quota (0 is unlimited).
+// @Tags         Users
+// @Produce      json
+// @Param        page  query int false "Page number" default(1) minimum(1)
+// @Param        limit query int false "Page size" default(10) minimum(1) maximum(100)
+// @Success      200 {object} response.PaginatedStorageUsageResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/storage [get]
+func (h *FileHandler) GetStorageReport(c *gin.Context) {
+	page, limit, err := helper.PageParams(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	usages, total, err := h.usecase.StorageReport(page, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "success", usages, page, limit, total)
+}
+
+// SetStorageQuota godoc
+// @Summary      Set the storage quota of a user
+// @Description  Overrides the default quota of the user, 0 is unlimited. Files already stored are kept when over the quota.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        id   path string                  true "User ID"
+// @Param        body body request.SetQuotaRequest true "Quota"
+// @Success      200 {object} response.StorageUsageResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/{id}/storage-quota [put]
+func (h *FileHandler) SetStorageQuota(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req request.SetQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Validation(err).WithCode(apperror.ValidationError))
+		return
+	}
+
+	usage, err := h.usecase.SetQuota(id, *req.QuotaBytes)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "storage quota saved", usage)
+}
+
+// DeleteStorageQuota godoc
+// @Summary      Restore the default storage quota of a user
+// @Tags         Users
+// @Produce      json
+// @Param        id path string true "User ID"
+// @Success      200 {object} response.StorageUsageResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/{id}/storage-quota [delete]
+func (h *FileHandler) DeleteStorageQuota(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	usage, err := h.usecase.DeleteQuota(id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "storage quota removed", usage)
+}
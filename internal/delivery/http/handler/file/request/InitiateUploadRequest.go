@@ -0,0 +1,10 @@
+package request
+
+// InitiateUploadRequest is the body of POST /files/uploads.
+type InitiateUploadRequest struct {
+	Name        string `json:"name" binding:"required,max=1024"`
+	ContentType string `json:"content_type" binding:"max=255"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+	// Checksum is the SHA-256 of the file, hex-encoded
+	Checksum string `json:"checksum" binding:"omitempty,len=64,hexadecimal"`
+}
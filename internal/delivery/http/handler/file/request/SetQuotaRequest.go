@@ -0,0 +1,7 @@
+package request
+
+// SetQuotaRequest is the body of PUT /users/{id}/storage-quota.
+type SetQuotaRequest struct {
+	// QuotaBytes bounds the bytes stored by the user, 0 is unlimited
+	QuotaBytes *int64 `json:"quota_bytes" binding:"required,gte=0"`
+}
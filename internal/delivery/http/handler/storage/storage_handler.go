@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+	"github.com/gin-gonic/gin"
+)
+
+// metaHeaderPrefix carries user-defined metadata, as in S3.
+const metaHeaderPrefix = "X-Amz-Meta-"
+
+// Bucket is a bucket served by the API.
+type Bucket struct {
+	Storage storage.IS3Service
+	// Public buckets serve their objects without a signature
+	Public bool
+}
+
+// StorageHandler serves the objects and emulated presigned URLs of the
+// buckets whose driver has no server of its own (local, memory).
+type StorageHandler struct {
+	buckets    map[string]Bucket
+	signer     *signedurl.Signer
+	maxPutSize int64
+}
+
+func New(signer *signedurl.Signer, buckets map[string]Bucket, maxPutSize int64) *StorageHandler {
+	return &StorageHandler{
+		buckets:    buckets,
+		signer:     signer,
+		maxPutSize: maxPutSize,
+	}
+}
+
+// PutBodyLimit returns the largest request body PutObject accepts.
+func (h *StorageHandler) PutBodyLimit() int64 {
+	return h.maxPutSize
+}
+
+// GetObject godoc
+// @Summary      Download a stored object
+// @Description  Objects of public buckets are served as is, the others need a presigned URL.
+// @Tags         Storage
+// @Produce      octet-stream
+// @Param        bucket    path  string true  "Bucket name"
+// @Param        key       path  string true  "Object key"
+// @Param        expires   query int    false "Expiry of the presigned URL (unix time)"
+// @Param        signature query string false "Signature of the presigned URL"
+// @Success      200 {file} file
+// @Failure      403 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Router       /storage/{bucket}/{key} [get]
+func (h *StorageHandler) GetObject(c *gin.Context) {
+	bucketName, key := c.Param("bucket"), strings.TrimPrefix(c.Param("key"), "/")
+	bucket, ok := h.buckets[bucketName]
+	if !ok {
+		c.Error(apperror.NewNotFoundError("bucket not found"))
+		return
+	}
+
+	if !bucket.Public || c.Query(signedurl.ParamSignature) != "" {
+		if err := h.signer.Verify(http.MethodGet, bucketName, key, c.Request.URL.Query()); err != nil {
+			c.Error(apperror.NewForbiddenError(err.Error()))
+			return
+		}
+	}
+
+	body, obj, err := bucket.Storage.Download(c.Request.Context(), key)
+	if err != nil {
+		c.Error(objectError(err))
+		return
+	}
+	defer body.Close()
+
+	headers := map[string]string{
+		"ETag":          strconv.Quote(obj.ETag),
+		"Last-Modified": obj.LastModified.UTC().Format(http.TimeFormat),
+	}
+	if obj.CacheControl != "" {
+		headers["Cache-Control"] = obj.CacheControl
+	}
+	for name, value := range obj.Metadata {
+		headers[metaHeaderPrefix+name] = value
+	}
+
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.DataFromReader(http.StatusOK, obj.Size, contentType, body, headers)
+}
+
+// PutObject godoc
+// @Summary      Upload an object through a presigned URL
+// @Description  Metadata is read from the X-Amz-Meta-* headers. The body must not exceed STORAGE_MAX_PUT_SIZE_MB.
+// @Tags         Storage
+// @Accept       octet-stream
+// @Param        bucket    path  string true "Bucket name"
+// @Param        key       path  string true "Object key"
+// @Param        expires   query int    true "Expiry of the presigned URL (unix time)"
+// @Param        signature query string true "Signature of the presigned URL"
+// @Success      200
+// @Failure      403 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      413 {object} response.ErrorSwaggerResponse
+// @Router       /storage/{bucket}/{key} [put]
+func (h *StorageHandler) PutObject(c *gin.Context) {
+	bucketName, key := c.Param("bucket"), strings.TrimPrefix(c.Param("key"), "/")
+	bucket, ok := h.buckets[bucketName]
+	if !ok {
+		c.Error(apperror.NewNotFoundError("bucket not found"))
+		return
+	}
+
+	if err := h.signer.Verify(http.MethodPut, bucketName, key, c.Request.URL.Query()); err != nil {
+		c.Error(apperror.NewForbiddenError(err.Error()))
+		return
+	}
+
+	opts := []storage.UploadOption{storage.WithContentType(c.ContentType())}
+	if cacheControl := c.GetHeader("Cache-Control"); cacheControl != "" {
+		opts = append(opts, storage.WithCacheControl(cacheControl))
+	}
+	metadata := map[string]string{}
+	for name, values := range c.Request.Header {
+		if strings.HasPrefix(name, metaHeaderPrefix) && len(values) > 0 {
+			metadata[strings.ToLower(strings.TrimPrefix(name, metaHeaderPrefix))] = values[0]
+		}
+	}
+	opts = append(opts, storage.WithMetadata(metadata))
+
+	if err := bucket.Storage.Upload(c.Request.Context(), key, c.Request.Body, opts...); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Error(apperror.NewAppError(http.StatusRequestEntityTooLarge, apperror.BadRequestError, "request body is too large", err))
+			return
+		}
+		c.Error(objectError(err))
+		return
+	}
+
+	obj, err := bucket.Storage.Head(c.Request.Context(), key)
+	if err != nil {
+		c.Error(objectError(err))
+		return
+	}
+	c.Header("ETag", strconv.Quote(obj.ETag))
+	c.Status(http.StatusOK)
+}
+
+func objectError(err error) error {
+	switch {
+	case errors.Is(err, storage.ErrObjectNotFound):
+		return apperror.NotFound("object not found", err)
+	case errors.Is(err, storage.ErrInvalidKey):
+		return apperror.BadRequest("invalid object key", err)
+	default:
+		return apperror.Internal(err)
+	}
+}
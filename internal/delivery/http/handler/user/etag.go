@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/gin-gonic/gin"
+)
+
+// Users are tagged with their version column, e.g. ETag: "3".
+func etag(version int) string {
+	return strconv.Quote(strconv.Itoa(version))
+}
+
+// ifMatchVersion returns the version required by the If-Match header, or 0 when
+// the header is absent or "*". Weak or malformed tags can never match (RFC 9110
+// uses strong comparison for If-Match) and fail the precondition right away.
+func ifMatchVersion(c *gin.Context) (int, error) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, nil
+	}
+
+	unquoted, err := strconv.Unquote(header)
+	if err == nil {
+		if version, err := strconv.Atoi(unquoted); err == nil && version > 0 {
+			return version, nil
+		}
+	}
+
+	return 0, apperror.NewAppError(
+		http.StatusPreconditionFailed,
+		apperror.PreconditionFailed,
+		"If-Match does not match the current version",
+		nil,
+	)
+}
@@ -0,0 +1,39 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+const exportFormatJSONL = "jsonl"
+
+// exportColumns is the header row of CSV and XLSX exports. roles uses the
+// separator accepted by the import.
+var exportColumns = []string{
+	"id", "name", "email", "roles", "is_active", "keycloak_id",
+	"created_at", "created_by", "updated_at", "updated_by", "deleted_at",
+}
+
+func exportRow(u user.User) []string {
+	deletedAt := ""
+	if u.DeletedAt != nil {
+		deletedAt = u.DeletedAt.UTC().Format(time.RFC3339)
+	}
+
+	return []string{
+		u.ID,
+		u.Name,
+		u.Email,
+		strings.Join(u.Roles, ";"),
+		strconv.FormatBool(u.IsActive),
+		u.KeycloakID,
+		u.CreatedAt.UTC().Format(time.RFC3339),
+		u.CreatedBy,
+		u.UpdatedAt.UTC().Format(time.RFC3339),
+		u.UpdatedBy,
+		deletedAt,
+	}
+}
@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// The state and nonce of a social login are kept in short-lived cookies scoped
+// to the auth routes, so callbacks can be validated without server-side storage.
+const (
+	oauthStateCookie  = "oauth_state"
+	oauthNonceCookie  = "oauth_nonce"
+	oauthCookiePath   = "/api/v1/auth"
+	oauthCookieMaxAge = 600
+)
+
+func setOAuthCookie(c *gin.Context, name, value string, maxAge int) {
+	// Lax so the cookie is sent on the top-level redirect back from the provider
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(name, value, maxAge, oauthCookiePath, "", c.Request.TLS != nil, true)
+}
+
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,9 @@
+package request
+
+// BulkUsersRequest is the body of POST /users/bulk. role is required by the
+// add-role and remove-role actions.
+type BulkUsersRequest struct {
+	Action string   `json:"action" binding:"required,oneof=activate deactivate delete add-role remove-role"`
+	IDs    []string `json:"ids" binding:"required,min=1,dive,uuid"`
+	Role   string   `json:"role"`
+}
@@ -0,0 +1,9 @@
+package request
+
+import "time"
+
+// DeactivateUserRequest is the optional body of POST /users/:id/deactivate.
+// Without at the user is deactivated right away.
+type DeactivateUserRequest struct {
+	At *time.Time `json:"at" example:"2026-12-31T17:00:00Z"`
+}
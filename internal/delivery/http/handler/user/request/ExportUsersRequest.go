@@ -0,0 +1,8 @@
+package request
+
+// ExportUsersRequest holds the query parameters of GET /users/export, the
+// filters and sort being those of GET /users.
+type ExportUsersRequest struct {
+	ListUsersRequest
+	Format string `form:"format" binding:"omitempty,oneof=csv xlsx jsonl"`
+}
@@ -0,0 +1,7 @@
+package request
+
+// ImportUsersRequest holds the query parameters of POST /users/import.
+type ImportUsersRequest struct {
+	DryRun    bool `form:"dry_run"`
+	BatchSize int  `form:"batch_size" binding:"omitempty,min=1,max=1000"`
+}
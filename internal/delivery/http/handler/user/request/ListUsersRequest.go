@@ -0,0 +1,48 @@
+package request
+
+import (
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// ListUsersRequest holds the filter and sort query parameters of GET /users.
+type ListUsersRequest struct {
+	Search      string    `form:"search"`
+	Email       string    `form:"email"`
+	Role        string    `form:"role"`
+	IsActive    *bool     `form:"is_active"`
+	CreatedFrom time.Time `form:"created_from" time_format:"2006-01-02"`
+	CreatedTo   time.Time `form:"created_to" time_format:"2006-01-02"`
+	Department  string    `form:"department"`
+	Locale      string    `form:"locale"`
+	Timezone    string    `form:"timezone"`
+	SortBy      string    `form:"sort_by" binding:"omitempty,oneof=name email created_at is_active"`
+	SortDir     string    `form:"sort_dir" binding:"omitempty,oneof=asc desc"`
+	Trashed     bool      `form:"trashed"`
+	// Attributes holds the attr[name]=value parameters, read with c.QueryMap
+	Attributes map[string]string `form:"-"`
+}
+
+// Query converts the request to a user.ListQuery. created_to is inclusive, so
+// the range ends at the start of the following day.
+func (r ListUsersRequest) Query() user.ListQuery {
+	q := user.ListQuery{
+		Search:      r.Search,
+		Email:       r.Email,
+		Role:        r.Role,
+		IsActive:    r.IsActive,
+		CreatedFrom: r.CreatedFrom,
+		Department:  r.Department,
+		Locale:      r.Locale,
+		Timezone:    r.Timezone,
+		Attributes:  r.Attributes,
+		SortBy:      r.SortBy,
+		SortDir:     r.SortDir,
+		Trashed:     r.Trashed,
+	}
+	if !r.CreatedTo.IsZero() {
+		q.CreatedTo = r.CreatedTo.AddDate(0, 0, 1)
+	}
+	return q
+}
@@ -0,0 +1,21 @@
+package request
+
+import "github.com/afandimsr/go-gin-api/internal/domain/user"
+
+// SaveAttributeRequest is the body of PUT /users/attributes/:name.
+type SaveAttributeRequest struct {
+	Label    string   `json:"label" binding:"max=255"`
+	Type     string   `json:"type" binding:"required,oneof=string number boolean date"`
+	Required bool     `json:"required"`
+	Options  []string `json:"options" binding:"max=100"`
+}
+
+func (r SaveAttributeRequest) Definition(name string) user.AttributeDefinition {
+	return user.AttributeDefinition{
+		Name:     name,
+		Label:    r.Label,
+		Type:     r.Type,
+		Required: r.Required,
+		Options:  r.Options,
+	}
+}
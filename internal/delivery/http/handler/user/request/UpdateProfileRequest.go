@@ -0,0 +1,27 @@
+package request
+
+import "github.com/afandimsr/go-gin-api/internal/domain/user"
+
+// UpdateProfileRequest is the body of PUT /users/:id/profile and PUT /me/profile.
+// It replaces the whole profile, omitted fields are cleared.
+type UpdateProfileRequest struct {
+	Phone      string                 `json:"phone" binding:"omitempty,e164" example:"+6281234567890"`
+	Department string                 `json:"department" binding:"max=100"`
+	JobTitle   string                 `json:"job_title" binding:"max=100"`
+	AvatarURL  string                 `json:"avatar_url" binding:"omitempty,url,max=512"`
+	Locale     string                 `json:"locale" binding:"omitempty,max=35,bcp47_language_tag" example:"id-ID"`
+	Timezone   string                 `json:"timezone" binding:"omitempty,max=64,timezone" example:"Asia/Jakarta"`
+	Attributes map[string]interface{} `json:"attributes"`
+}
+
+func (r UpdateProfileRequest) Profile() user.Profile {
+	return user.Profile{
+		Phone:      r.Phone,
+		Department: r.Department,
+		JobTitle:   r.JobTitle,
+		AvatarURL:  r.AvatarURL,
+		Locale:     r.Locale,
+		Timezone:   r.Timezone,
+		Attributes: r.Attributes,
+	}
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
-	"strconv"
+	"net/url"
+	"time"
 
 	"github.com/afandimsr/go-gin-api/internal/delivery/http/handler/user/request"
 	"github.com/afandimsr/go-gin-api/internal/delivery/http/helper"
@@ -12,6 +16,7 @@ import (
 	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
 	"github.com/afandimsr/go-gin-api/internal/domain/user"
 	"github.com/afandimsr/go-gin-api/internal/pkg/oidc"
+	"github.com/afandimsr/go-gin-api/internal/pkg/spreadsheet"
 	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
 	"github.com/gin-gonic/gin"
 )
@@ -19,33 +24,77 @@ import (
 type UserHandler struct {
 	usecase      *uc.Usecase
 	oidcProvider *oidc.OIDCProvider
+	providers    *oidc.Registry
+	// frontendCallbackURL receives the issued token after a social login
+	frontendCallbackURL string
 }
 
-func New(usecase *uc.Usecase, oidcProvider *oidc.OIDCProvider) *UserHandler {
+func New(usecase *uc.Usecase, oidcProvider *oidc.OIDCProvider, providers *oidc.Registry, frontendCallbackURL string) *UserHandler {
 	return &UserHandler{
-		usecase:      usecase,
-		oidcProvider: oidcProvider,
+		usecase:             usecase,
+		oidcProvider:        oidcProvider,
+		providers:           providers,
+		frontendCallbackURL: frontendCallbackURL,
 	}
 }
 
 // GetUsers godoc
 // @Summary      Get all users
+// @Description  Offset pagination by default. Passing cursor (empty for the first page) switches to keyset pagination, follow pagination.next_cursor for the next page.
 // @Tags         Users
 // @Produce      json
-// @Success      200 {object} response.SuccessUserResponse
+// @Param        page         query int    false "Page number (offset mode)" default(1) minimum(1)
+// @Param        limit        query int    false "Page size" default(10) minimum(1) maximum(100)
+// @Param        cursor       query string false "Opaque cursor from pagination.next_cursor (cursor mode)"
+// @Param        search       query string false "Matches name or email"
+// @Param        email        query string false "Exact email"
+// @Param        role         query string false "Role name"
+// @Param        is_active    query bool   false "Active state"
+// @Param        created_from query string false "Created on or after (YYYY-MM-DD)"
+// @Param        created_to   query string false "Created on or before (YYYY-MM-DD)"
+// @Param        department   query string false "Department, case-insensitive"
+// @Param        locale       query string false "Locale"
+// @Param        timezone     query string false "Time zone"
+// @Param        attr         query string false "Custom attribute filters, as attr[name]=value"
+// @Param        sort_by      query string false "Sort field" Enums(name, email, created_at, is_active)
+// @Param        sort_dir     query string false "Sort direction" Enums(asc, desc)
+// @Param        trashed      query bool   false "List soft-deleted users instead"
+// @Success      200 {object} response.PaginatedUserResponse "Offset mode; in cursor mode pagination is {limit, next_cursor, links}"
+// @Failure      400 {object} response.ErrorSwaggerResponse
 // @Failure      500 {object} response.ErrorSwaggerResponse
 // @Router       /users [get]
 func (h *UserHandler) GetUsers(c *gin.Context) {
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	page, limit, err := helper.PageParams(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req request.ListUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperror.Validation(err).WithCode(apperror.ValidationError))
+		return
+	}
+	req.Attributes = c.QueryMap("attr")
+
+	if cursor, ok := c.GetQuery("cursor"); ok {
+		users, next, err := h.usecase.GetAllAfter(c.Request.Context(), cursor, limit, req.Query())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		response.CursorPaginated(c, http.StatusOK, "success", users, limit, next)
+		return
+	}
 
-	users, err := h.usecase.GetAll(page, limit)
+	users, total, err := h.usecase.GetAll(c.Request.Context(), page, limit, req.Query())
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	response.Success(c, http.StatusOK, "success", users)
+	response.Paginated(c, http.StatusOK, "success", users, page, limit, total)
 }
 
 // GetUser godoc
@@ -53,7 +102,10 @@ func (h *UserHandler) GetUsers(c *gin.Context) {
 // @Tags         Users
 // @Produce      json
 // @Param        id   path      int  true  "User ID"
+// @Param        If-None-Match header string false "ETag of a cached copy"
 // @Success      200 {object} response.SuccessSingleUserResponse
+// @Success      304 "Not modified"
+// @Header       200 {string} ETag "Current version of the user"
 // @Failure      404 {object} response.ErrorSwaggerResponse
 // @Failure      500 {object} response.ErrorSwaggerResponse
 // @Router       /users/{id} [get]
@@ -64,12 +116,19 @@ func (h *UserHandler) GetUser(c *gin.Context) {
 		return
 	}
 
-	u, err := h.usecase.GetByID(id)
+	u, err := h.usecase.GetByID(c.Request.Context(), id)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
+	tag := etag(u.Version)
+	c.Header("ETag", tag)
+	if c.GetHeader("If-None-Match") == tag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
 	response.Success(c, http.StatusOK, "success", u)
 }
 
@@ -91,7 +150,7 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.usecase.Create(req); err != nil {
+	if err := h.usecase.Create(c.Request.Context(), req); err != nil {
 		c.Error(err)
 		return
 	}
@@ -118,7 +177,7 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	token, err := h.usecase.Login(req.Email, req.Password)
+	token, err := h.usecase.Login(c.Request.Context(), req.Email, req.Password)
 	if err != nil {
 		c.Error(err)
 		return
@@ -134,9 +193,11 @@ func (h *UserHandler) Login(c *gin.Context) {
 // @Produce      json
 // @Param        id   path      int  true  "User ID"
 // @Param        body body user.User true "User payload"
+// @Param        If-Match header string false "ETag from GET /users/{id}"
 // @Success      200 {object} response.SuccessSingleUserResponse
 // @Failure      400 {object} response.ErrorSwaggerResponse
 // @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      412 {object} response.ErrorSwaggerResponse
 // @Failure      500 {object} response.ErrorSwaggerResponse
 // @Router       /users/{id} [put]
 func (h *UserHandler) UpdateUser(c *gin.Context) {
@@ -152,7 +213,14 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.usecase.Update(id, req); err != nil {
+	// The precondition comes from If-Match only, never from the body
+	req.Version, err = ifMatchVersion(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.usecase.Update(c.Request.Context(), id, req); err != nil {
 		c.Error(err)
 		return
 	}
@@ -160,6 +228,470 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 	response.Success(c, http.StatusOK, "user updated", nil)
 }
 
+// PatchUser godoc
+// @Summary      Partially update user
+// @Description  JSON Merge Patch (RFC 7396) of name, email and roles. Omitted members are kept, null removes them.
+// @Tags         Users
+// @Accept       json
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Param        body body user.UserPatch true "Merge patch"
+// @Param        If-Match header string false "ETag from GET /users/{id}"
+// @Success      200 {object} response.SuccessSingleUserResponse
+// @Header       200 {string} ETag "New version of the user"
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      412 {object} response.ErrorSwaggerResponse
+// @Failure      415 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/{id} [patch]
+func (h *UserHandler) PatchUser(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if ct := c.ContentType(); ct != "application/merge-patch+json" && ct != "application/json" {
+		c.Error(apperror.NewAppError(
+			http.StatusUnsupportedMediaType,
+			apperror.BadRequestError,
+			"content type must be application/merge-patch+json",
+			nil,
+		))
+		return
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	patch, err := c.GetRawData()
+	if err != nil {
+		c.Error(apperror.BadRequest("invalid request", err))
+		return
+	}
+
+	u, err := h.usecase.Patch(c.Request.Context(), id, patch, version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etag(u.Version))
+	response.Success(c, http.StatusOK, "user updated", u)
+}
+
+// UpdateUserProfile godoc
+// @Summary      Replace the profile of a user
+// @Description  Omitted fields are cleared. Custom attributes are validated against GET /users/attributes.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Param        body body request.UpdateProfileRequest true "Profile"
+// @Param        If-Match header string false "ETag from GET /users/{id}"
+// @Success      200 {object} response.SuccessSingleUserResponse
+// @Header       200 {string} ETag "New version of the user"
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      412 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/{id}/profile [put]
+func (h *UserHandler) UpdateUserProfile(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.updateProfile(c, id)
+}
+
+// GetMe godoc
+// @Summary      Get the current user
+// @Tags         Me
+// @Produce      json
+// @Success      200 {object} response.SuccessSingleUserResponse
+// @Header       200 {string} ETag "Current version of the user"
+// @Failure      401 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /me [get]
+func (h *UserHandler) GetMe(c *gin.Context) {
+	u, err := h.usecase.GetByID(c.Request.Context(), user.ActorFromContext(c.Request.Context()))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etag(u.Version))
+	response.Success(c, http.StatusOK, "success", u)
+}
+
+// UpdateMyProfile godoc
+// @Summary      Replace the profile of the current user
+// @Description  Omitted fields are cleared. Custom attributes are validated against the attribute definitions.
+// @Tags         Me
+// @Accept       json
+// @Produce      json
+// @Param        body body request.UpdateProfileRequest true "Profile"
+// @Param        If-Match header string false "ETag from GET /me"
+// @Success      200 {object} response.SuccessSingleUserResponse
+// @Header       200 {string} ETag "New version of the user"
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      412 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /me/profile [put]
+func (h *UserHandler) UpdateMyProfile(c *gin.Context) {
+	h.updateProfile(c, user.ActorFromContext(c.Request.Context()))
+}
+
+func (h *UserHandler) updateProfile(c *gin.Context, id string) {
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req request.UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Validation(err).WithCode(apperror.ValidationError))
+		return
+	}
+
+	u, err := h.usecase.UpdateProfile(c.Request.Context(), id, req.Profile(), version)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etag(u.Version))
+	response.Success(c, http.StatusOK, "profile updated", u)
+}
+
+// UploadUserAvatar godoc
+// @Summary      Upload the avatar of a user
+// @Description  JPEG, PNG, GIF or WebP detected from the content, at most 2 MB. Square variants of 512, 128 and 64 pixels are stored; profile.avatar_url points to the 512 one and the others share its URL with the size as file name.
+// @Tags         Users
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Param        file formData  file    true  "Image"
+// @Success      200 {object} response.SuccessSingleUserResponse
+// @Header       200 {string} ETag "New version of the user"
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      413 {object} response.ErrorSwaggerResponse
+// @Failure      415 {object} response.ErrorSwaggerResponse
+// @Failure      422 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/{id}/avatar [put]
+func (h *UserHandler) UploadUserAvatar(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	h.uploadAvatar(c, id)
+}
+
+// UploadMyAvatar godoc
+// @Summary      Upload the avatar of the current user
+// @Description  Same rules as PUT /users/{id}/avatar.
+// @Tags         Me
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file formData  file    true  "Image"
+// @Success      200 {object} response.SuccessSingleUserResponse
+// @Header       200 {string} ETag "New version of the user"
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      413 {object} response.ErrorSwaggerResponse
+// @Failure      415 {object} response.ErrorSwaggerResponse
+// @Failure      422 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /me/avatar [put]
+func (h *UserHandler) UploadMyAvatar(c *gin.Context) {
+	h.uploadAvatar(c, user.ActorFromContext(c.Request.Context()))
+}
+
+func (h *UserHandler) uploadAvatar(c *gin.Context, id string) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			c.Error(apperror.NewAppError(http.StatusRequestEntityTooLarge, apperror.BadRequestError, "request body is too large", err))
+			return
+		}
+		c.Error(apperror.BadRequest("file is required", err))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	defer file.Close()
+
+	// One byte more than allowed is enough to reject the file
+	data, err := io.ReadAll(io.LimitReader(file, uc.MaxAvatarSize+1))
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+
+	u, err := h.usecase.UploadAvatar(c.Request.Context(), id, data)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("ETag", etag(u.Version))
+	response.Success(c, http.StatusOK, "avatar updated", u)
+}
+
+// GetAttributes godoc
+// @Summary      List custom profile attributes
+// @Tags         Users
+// @Produce      json
+// @Success      200 {object} response.AttributesResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/attributes [get]
+func (h *UserHandler) GetAttributes(c *gin.Context) {
+	defs, err := h.usecase.Attributes(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "success", defs)
+}
+
+// SaveAttribute godoc
+// @Summary      Create or replace a custom profile attribute
+// @Description  Names are lowercase letters, digits and underscores. Options restrict string attributes to a set of values.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        name path      string  true  "Attribute name"
+// @Param        body body request.SaveAttributeRequest true "Attribute definition"
+// @Success      200 {object} response.AttributeResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/attributes/{name} [put]
+func (h *UserHandler) SaveAttribute(c *gin.Context) {
+	var req request.SaveAttributeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Validation(err).WithCode(apperror.ValidationError))
+		return
+	}
+
+	def, err := h.usecase.SaveAttribute(c.Request.Context(), req.Definition(c.Param("name")))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "attribute saved", def)
+}
+
+// DeleteAttribute godoc
+// @Summary      Delete a custom profile attribute
+// @Description  Values already stored on users are kept until their next profile update.
+// @Tags         Users
+// @Produce      json
+// @Param        name path      string  true  "Attribute name"
+// @Success      200 {object} response.SuccessResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/attributes/{name} [delete]
+func (h *UserHandler) DeleteAttribute(c *gin.Context) {
+	if err := h.usecase.DeleteAttribute(c.Request.Context(), c.Param("name")); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "attribute deleted", nil)
+}
+
+// BulkUsers godoc
+// @Summary      Bulk user operation
+// @Description  Applies activate, deactivate, delete, add-role or remove-role to up to 100 users. Every user is processed independently and gets its own result.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        body body request.BulkUsersRequest true "Action and user ids"
+// @Success      200 {object} response.BulkUsersResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/bulk [post]
+func (h *UserHandler) BulkUsers(c *gin.Context) {
+	var req request.BulkUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.Error(apperror.Validation(err).WithCode(apperror.ValidationError))
+		return
+	}
+
+	report, err := h.usecase.Bulk(c.Request.Context(), req.Action, req.IDs, req.Role)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "bulk operation completed", report)
+}
+
+// ExportUsers godoc
+// @Summary      Export users
+// @Description  Streams every user matching the GET /users filters, without paging. Password hashes are never exported.
+// @Tags         Users
+// @Produce      text/csv
+// @Produce      application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce      application/x-ndjson
+// @Param        format       query string false "File format" Enums(csv, xlsx, jsonl) default(csv)
+// @Param        search       query string false "Matches name or email"
+// @Param        email        query string false "Exact email"
+// @Param        role         query string false "Role name"
+// @Param        is_active    query bool   false "Active state"
+// @Param        created_from query string false "Created on or after (YYYY-MM-DD)"
+// @Param        created_to   query string false "Created on or before (YYYY-MM-DD)"
+// @Param        department   query string false "Department, case-insensitive"
+// @Param        locale       query string false "Locale"
+// @Param        timezone     query string false "Time zone"
+// @Param        attr         query string false "Custom attribute filters, as attr[name]=value"
+// @Param        sort_by      query string false "Sort field" Enums(name, email, created_at, is_active)
+// @Param        sort_dir     query string false "Sort direction" Enums(asc, desc)
+// @Param        trashed      query bool   false "Export soft-deleted users instead"
+// @Success      200 {file} file
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/export [get]
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	var req request.ExportUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperror.Validation(err).WithCode(apperror.ValidationError))
+		return
+	}
+	req.Attributes = c.QueryMap("attr")
+	format := req.Format
+	if format == "" {
+		format = spreadsheet.FormatCSV
+	}
+
+	var (
+		write  func(user.User) error
+		finish func() error
+		abort  = func() {}
+	)
+	if format == exportFormatJSONL {
+		c.Header("Content-Type", "application/x-ndjson")
+		encoder := json.NewEncoder(c.Writer)
+		write = func(u user.User) error { return encoder.Encode(u) }
+		finish = func() error { return nil }
+	} else {
+		c.Header("Content-Type", spreadsheet.ContentType(format))
+		w, err := spreadsheet.NewWriter(c.Writer, format)
+		if err != nil {
+			c.Error(apperror.Internal(err))
+			return
+		}
+		if err := w.Write(exportColumns); err != nil {
+			w.Abort()
+			c.Error(apperror.Internal(err))
+			return
+		}
+		write = func(u user.User) error { return w.Write(exportRow(u)) }
+		finish, abort = w.Close, w.Abort
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="users-%s.%s"`, time.Now().Format("20060102-150405"), format))
+
+	err := h.usecase.Export(c.Request.Context(), req.Query(), write)
+	if err != nil {
+		abort()
+	} else {
+		err = finish()
+	}
+	if err == nil {
+		return
+	}
+
+	if !c.Writer.Written() {
+		// Nothing sent yet, answer with a regular JSON error
+		c.Writer.Header().Del("Content-Type")
+		c.Writer.Header().Del("Content-Disposition")
+		c.Error(err)
+		return
+	}
+	// The status is already sent, the client gets a truncated file
+	log.Printf("[Handler] User export aborted: %v", err)
+	c.Abort()
+}
+
+// ImportUsers godoc
+// @Summary      Import users from CSV or XLSX
+// @Description  The header row must contain email and password, name and roles (separated by ";") are optional, at most 1000 data rows. Each batch is saved in one transaction, invalid rows are skipped and reported.
+// @Tags         Users
+// @Accept       multipart/form-data
+// @Produce      json
+// @Param        file       formData file true  "CSV or XLSX file"
+// @Param        dry_run    query    bool false "Validate only, create nothing"
+// @Param        batch_size query    int  false "Rows per transaction" default(100) minimum(1) maximum(1000)
+// @Success      200 {object} response.ImportUsersResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/import [post]
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	var req request.ImportUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.Error(apperror.Validation(err).WithCode(apperror.ValidationError))
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.Error(apperror.BadRequest("file is required", err))
+		return
+	}
+
+	format, err := spreadsheet.FormatFromFilename(fileHeader.Filename)
+	if err != nil {
+		c.Error(apperror.BadRequest(err.Error(), err))
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.Error(apperror.Internal(err))
+		return
+	}
+	defer file.Close()
+
+	records, err := spreadsheet.ReadAll(file, format)
+	if err != nil {
+		c.Error(apperror.BadRequest("unable to read "+format+" file", err))
+		return
+	}
+
+	report, err := h.usecase.ImportUsers(c.Request.Context(), records, uc.ImportOptions{
+		DryRun:    req.DryRun,
+		BatchSize: req.BatchSize,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	message := "users imported"
+	if req.DryRun {
+		message = "import validated"
+	}
+	response.Success(c, http.StatusOK, message, report)
+}
+
 // DeleteUser godoc
 // @Summary      Delete user
 // @Tags         Users
@@ -177,7 +709,7 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
-	if err := h.usecase.Delete(id); err != nil {
+	if err := h.usecase.Delete(c.Request.Context(), id); err != nil {
 		c.Error(err)
 		return
 	}
@@ -185,6 +717,124 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	response.Success(c, http.StatusOK, "user deleted", nil)
 }
 
+// RestoreUser godoc
+// @Summary      Restore a deleted user
+// @Tags         Users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200 {object} response.SuccessResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      409 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/{id}/restore [post]
+func (h *UserHandler) RestoreUser(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.usecase.Restore(c.Request.Context(), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "user restored", nil)
+}
+
+// ActivateUser godoc
+// @Summary      Activate a user
+// @Description  Enables the user locally and in Keycloak. Activating an active user cancels its scheduled deactivation.
+// @Tags         Users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200 {object} response.SuccessResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Failure      502 {object} response.ErrorSwaggerResponse
+// @Router       /users/{id}/activate [post]
+func (h *UserHandler) ActivateUser(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.usecase.Activate(c.Request.Context(), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "user activated", nil)
+}
+
+// DeactivateUser godoc
+// @Summary      Deactivate a user
+// @Description  Disables the user locally and in Keycloak and ends its sessions. With a future "at" the deactivation is scheduled instead.
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Param        body body      request.DeactivateUserRequest false "Scheduled deactivation date"
+// @Success      200 {object} response.SuccessResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      403 {object} response.ErrorSwaggerResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Failure      502 {object} response.ErrorSwaggerResponse
+// @Router       /users/{id}/deactivate [post]
+func (h *UserHandler) DeactivateUser(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	var req request.DeactivateUserRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.Error(apperror.BadRequest("invalid request body", err))
+			return
+		}
+	}
+
+	if err := h.usecase.Deactivate(c.Request.Context(), id, req.At); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if req.At != nil {
+		response.Success(c, http.StatusOK, "user deactivation scheduled", nil)
+		return
+	}
+	response.Success(c, http.StatusOK, "user deactivated", nil)
+}
+
+// PurgeUser godoc
+// @Summary      Permanently delete a deleted user
+// @Description  Only users that were deleted (soft delete) before can be purged
+// @Tags         Users
+// @Produce      json
+// @Param        id   path      string  true  "User ID"
+// @Success      200 {object} response.SuccessResponse
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Failure      500 {object} response.ErrorSwaggerResponse
+// @Router       /users/{id}/purge [delete]
+func (h *UserHandler) PurgeUser(c *gin.Context) {
+	id, err := helper.ValidateUUIDParamNotFound(c, "id")
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := h.usecase.Purge(c.Request.Context(), id); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "user purged", nil)
+}
+
 // ChangePassword godoc
 // @Summary      Change user password
 // @Tags         Users
@@ -210,7 +860,7 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.usecase.ChangePassword(id, req.NewPassword); err != nil {
+	if err := h.usecase.ChangePassword(c.Request.Context(), id, req.NewPassword); err != nil {
 		c.Error(err)
 		return
 	}
@@ -218,76 +868,118 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	response.Success(c, http.StatusOK, "password changed", nil)
 }
 
+// OIDCLogin starts the Keycloak login, kept for clients using /auth/login.
 func (h *UserHandler) OIDCLogin(c *gin.Context) {
-	if h.oidcProvider == nil {
-		c.Error(apperror.Internal(fmt.Errorf("OIDC provider not configured")))
-		return
-	}
-
-	state := "random-state" // Should be dynamic and validated
-	url := h.oidcProvider.OAuth2Config.AuthCodeURL(state)
-	c.Redirect(http.StatusFound, url)
+	h.socialLogin(c, oidc.KeycloakProviderName)
 }
 
+// OIDCCallback completes the Keycloak login started by OIDCLogin.
 func (h *UserHandler) OIDCCallback(c *gin.Context) {
-	if h.oidcProvider == nil {
-		c.Error(apperror.Internal(fmt.Errorf("OIDC provider not configured")))
-		return
-	}
+	h.socialCallback(c, oidc.KeycloakProviderName)
+}
 
-	code := c.Query("code")
-	state := c.Query("state")
+// SocialLogin godoc
+// @Summary      Start social login
+// @Description  Redirects to the login page of the identity provider
+// @Tags         Auth
+// @Param        provider path string true "Provider name, e.g. google, github, keycloak"
+// @Success      302
+// @Failure      404 {object} response.ErrorSwaggerResponse
+// @Router       /auth/{provider}/login [get]
+func (h *UserHandler) SocialLogin(c *gin.Context) {
+	h.socialLogin(c, c.Param("provider"))
+}
 
-	log.Printf("[OIDC] Callback received. Code: %s, State: %s", code, state)
+// SocialCallback godoc
+// @Summary      Social login callback
+// @Description  Exchanges the authorization code, links or registers the user and redirects to the frontend with a token
+// @Tags         Auth
+// @Param        provider path  string true "Provider name"
+// @Param        code     query string true "Authorization code"
+// @Param        state    query string true "State returned by the provider"
+// @Success      302
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Failure      401 {object} response.ErrorSwaggerResponse
+// @Failure      409 {object} response.ErrorSwaggerResponse
+// @Router       /auth/{provider}/callback [get]
+func (h *UserHandler) SocialCallback(c *gin.Context) {
+	h.socialCallback(c, c.Param("provider"))
+}
 
-	if state != "random-state" {
-		log.Printf("[OIDC] State mismatch. Expected: random-state, Got: %s", state)
-		c.Error(apperror.BadRequest("invalid state", nil))
+func (h *UserHandler) socialLogin(c *gin.Context, name string) {
+	provider, ok := h.socialProvider(name)
+	if !ok {
+		c.Error(apperror.NotFound("login provider not found", nil))
 		return
 	}
 
-	oauth2Token, err := h.oidcProvider.OAuth2Config.Exchange(c.Request.Context(), code)
-	if err != nil {
-		log.Printf("[OIDC] Token exchange failed: %v", err)
-		c.Error(apperror.Internal(err))
-		return
-	}
+	state, nonce := randomToken(), randomToken()
+	setOAuthCookie(c, oauthStateCookie, name+":"+state, oauthCookieMaxAge)
+	setOAuthCookie(c, oauthNonceCookie, nonce, oauthCookieMaxAge)
+
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(state, nonce))
+}
 
-	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+func (h *UserHandler) socialCallback(c *gin.Context, name string) {
+	provider, ok := h.socialProvider(name)
 	if !ok {
-		log.Printf("[OIDC] No id_token in response")
-		c.Error(apperror.Internal(fmt.Errorf("no id_token in token response")))
+		c.Error(apperror.NotFound("login provider not found", nil))
 		return
 	}
 
-	idToken, err := h.oidcProvider.Verifier.Verify(c.Request.Context(), rawIDToken)
-	if err != nil {
-		log.Printf("[OIDC] ID Token verification failed: %v", err)
-		c.Error(apperror.Unauthorized("failed to verify ID token", err))
+	expectedState, _ := c.Cookie(oauthStateCookie)
+	nonce, _ := c.Cookie(oauthNonceCookie)
+	setOAuthCookie(c, oauthStateCookie, "", -1)
+	setOAuthCookie(c, oauthNonceCookie, "", -1)
+
+	state := c.Query("state")
+	if expectedState == "" || expectedState != name+":"+state {
+		log.Printf("[OIDC] State mismatch for provider %s", name)
+		c.Error(apperror.BadRequest("invalid state", nil))
 		return
 	}
 
-	var claims map[string]interface{}
-	if err := idToken.Claims(&claims); err != nil {
-		log.Printf("[OIDC] Failed to extract claims: %v", err)
-		c.Error(apperror.Internal(err))
+	if errParam := c.Query("error"); errParam != "" {
+		c.Error(apperror.Unauthorized("login cancelled: "+errParam, nil))
 		return
 	}
 
-	log.Printf("[OIDC] Claims extracted: email=%v, sub=%v", claims["email"], claims["sub"])
+	info, err := provider.Exchange(c.Request.Context(), c.Query("code"), nonce)
+	if err != nil {
+		log.Printf("[OIDC] %s login failed: %v", name, err)
+		if errors.Is(err, oidc.ErrInvalidIDToken) {
+			c.Error(apperror.Unauthorized("failed to verify ID token", err))
+			return
+		}
+		c.Error(apperror.NewAppError(http.StatusBadGateway, apperror.ExternalServiceError, "login with "+name+" failed", err))
+		return
+	}
 
-	token, err := h.usecase.LoginWithOIDC(claims, oauth2Token.AccessToken)
+	token, err := h.usecase.LoginWithIdentity(c.Request.Context(), user.ExternalIdentity{
+		Provider:      name,
+		Subject:       info.Subject,
+		Email:         info.Email,
+		EmailVerified: info.EmailVerified,
+		Name:          info.Name,
+		AccessToken:   info.AccessToken,
+		SessionID:     info.SessionID,
+	})
 	if err != nil {
-		log.Printf("[OIDC] LoginWithOIDC failed: %v", err)
 		c.Error(err)
 		return
 	}
 
-	log.Printf("[OIDC] Login successful, redirecting to frontend")
+	log.Printf("[OIDC] Login via %s successful, redirecting to frontend", name)
 
 	// Redirect to frontend with token
-	targetURL := fmt.Sprintf("http://localhost:5173/auth/callback?token=%s", token)
-	c.Redirect(http.StatusFound, targetURL)
+	c.Redirect(http.StatusFound, h.frontendCallbackURL+"?token="+url.QueryEscape(token))
+}
+
+func (h *UserHandler) socialProvider(name string) (oidc.SocialProvider, bool) {
+	if h.providers == nil {
+		return nil, false
+	}
+	return h.providers.Get(name)
 }
 
 func (h *UserHandler) Logout(c *gin.Context) {
@@ -304,3 +996,42 @@ func (h *UserHandler) Logout(c *gin.Context) {
 
 	c.Redirect(http.StatusFound, logoutURL)
 }
+
+// BackchannelLogout godoc
+// @Summary      OIDC back-channel logout
+// @Description  Called by Keycloak when a session ends; revokes the matching local sessions
+// @Tags         Auth
+// @Accept       x-www-form-urlencoded
+// @Produce      json
+// @Param        logout_token formData string true "Logout token issued by Keycloak"
+// @Success      200 {object} response.SuccessResponse
+// @Failure      400 {object} response.ErrorSwaggerResponse
+// @Router       /auth/backchannel-logout [post]
+func (h *UserHandler) BackchannelLogout(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+
+	if h.oidcProvider == nil {
+		c.Error(apperror.Internal(fmt.Errorf("OIDC provider not configured")))
+		return
+	}
+
+	rawToken := c.PostForm("logout_token")
+	if rawToken == "" {
+		c.Error(apperror.BadRequest("logout_token is required", nil))
+		return
+	}
+
+	claims, err := h.oidcProvider.VerifyLogoutToken(c.Request.Context(), rawToken)
+	if err != nil {
+		log.Printf("[OIDC] Logout token verification failed: %v", err)
+		c.Error(apperror.BadRequest("invalid logout token", err))
+		return
+	}
+
+	if err := h.usecase.BackchannelLogout(c.Request.Context(), claims.Subject, claims.SessionID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "logout success", nil)
+}
@@ -0,0 +1,43 @@
+package helper
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	DefaultPage  = 1
+	DefaultLimit = 10
+	MaxLimit     = 100
+	// MaxOffset bounds the rows skipped by offset pagination, deeper pages
+	// are slow and (page-1)*limit must not overflow
+	MaxOffset = 10000
+)
+
+// PageParams reads the page and limit query parameters. Missing or invalid
+// values fall back to the defaults and limit is capped at MaxLimit. A page
+// starting beyond MaxOffset is a validation error.
+func PageParams(c *gin.Context) (page, limit int, err error) {
+	page, err = strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = DefaultPage
+	}
+
+	limit, err = strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	// Compared by division so a huge page cannot overflow
+	if maxPage := MaxOffset/limit + 1; page > maxPage {
+		return 0, 0, apperror.BadRequest(fmt.Sprintf("page must be at most %d with limit %d", maxPage, limit), nil).
+			WithCode(apperror.ValidationError)
+	}
+	return page, limit, nil
+}
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"errors"
 	"strings"
+	"time"
 
 	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
 	"github.com/afandimsr/go-gin-api/internal/domain/user"
@@ -9,7 +11,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func AuthMiddleware(ks user.KeycloakService) gin.HandlerFunc {
+func AuthMiddleware(ks user.KeycloakService, sessions user.SessionRepository, users user.UserRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -32,18 +34,63 @@ func AuthMiddleware(ks user.KeycloakService) gin.HandlerFunc {
 			return
 		}
 
+		// Local session check (tokens issued before sessions existed have no ID)
+		if claims.ID != "" && sessions != nil {
+			session, err := sessions.FindByID(c.Request.Context(), claims.ID)
+			if err != nil && !errors.Is(err, user.ErrSessionNotFound) {
+				c.Error(apperror.Internal(err))
+				c.Abort()
+				return
+			}
+			if err != nil || !session.IsActive(time.Now()) {
+				c.Error(apperror.Unauthorized("session has been revoked", err))
+				c.Abort()
+				return
+			}
+		}
+
 		// Real-time Keycloak Session Check
 		if claims.KeycloakToken != "" && ks != nil {
-			if err := ks.VerifyToken(claims.KeycloakToken); err != nil {
-				c.Error(apperror.Unauthorized("sessions revoked in keycloak", err))
+			if err := ks.VerifyToken(c.Request.Context(), claims.KeycloakToken); err != nil {
+				if errors.Is(err, user.ErrKeycloakSessionInvalid) {
+					c.Error(apperror.Unauthorized("sessions revoked in keycloak", err))
+				} else {
+					c.Error(err)
+				}
+				c.Abort()
+				return
+			}
+		}
+
+		// Deactivated and deleted users lose access even with an unexpired token,
+		// and a role change applies at once instead of when the token expires
+		roles := claims.Roles
+		if users != nil {
+			account, err := users.FindByID(c.Request.Context(), claims.UserID)
+			if err != nil && !errors.Is(err, user.ErrUserNotFound) {
+				c.Error(apperror.Internal(err))
+				c.Abort()
+				return
+			}
+			if err != nil {
+				c.Error(apperror.Unauthorized("user no longer exists", err))
+				c.Abort()
+				return
+			}
+			if !account.IsActive {
+				c.Error(apperror.NewForbiddenError("user account is deactivated").WithCode(apperror.UserInactive))
 				c.Abort()
 				return
 			}
+			roles = account.Roles
 		}
 
 		c.Set("userID", claims.UserID)
 		c.Set("email", claims.Email)
-		c.Set("roles", claims.Roles)
+		c.Set("roles", roles)
+		// Expose the caller to the usecase layer for audit columns and authorization
+		ctx := user.WithActor(c.Request.Context(), claims.UserID)
+		c.Request = c.Request.WithContext(user.WithActorRoles(ctx, roles))
 		c.Next()
 	}
 }
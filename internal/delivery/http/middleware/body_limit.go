@@ -1,17 +1,29 @@
 package middleware
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+// originalBodyKey holds the request body before any limit, so that a route
+// can raise the limit set for the whole API.
+const originalBodyKey = "originalBody"
+
 // BodyLimit returns a middleware that limits the size of the request body.
+// A later BodyLimit, on a route or group, replaces the limit of an earlier
+// one instead of adding to it.
 func BodyLimit(maxBytes int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		body, ok := c.Get(originalBodyKey)
+		if !ok {
+			body = c.Request.Body
+			c.Set(originalBodyKey, body)
+		}
 		c.Request.Body = http.MaxBytesReader(
 			c.Writer,
-			c.Request.Body,
+			body.(io.ReadCloser),
 			maxBytes,
 		)
 		c.Next()
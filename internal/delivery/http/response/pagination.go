@@ -0,0 +1,131 @@
+package response
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Pagination describes the page returned in a PaginatedResponse. Links keep the
+// request's other query parameters (filters, sort) so clients can follow them as is.
+type Pagination struct {
+	Page       int             `json:"page" example:"1"`
+	Limit      int             `json:"limit" example:"10"`
+	Total      int64           `json:"total" example:"42"`
+	TotalPages int             `json:"total_pages" example:"5"`
+	Links      PaginationLinks `json:"links"`
+}
+
+type PaginationLinks struct {
+	Self  string `json:"self" example:"/api/v1/users?limit=10&page=1"`
+	First string `json:"first" example:"/api/v1/users?limit=10&page=1"`
+	Last  string `json:"last" example:"/api/v1/users?limit=10&page=5"`
+	Next  string `json:"next,omitempty" example:"/api/v1/users?limit=10&page=2"`
+	Prev  string `json:"prev,omitempty"`
+}
+
+type PaginatedResponse struct {
+	Success    bool        `json:"success"`
+	Message    string      `json:"message"`
+	Data       interface{} `json:"data"`
+	Pagination Pagination  `json:"pagination"`
+}
+
+func Paginated(c *gin.Context, status int, message string, data interface{}, page, limit int, total int64) {
+	c.JSON(status, PaginatedResponse{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		Pagination: NewPagination(c.Request.URL, page, limit, total),
+	})
+}
+
+// CursorPagination describes a page of a keyset paginated list. NextCursor is
+// empty on the last page.
+type CursorPagination struct {
+	Limit      int         `json:"limit" example:"10"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	Links      CursorLinks `json:"links"`
+}
+
+type CursorLinks struct {
+	Self string `json:"self" example:"/api/v1/users?cursor=&limit=10"`
+	Next string `json:"next,omitempty"`
+}
+
+type CursorPaginatedResponse struct {
+	Success    bool             `json:"success"`
+	Message    string           `json:"message"`
+	Data       interface{}      `json:"data"`
+	Pagination CursorPagination `json:"pagination"`
+}
+
+func CursorPaginated(c *gin.Context, status int, message string, data interface{}, limit int, nextCursor string) {
+	u := c.Request.URL
+	p := CursorPagination{
+		Limit:      limit,
+		NextCursor: nextCursor,
+		Links:      CursorLinks{Self: cursorLink(u, u.Query().Get("cursor"), limit)},
+	}
+	if nextCursor != "" {
+		p.Links.Next = cursorLink(u, nextCursor, limit)
+	}
+
+	c.JSON(status, CursorPaginatedResponse{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		Pagination: p,
+	})
+}
+
+// NewPagination computes the page counts and links for the request URL u.
+func NewPagination(u *url.URL, page, limit int, total int64) Pagination {
+	totalPages := 0
+	if limit > 0 {
+		totalPages = int((total + int64(limit) - 1) / int64(limit))
+	}
+	lastPage := totalPages
+	if lastPage < 1 {
+		lastPage = 1
+	}
+
+	p := Pagination{
+		Page:       page,
+		Limit:      limit,
+		Total:      total,
+		TotalPages: totalPages,
+		Links: PaginationLinks{
+			Self:  pageLink(u, page, limit),
+			First: pageLink(u, 1, limit),
+			Last:  pageLink(u, lastPage, limit),
+		},
+	}
+	if page < totalPages {
+		p.Links.Next = pageLink(u, page+1, limit)
+	}
+	if page > 1 {
+		p.Links.Prev = pageLink(u, min(page-1, lastPage), limit)
+	}
+	return p
+}
+
+func pageLink(u *url.URL, page, limit int) string {
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("limit", strconv.Itoa(limit))
+
+	link := url.URL{Path: u.Path, RawQuery: q.Encode()}
+	return link.String()
+}
+
+func cursorLink(u *url.URL, cursor string, limit int) string {
+	q := u.Query()
+	q.Del("page")
+	q.Set("cursor", cursor)
+	q.Set("limit", strconv.Itoa(limit))
+
+	link := url.URL{Path: u.Path, RawQuery: q.Encode()}
+	return link.String()
+}
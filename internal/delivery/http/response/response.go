@@ -3,6 +3,7 @@ package response
 import (
 	"strings"
 
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 )
@@ -95,6 +96,10 @@ func ValidationError(c *gin.Context, err error) {
 				errors[field] = "Nilai tidak valid"
 			}
 		}
+	} else if fieldErrors, ok := err.(apperror.FieldErrors); ok {
+		for field, message := range fieldErrors {
+			errors[field] = message
+		}
 	}
 
 	Error(
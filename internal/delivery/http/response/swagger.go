@@ -1,12 +1,16 @@
 package response
 
-import "github.com/afandimsr/go-gin-api/internal/domain/user"
+import (
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
 
 // Generic success response for swagger
-type SuccessUserResponse struct {
-	Success bool        `json:"success" example:"true"`
-	Message string      `json:"message" example:"success"`
-	Data    []user.User `json:"data"`
+type PaginatedUserResponse struct {
+	Success    bool        `json:"success" example:"true"`
+	Message    string      `json:"message" example:"success"`
+	Data       []user.User `json:"data"`
+	Pagination Pagination  `json:"pagination"`
 }
 
 type SuccessSingleUserResponse struct {
@@ -15,6 +19,72 @@ type SuccessSingleUserResponse struct {
 	Data    user.User `json:"data"`
 }
 
+type ImportUsersResponse struct {
+	Success bool              `json:"success" example:"true"`
+	Message string            `json:"message" example:"users imported"`
+	Data    user.ImportReport `json:"data"`
+}
+
+type BulkUsersResponse struct {
+	Success bool            `json:"success" example:"true"`
+	Message string          `json:"message" example:"bulk operation completed"`
+	Data    user.BulkReport `json:"data"`
+}
+
+type AttributesResponse struct {
+	Success bool                       `json:"success" example:"true"`
+	Message string                     `json:"message" example:"success"`
+	Data    []user.AttributeDefinition `json:"data"`
+}
+
+type AttributeResponse struct {
+	Success bool                     `json:"success" example:"true"`
+	Message string                   `json:"message" example:"attribute saved"`
+	Data    user.AttributeDefinition `json:"data"`
+}
+
+type FileResponse struct {
+	Success bool      `json:"success" example:"true"`
+	Message string    `json:"message" example:"file uploaded"`
+	Data    file.File `json:"data"`
+}
+
+type FileDownloadResponse struct {
+	Success bool          `json:"success" example:"true"`
+	Message string        `json:"message" example:"success"`
+	Data    file.Download `json:"data"`
+}
+
+type UploadSessionResponse struct {
+	Success bool               `json:"success" example:"true"`
+	Message string             `json:"message" example:"success"`
+	Data    file.UploadSession `json:"data"`
+}
+
+type UploadedPartResponse struct {
+	Success bool              `json:"success" example:"true"`
+	Message string            `json:"message" example:"part uploaded"`
+	Data    file.UploadedPart `json:"data"`
+}
+
+type UploadAbortedResponse struct {
+	Success bool   `json:"success" example:"true"`
+	Message string `json:"message" example:"upload aborted"`
+}
+
+type StorageUsageResponse struct {
+	Success bool       `json:"success" example:"true"`
+	Message string     `json:"message" example:"success"`
+	Data    file.Usage `json:"data"`
+}
+
+type PaginatedStorageUsageResponse struct {
+	Success    bool         `json:"success" example:"true"`
+	Message    string       `json:"message" example:"success"`
+	Data       []file.Usage `json:"data"`
+	Pagination Pagination   `json:"pagination"`
+}
+
 type ErrorSwaggerResponse struct {
 	Success bool   `json:"success" example:"false"`
 	Message string `json:"message" example:"error"`
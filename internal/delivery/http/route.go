@@ -1,6 +1,8 @@
 package http
 
 import (
+	fileDelivery "github.com/afandimsr/go-gin-api/internal/delivery/http/handler/file"
+	storageDelivery "github.com/afandimsr/go-gin-api/internal/delivery/http/handler/storage"
 	handler "github.com/afandimsr/go-gin-api/internal/delivery/http/handler/user"
 	"github.com/afandimsr/go-gin-api/internal/delivery/http/middleware"
 	"github.com/afandimsr/go-gin-api/internal/domain/user"
@@ -10,7 +12,11 @@ import (
 func RegisterRoutes(
 	r *gin.Engine,
 	userHandler *handler.UserHandler,
+	storageHandler *storageDelivery.StorageHandler,
+	fileHandler *fileDelivery.FileHandler,
 	ks user.KeycloakService,
+	sessions user.SessionRepository,
+	userRepo user.UserRepository,
 ) {
 
 	api := r.Group("/api/v1")
@@ -19,21 +25,68 @@ func RegisterRoutes(
 	api.POST("/login", userHandler.Login)
 	api.GET("/auth/login", userHandler.OIDCLogin)
 	api.GET("/auth/callback", userHandler.OIDCCallback)
+	api.GET("/auth/:provider/login", userHandler.SocialLogin)
+	api.GET("/auth/:provider/callback", userHandler.SocialCallback)
 	api.GET("/logout", userHandler.Logout)
+	api.POST("/auth/backchannel-logout", userHandler.BackchannelLogout)
 
 	// health check
 	api.GET("/health", healthHandler)
 
+	// objects and presigned URLs of the local and memory storage drivers
+	api.GET("/storage/:bucket/*key", storageHandler.GetObject)
+	api.HEAD("/storage/:bucket/*key", storageHandler.GetObject)
+	api.PUT("/storage/:bucket/*key", middleware.BodyLimit(storageHandler.PutBodyLimit()), storageHandler.PutObject)
+
 	// user routes
 	users := api.Group("/users")
-	users.Use(middleware.AuthMiddleware(ks), middleware.AdminOnly())
+	users.Use(middleware.AuthMiddleware(ks, sessions, userRepo), middleware.AdminOnly())
 	{
 		users.PUT("/:id", userHandler.UpdateUser)
+		users.PATCH("/:id", userHandler.PatchUser)
 		users.DELETE("/:id", userHandler.DeleteUser)
+		users.POST("/:id/restore", userHandler.RestoreUser)
+		users.POST("/:id/activate", userHandler.ActivateUser)
+		users.POST("/:id/deactivate", userHandler.DeactivateUser)
+		users.DELETE("/:id/purge", userHandler.PurgeUser)
 		users.GET("", userHandler.GetUsers)
+		users.GET("/export", userHandler.ExportUsers)
+		users.GET("/storage", fileHandler.GetStorageReport)
+		users.GET("/attributes", userHandler.GetAttributes)
+		users.PUT("/attributes/:name", userHandler.SaveAttribute)
+		users.DELETE("/attributes/:name", userHandler.DeleteAttribute)
 		users.POST("", userHandler.CreateUser)
+		users.POST("/import", userHandler.ImportUsers)
+		users.POST("/bulk", userHandler.BulkUsers)
 		users.GET("/:id", userHandler.GetUser)
 		users.PUT("/:id/change-password", userHandler.ChangePassword)
+		users.PUT("/:id/profile", userHandler.UpdateUserProfile)
+		users.PUT("/:id/avatar", userHandler.UploadUserAvatar)
+		users.PUT("/:id/storage-quota", fileHandler.SetStorageQuota)
+		users.DELETE("/:id/storage-quota", fileHandler.DeleteStorageQuota)
+	}
+
+	// current user routes
+	me := api.Group("/me")
+	me.Use(middleware.AuthMiddleware(ks, sessions, userRepo))
+	{
+		me.GET("", userHandler.GetMe)
+		me.PUT("/profile", userHandler.UpdateMyProfile)
+		me.PUT("/avatar", userHandler.UploadMyAvatar)
+		me.GET("/storage", fileHandler.GetMyStorage)
+	}
+
+	// file routes, access to a file is checked by the usecase
+	files := api.Group("/files")
+	files.Use(middleware.AuthMiddleware(ks, sessions, userRepo))
+	{
+		files.POST("", middleware.BodyLimit(fileHandler.UploadBodyLimit()), fileHandler.UploadFile)
+		files.GET("/:id", fileHandler.GetFile)
+		files.POST("/uploads", fileHandler.InitiateUpload)
+		files.GET("/uploads/:id", fileHandler.GetUpload)
+		files.PUT("/uploads/:id/parts/:number", middleware.BodyLimit(fileHandler.PartBodyLimit()), fileHandler.UploadPart)
+		files.POST("/uploads/:id/complete", fileHandler.CompleteUpload)
+		files.DELETE("/uploads/:id", fileHandler.AbortUpload)
 	}
 }
 
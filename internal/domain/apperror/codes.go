@@ -17,6 +17,8 @@ const (
 	BadRequestError = "BAD_REQUEST"
 	NotFoundError   = "NOT_FOUND"
 	ConflictError   = "CONFLICT"
+	// PreconditionFailed is returned when If-Match does not match the current version
+	PreconditionFailed = "PRECONDITION_FAILED"
 )
 
 // ======================
@@ -41,6 +43,19 @@ const (
 	UserPasswordMismatch = "USER_PASSWORD_MISMATCH"
 )
 
+// ======================
+// File Domain
+// ======================
+const (
+	FileNotFound          = "FILE_NOT_FOUND"
+	FileTooLarge          = "FILE_TOO_LARGE"
+	UploadSessionNotFound = "UPLOAD_SESSION_NOT_FOUND"
+	UploadIncomplete      = "UPLOAD_INCOMPLETE"
+	ChecksumMismatch      = "CHECKSUM_MISMATCH"
+	FileRejected          = "FILE_REJECTED"
+	StorageQuotaExceeded  = "STORAGE_QUOTA_EXCEEDED"
+)
+
 // ======================
 // Permission / Role
 // ======================
@@ -17,8 +17,9 @@ func isDevelopment() bool {
 	return config.Load().AppEnv == "development" || gin.Mode() == gin.DebugMode
 }
 
-// HandleDatabaseError converts database errors to AppError (works with MySQL, PostgreSQL, SQLite, MS SQL Server)
-func HandleDatabaseError(err error) *AppError {
+// HandleDatabaseError converts database errors to AppError (works with MySQL, PostgreSQL, SQLite, MS SQL Server).
+// It returns a plain error so a nil input stays a nil interface for callers returning error.
+func HandleDatabaseError(err error) error {
 	if err == nil {
 		return nil
 	}
@@ -2,6 +2,8 @@ package apperror
 
 import (
 	"net/http"
+	"sort"
+	"strings"
 )
 
 type AppError struct {
@@ -119,6 +121,24 @@ func NewValidationError(err error) *AppError {
 	}
 }
 
+// FieldErrors are business rule violations keyed by field name. Wrapped by
+// NewValidationError they are rendered like request binding errors.
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, len(fields))
+	for i, field := range fields {
+		messages[i] = field + ": " + e[field]
+	}
+	return strings.Join(messages, "; ")
+}
+
 // ==================== Legacy Helpers (Backward Compatibility) ====================
 
 // BadRequest creates a 400 error (legacy)
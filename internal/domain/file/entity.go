@@ -0,0 +1,24 @@
+package file
+
+import "time"
+
+// File is an uploaded file, its content is stored in the private bucket under
+// StorageKey.
+type File struct {
+	ID          string `json:"id"`
+	OwnerID     string `json:"owner_id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	// Checksum is the SHA-256 of the content, hex-encoded
+	Checksum   string    `json:"checksum,omitempty"`
+	StorageKey string    `json:"-"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Download grants access to the content of a file until ExpiresAt.
+type Download struct {
+	File
+	URL       string    `json:"url"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -0,0 +1,10 @@
+package file
+
+import "errors"
+
+var (
+	ErrFileNotFound          = errors.New("file not found")
+	ErrUploadSessionNotFound = errors.New("upload session not found")
+	ErrOwnerNotFound         = errors.New("owner not found")
+	ErrQuotaExceeded         = errors.New("storage quota exceeded")
+)
@@ -0,0 +1,21 @@
+package file
+
+import (
+	"context"
+	"io"
+)
+
+// Rejected describes an upload whose content failed a check.
+type Rejected struct {
+	OwnerID     string
+	Name        string
+	ContentType string
+	Reason      string
+}
+
+// Quarantine keeps the content of rejected uploads for review, away from the
+// buckets it was meant for.
+type Quarantine interface {
+	// Put stores body and returns the key it is kept under.
+	Put(ctx context.Context, r Rejected, body io.Reader) (string, error)
+}
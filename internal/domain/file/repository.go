@@ -0,0 +1,6 @@
+package file
+
+type Repository interface {
+	Save(f File) error
+	FindByID(id string) (File, error)
+}
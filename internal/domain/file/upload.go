@@ -0,0 +1,73 @@
+package file
+
+import "time"
+
+// Statuses of an upload session.
+const (
+	UploadPending   = "pending"
+	UploadCompleted = "completed"
+	UploadAborted   = "aborted"
+)
+
+// UploadSession is a resumable upload of a file in parts of PartSize bytes,
+// the last part holding the remainder. The completed session becomes the
+// file of the same id.
+type UploadSession struct {
+	ID          string `json:"id"`
+	OwnerID     string `json:"owner_id"`
+	Name        string `json:"name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	PartSize    int64  `json:"part_size"`
+	TotalParts  int    `json:"total_parts"`
+	// Checksum is the expected SHA-256 of the file, hex-encoded
+	Checksum   string         `json:"checksum,omitempty"`
+	Status     string         `json:"status"`
+	StorageKey string         `json:"-"`
+	UploadID   string         `json:"-"`
+	Parts      []UploadedPart `json:"parts"`
+	ExpiresAt  time.Time      `json:"expires_at"`
+	CreatedAt  time.Time      `json:"created_at"`
+}
+
+// UploadedPart is a part of an upload session stored in the bucket.
+type UploadedPart struct {
+	Number int    `json:"number"`
+	Size   int64  `json:"size"`
+	ETag   string `json:"-"`
+	// Checksum is the SHA-256 of the part, hex-encoded
+	Checksum string `json:"checksum"`
+}
+
+// PartCount returns the number of parts of size bytes split in parts of
+// partSize bytes.
+func PartCount(size, partSize int64) int {
+	if size == 0 {
+		return 1
+	}
+	return int((size + partSize - 1) / partSize)
+}
+
+// ExpectedPartSize returns the size part number must have, 0 when the session
+// has no such part.
+func (s UploadSession) ExpectedPartSize(number int) int64 {
+	switch {
+	case number < 1 || number > s.TotalParts:
+		return 0
+	case number < s.TotalParts:
+		return s.PartSize
+	default:
+		return s.Size - int64(s.TotalParts-1)*s.PartSize
+	}
+}
+
+type UploadSessionRepository interface {
+	Create(s UploadSession) error
+	// FindByID returns the session with its parts ordered by number.
+	FindByID(id string) (UploadSession, error)
+	// SavePart records a part, replacing a previous upload of the same number.
+	SavePart(sessionID string, part UploadedPart) error
+	UpdateStatus(id, status string) error
+	// FindExpired returns up to limit pending sessions expired before now.
+	FindExpired(now time.Time, limit int) ([]UploadSession, error)
+}
@@ -0,0 +1,49 @@
+package file
+
+import "time"
+
+// Usage is the storage used by the files of a user against their quota.
+type Usage struct {
+	OwnerID string `json:"owner_id"`
+	// Email of the owner, in reports
+	Email     string `json:"email,omitempty"`
+	Files     int64  `json:"files"`
+	UsedBytes int64  `json:"used_bytes"`
+	// ReservedBytes are held by the pending upload sessions and the uploads in
+	// progress of the user
+	ReservedBytes int64 `json:"reserved_bytes"`
+	// QuotaBytes bounds UsedBytes and ReservedBytes together, 0 is unlimited
+	QuotaBytes int64 `json:"quota_bytes"`
+	// CustomQuota tells whether the quota was set for the user rather than
+	// being the default one
+	CustomQuota bool `json:"custom_quota"`
+}
+
+// Exceeds tells whether storing size more bytes goes over the quota.
+func (u Usage) Exceeds(size int64) bool {
+	return u.QuotaBytes > 0 && u.UsedBytes+u.ReservedBytes+size > u.QuotaBytes
+}
+
+type UsageRepository interface {
+	// Usage returns the storage used by owner, counting the upload sessions
+	// pending and the reservations unexpired at now. QuotaBytes is the custom
+	// quota of the owner.
+	Usage(ownerID string, now time.Time) (Usage, error)
+	// Reserve holds size bytes for owner until Release or expiresAt, if allow
+	// accepts the usage of owner at now. The usage is read under a lock on the
+	// owner, so concurrent reservations cannot exceed the quota together. It
+	// returns the id of the reservation, ErrQuotaExceeded when allow refuses
+	// and ErrOwnerNotFound when the user does not exist.
+	Reserve(ownerID string, size int64, now, expiresAt time.Time, allow func(Usage) bool) (string, error)
+	// Release deletes a reservation, once its bytes are held by a file or an
+	// upload session.
+	Release(id string) error
+	// ListUsage returns a page of the owners of files by bytes used, largest
+	// first, and the total number of owners.
+	ListUsage(limit, offset int) ([]Usage, int64, error)
+	// SetQuota sets a custom quota for a user, 0 being unlimited. It returns
+	// ErrOwnerNotFound when the user does not exist.
+	SetQuota(ownerID string, quotaBytes int64) error
+	// DeleteQuota restores the default quota of a user.
+	DeleteQuota(ownerID string) error
+}
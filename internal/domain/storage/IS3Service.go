@@ -2,14 +2,137 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
+	"time"
 )
 
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrInvalidKey     = errors.New("invalid object key")
+	ErrUploadNotFound = errors.New("multipart upload not found")
+)
+
+// MinPartSize is the smallest size of the parts of a multipart upload but
+// the last one.
+const MinPartSize = 5 << 20
+
+// Object describes a stored object.
+type Object struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type,omitempty"`
+	CacheControl string            `json:"cache_control,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified time.Time         `json:"last_modified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// ListPage is one page of a listing, NextCursor is empty on the last page.
+type ListPage struct {
+	Objects    []Object `json:"objects"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// Part is an uploaded part of a multipart upload, numbered from 1.
+type Part struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+// UploadOptions holds the attributes stored along with an object.
+type UploadOptions struct {
+	ContentType  string
+	CacheControl string
+	Metadata     map[string]string
+}
+
+// UploadOption sets an attribute of an uploaded object.
+type UploadOption func(*UploadOptions)
+
+// WithContentType sets the content type of the object, derived from the
+// extension of its key when not set.
+func WithContentType(contentType string) UploadOption {
+	return func(o *UploadOptions) { o.ContentType = contentType }
+}
+
+// WithCacheControl sets the Cache-Control header the object is served with.
+func WithCacheControl(cacheControl string) UploadOption {
+	return func(o *UploadOptions) { o.CacheControl = cacheControl }
+}
+
+// WithMetadata adds user-defined metadata to the object.
+func WithMetadata(metadata map[string]string) UploadOption {
+	return func(o *UploadOptions) {
+		if o.Metadata == nil {
+			o.Metadata = make(map[string]string, len(metadata))
+		}
+		for k, v := range metadata {
+			o.Metadata[k] = v
+		}
+	}
+}
+
+// ApplyUploadOptions returns the options resulting of opts.
+func ApplyUploadOptions(opts []UploadOption) UploadOptions {
+	var o UploadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
 // IS3Service defines the interface for S3 storage operations.
+// Operations on a missing object return ErrObjectNotFound.
 type IS3Service interface {
 	Upload(
 		ctx context.Context,
 		key string,
 		body io.Reader,
+		opts ...UploadOption,
 	) error
+
+	// Download returns the content of the object, to be closed by the caller.
+	Download(ctx context.Context, key string) (io.ReadCloser, Object, error)
+
+	// Head returns the attributes of the object without its content.
+	Head(ctx context.Context, key string) (Object, error)
+
+	Exists(ctx context.Context, key string) (bool, error)
+
+	// Delete removes the object, deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// List returns up to limit objects whose key starts with prefix, cursor
+	// being the NextCursor of the previous page.
+	List(ctx context.Context, prefix, cursor string, limit int) (ListPage, error)
+
+	// Copy copies the object srcKey to dstKey along with its attributes.
+	Copy(ctx context.Context, srcKey, dstKey string) error
+
+	// PresignGet returns a URL downloading the object until expiry elapses.
+	PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error)
+
+	// PresignPut returns a URL uploading the object until expiry elapses.
+	// The uploader must send the metadata set by opts as x-amz-meta-* headers.
+	PresignPut(ctx context.Context, key string, expiry time.Duration, opts ...UploadOption) (string, error)
+
+	// URL returns the public URL of the object stored under key.
+	URL(key string) string
+
+	// CreateMultipartUpload starts the upload of an object in parts and
+	// returns its upload id. The object exists once the upload is completed.
+	CreateMultipartUpload(ctx context.Context, key string, opts ...UploadOption) (string, error)
+
+	// UploadPart stores a part of size bytes, replacing a previous upload of
+	// the same part.
+	UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (Part, error)
+
+	// CompleteMultipartUpload assembles parts, ordered by number, into the object.
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []Part) error
+
+	// AbortMultipartUpload discards the uploaded parts, aborting a missing
+	// upload is not an error.
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
 }
@@ -0,0 +1,13 @@
+// Package transaction defines the unit of work spanning several repository
+// calls.
+package transaction
+
+import "context"
+
+// Manager runs units of work in a database transaction.
+type Manager interface {
+	// WithinTx runs fn in a transaction, committed when fn returns nil and
+	// rolled back otherwise. Repository calls given the ctx passed to fn take
+	// part in the transaction, and so does a nested WithinTx.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
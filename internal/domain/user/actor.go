@@ -0,0 +1,30 @@
+package user
+
+import "context"
+
+type (
+	actorKey      struct{}
+	actorRolesKey struct{}
+)
+
+// WithActor returns a copy of ctx carrying the id of the user performing the request.
+func WithActor(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, userID)
+}
+
+// ActorFromContext returns the id stored by WithActor, or "" for system actions.
+func ActorFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(actorKey{}).(string)
+	return id
+}
+
+// WithActorRoles returns a copy of ctx carrying the roles of the user performing the request.
+func WithActorRoles(ctx context.Context, roles []string) context.Context {
+	return context.WithValue(ctx, actorRolesKey{}, roles)
+}
+
+// ActorRolesFromContext returns the roles stored by WithActorRoles.
+func ActorRolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(actorRolesKey{}).([]string)
+	return roles
+}
@@ -0,0 +1,21 @@
+package user
+
+import "context"
+
+// AuthResult describes a successful credential check.
+type AuthResult struct {
+	// Provider is the name of the provider that accepted the credentials
+	Provider string
+	// Name is the display name reported by the provider, if any
+	Name string
+	// Roles derived by the provider (e.g. from LDAP groups); empty keeps the local roles
+	Roles []string
+}
+
+// AuthProvider verifies credentials against a single identity source. account is
+// the local user registered with the email, or nil when there is none yet.
+// Rejected credentials are reported as ErrInvalidCredentials.
+type AuthProvider interface {
+	Name() string
+	Authenticate(ctx context.Context, email, password string, account *User) (AuthResult, error)
+}
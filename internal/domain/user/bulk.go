@@ -0,0 +1,26 @@
+package user
+
+// Actions of a bulk operation on users.
+const (
+	BulkActivate   = "activate"
+	BulkDeactivate = "deactivate"
+	BulkDelete     = "delete"
+	BulkAddRole    = "add-role"
+	BulkRemoveRole = "remove-role"
+)
+
+type BulkItemResult struct {
+	ID        string `json:"id"`
+	Success   bool   `json:"success"`
+	Error     string `json:"error,omitempty"`
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// BulkReport lists the outcome of a bulk operation for every user.
+type BulkReport struct {
+	Action    string           `json:"action"`
+	Total     int              `json:"total"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+	Results   []BulkItemResult `json:"results"`
+}
@@ -1,13 +1,30 @@
 package user
 
+import "time"
+
 type User struct {
-	ID         string   `json:"id"`
-	KeycloakID string   `json:"keycloak_id,omitempty"`
-	Name       string   `json:"name"`
-	Email      string   `json:"email"`
-	Password   string   `json:"-"`
-	Roles      []string `json:"roles"`
-	IsActive   bool     `json:"is_active"`
+	ID           string     `json:"id"`
+	KeycloakID   string     `json:"keycloak_id,omitempty"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Password     string     `json:"-"`
+	Roles        []string   `json:"roles"`
+	Profile      Profile    `json:"profile"`
+	IsActive     bool       `json:"is_active"`
+	DeactivateAt *time.Time `json:"deactivate_at,omitempty"` // scheduled deactivation
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	CreatedBy    string     `json:"created_by,omitempty"`
+	UpdatedBy    string     `json:"updated_by,omitempty"`
+	DeletedAt    *time.Time `json:"deleted_at,omitempty"`
+	Version      int        `json:"version"` // incremented on every write, backs the ETag
+}
+
+// UserPatch is the document a JSON Merge Patch on a user is applied to.
+type UserPatch struct {
+	Name  string   `json:"name"`
+	Email string   `json:"email"`
+	Roles []string `json:"roles"`
 }
 
 type LoginRequest struct {
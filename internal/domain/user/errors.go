@@ -3,6 +3,14 @@ package user
 import "errors"
 
 var (
-	ErrUserNotFound = errors.New("user not found")
-	ErrPasswordWeak = errors.New("password weak")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrPasswordWeak       = errors.New("password weak")
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrIdentityNotFound   = errors.New("identity not found")
+	ErrVersionConflict    = errors.New("user version conflict")
+	ErrAttributeNotFound  = errors.New("attribute not found")
+	ErrEmailTaken         = errors.New("email used by another user")
+
+	ErrKeycloakSessionInvalid = errors.New("keycloak session invalid")
 )
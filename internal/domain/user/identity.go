@@ -0,0 +1,40 @@
+package user
+
+import (
+	"context"
+	"time"
+)
+
+// KeycloakIdentityProvider is the identity provider name of Keycloak logins.
+// Their subject is also kept in users.keycloak_id.
+const KeycloakIdentityProvider = "keycloak"
+
+// Identity links a local user to an account at an external identity provider.
+type Identity struct {
+	ID        string
+	UserID    string
+	Provider  string
+	Subject   string
+	Email     string
+	CreatedAt time.Time
+}
+
+// ExternalIdentity is the verified result of a social/OIDC login.
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// AccessToken and SessionID are only set for Keycloak logins
+	AccessToken string
+	SessionID   string
+}
+
+// IdentityRepository stores the identities of users. Its methods take part in
+// the transaction of ctx, if any.
+type IdentityRepository interface {
+	Create(ctx context.Context, identity Identity) error
+	FindByProviderSubject(ctx context.Context, provider, subject string) (Identity, error)
+	FindByUserID(ctx context.Context, userID string) ([]Identity, error)
+}
@@ -0,0 +1,28 @@
+package user
+
+// Statuses of a row of an ImportReport.
+const (
+	ImportStatusCreated = "created"
+	ImportStatusValid   = "valid" // dry-run only
+	ImportStatusInvalid = "invalid"
+	ImportStatusFailed  = "failed" // valid, but its batch could not be saved
+)
+
+type ImportRowResult struct {
+	// Line is the line of the row in the file, the header being line 1
+	Line   int      `json:"line"`
+	Email  string   `json:"email"`
+	Status string   `json:"status"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+// ImportReport lists the outcome of every row of an import file.
+type ImportReport struct {
+	DryRun  bool              `json:"dry_run"`
+	Total   int               `json:"total"`
+	Valid   int               `json:"valid"`
+	Created int               `json:"created"`
+	Invalid int               `json:"invalid"`
+	Failed  int               `json:"failed"`
+	Rows    []ImportRowResult `json:"rows"`
+}
@@ -0,0 +1,47 @@
+package user
+
+import (
+	"strings"
+	"time"
+)
+
+// Keycloak admin event resource and operation types we react to.
+const (
+	KeycloakResourceUser             = "USER"
+	KeycloakResourceRealmRoleMapping = "REALM_ROLE_MAPPING"
+
+	KeycloakOperationCreate = "CREATE"
+	KeycloakOperationUpdate = "UPDATE"
+	KeycloakOperationDelete = "DELETE"
+)
+
+// KeycloakAdminEvent is a single entry of the Keycloak admin event log.
+type KeycloakAdminEvent struct {
+	Time          time.Time
+	OperationType string
+	ResourceType  string
+	ResourcePath  string
+}
+
+// UserID returns the Keycloak user ID the event refers to, taken from
+// resource paths such as "users/{id}" or "users/{id}/role-mappings/realm".
+func (e KeycloakAdminEvent) UserID() string {
+	parts := strings.Split(e.ResourcePath, "/")
+	if len(parts) < 2 || parts[0] != "users" {
+		return ""
+	}
+	return parts[1]
+}
+
+// KeycloakUser is the subset of the Keycloak user representation we sync locally.
+type KeycloakUser struct {
+	ID        string
+	Email     string
+	FirstName string
+	LastName  string
+	Enabled   bool
+}
+
+func (u KeycloakUser) FullName() string {
+	return strings.TrimSpace(u.FirstName + " " + u.LastName)
+}
@@ -0,0 +1,142 @@
+package user
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// Profile holds the optional details of a user. Attributes are the custom
+// attributes, validated against the AttributeDefinitions set up by admins.
+type Profile struct {
+	Phone      string                 `json:"phone,omitempty"`
+	Department string                 `json:"department,omitempty"`
+	JobTitle   string                 `json:"job_title,omitempty"`
+	AvatarURL  string                 `json:"avatar_url,omitempty"`
+	Locale     string                 `json:"locale,omitempty"`
+	Timezone   string                 `json:"timezone,omitempty"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Types of custom attributes.
+const (
+	AttributeString  = "string"
+	AttributeNumber  = "number"
+	AttributeBoolean = "boolean"
+	AttributeDate    = "date" // YYYY-MM-DD
+)
+
+// MaxAttributeLength bounds the length of string attribute values.
+const MaxAttributeLength = 255
+
+var attributeNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// ValidAttributeName reports whether name can be used as a custom attribute name.
+func ValidAttributeName(name string) bool {
+	return attributeNamePattern.MatchString(name)
+}
+
+// AttributeDefinition is the schema of one custom profile attribute.
+type AttributeDefinition struct {
+	Name     string `json:"name"`
+	Label    string `json:"label,omitempty"`
+	Type     string `json:"type" enums:"string,number,boolean,date"`
+	Required bool   `json:"required"`
+	// Options restricts a string attribute to these values
+	Options   []string  `json:"options,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Validate checks the definition itself.
+func (d AttributeDefinition) Validate() error {
+	if !ValidAttributeName(d.Name) {
+		return fmt.Errorf("attribute name must be lowercase letters, digits and underscores, starting with a letter")
+	}
+
+	switch d.Type {
+	case AttributeString, AttributeNumber, AttributeBoolean, AttributeDate:
+	default:
+		return fmt.Errorf("unsupported attribute type %q", d.Type)
+	}
+
+	if len(d.Options) > 0 && d.Type != AttributeString {
+		return fmt.Errorf("options are only supported by string attributes")
+	}
+	for i, option := range d.Options {
+		if option == "" || len(option) > MaxAttributeLength {
+			return fmt.Errorf("options must be between 1 and %d characters", MaxAttributeLength)
+		}
+		if slices.Contains(d.Options[:i], option) {
+			return fmt.Errorf("option %q appears more than once", option)
+		}
+	}
+	return nil
+}
+
+// validateValue checks a value decoded from JSON against the definition.
+func (d AttributeDefinition) validateValue(value interface{}) string {
+	switch d.Type {
+	case AttributeString:
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if len(s) > MaxAttributeLength {
+			return fmt.Sprintf("must be at most %d characters", MaxAttributeLength)
+		}
+		if len(d.Options) > 0 && !slices.Contains(d.Options, s) {
+			return "must be one of the allowed options"
+		}
+	case AttributeNumber:
+		if _, ok := value.(float64); !ok {
+			return "must be a number"
+		}
+	case AttributeBoolean:
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+	case AttributeDate:
+		s, ok := value.(string)
+		if !ok {
+			return "must be a date (YYYY-MM-DD)"
+		}
+		if _, err := time.Parse(time.DateOnly, s); err != nil {
+			return "must be a date (YYYY-MM-DD)"
+		}
+	}
+	return ""
+}
+
+// ValidateAttributes checks custom attribute values, as decoded from JSON,
+// against defs. Null values are removed from attrs first. Unknown attributes,
+// missing required ones and invalid values are reported by attribute name.
+func ValidateAttributes(defs []AttributeDefinition, attrs map[string]interface{}) map[string]string {
+	for name, value := range attrs {
+		if value == nil {
+			delete(attrs, name)
+		}
+	}
+
+	errs := map[string]string{}
+	known := make(map[string]AttributeDefinition, len(defs))
+	for _, def := range defs {
+		known[def.Name] = def
+		if _, ok := attrs[def.Name]; !ok && def.Required {
+			errs[def.Name] = "is required"
+		}
+	}
+
+	for name, value := range attrs {
+		def, ok := known[name]
+		if !ok {
+			errs[name] = "is not a defined attribute"
+			continue
+		}
+		if msg := def.validateValue(value); msg != "" {
+			errs[name] = msg
+		}
+	}
+	return errs
+}
@@ -1,22 +1,61 @@
 package user
 
+import (
+	"context"
+	"time"
+)
+
+// UserRepository stores users with their roles. Its methods take part in the
+// transaction of ctx, if any (see transaction.Manager).
 type UserRepository interface {
-	FindAll(limit, offset int) ([]User, error)
-	FindByID(id string) (User, error)
-	FindByEmail(email string) (User, error)
-	FindByKeycloakID(keycloakID string) (User, error)
-	Save(user User) error
-	Update(user User) error
-	UpdateKeycloakID(id string, keycloakID string) error
-	Delete(id string) error
-	ChangePassword(id string, newPassword string) error // New method for changing password
+	FindAll(ctx context.Context, query ListQuery) ([]User, error)
+	Count(ctx context.Context, query ListQuery) (int64, error)
+	Stream(ctx context.Context, query ListQuery, fn func(User) error) error
+	FindByID(ctx context.Context, id string) (User, error)
+	FindByEmail(ctx context.Context, email string) (User, error)
+	// FindRegisteredEmails returns those of emails used by a user that is not deleted
+	FindRegisteredEmails(ctx context.Context, emails []string) ([]string, error)
+	FindByKeycloakID(ctx context.Context, keycloakID string) (User, error)
+	Save(ctx context.Context, user User) error       // records user.CreatedBy
+	SaveAll(ctx context.Context, users []User) error // all or nothing
+	Update(ctx context.Context, user User) error     // records user.UpdatedBy, ErrVersionConflict unless user.Version is current
+	UpdateKeycloakID(ctx context.Context, id string, keycloakID string) error
+	Delete(ctx context.Context, id string, actor string) error  // soft delete, finders skip deleted users
+	Restore(ctx context.Context, id string, actor string) error // ErrEmailTaken when a live user has the same email
+	Purge(ctx context.Context, id string) error
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	ChangePassword(ctx context.Context, id string, newPassword string, actor string) error
+	UpdateActive(ctx context.Context, id string, active bool, actor string) error
+	UpdateProfile(ctx context.Context, user User) error // replaces user.Profile, same locking as Update
+	// ScheduleDeactivation sets deactivate_at, nil cancels a scheduled deactivation
+	ScheduleDeactivation(ctx context.Context, id string, at *time.Time, actor string) error
+	// FindDueDeactivations returns active users whose deactivate_at is not after now
+	FindDueDeactivations(ctx context.Context, now time.Time, limit int) ([]User, error)
+	FindAllRoles(ctx context.Context) ([]string, error)
+	FindWithoutKeycloakID(ctx context.Context, afterID string, limit int) ([]User, error)
+}
+
+// AttributeRepository stores the definitions of the custom profile attributes.
+type AttributeRepository interface {
+	FindAll(ctx context.Context) ([]AttributeDefinition, error)
+	// Save creates the definition or replaces the one with the same name
+	Save(ctx context.Context, def AttributeDefinition) error
+	Delete(ctx context.Context, name string) error
 }
 
+// AuthService authenticates credentials, typically by trying a chain of AuthProviders.
 type AuthService interface {
-	Login(email, password string) (bool, error)
+	Authenticate(ctx context.Context, email, password string, account *User) (AuthResult, error)
 }
 
 type KeycloakService interface {
-	CreateUser(email, name, password string, roles []string) (string, error)
-	VerifyToken(accessToken string) error
+	CreateUser(ctx context.Context, email, name, password string, roles []string) (string, error)
+	// ImportUser creates the user with an existing bcrypt hash, or without a
+	// credential and the UPDATE_PASSWORD required action when requirePasswordUpdate is set.
+	ImportUser(ctx context.Context, email, name, passwordHash string, roles []string, requirePasswordUpdate bool) (string, error)
+	VerifyToken(ctx context.Context, accessToken string) error
+	AdminEvents(ctx context.Context, since time.Time) ([]KeycloakAdminEvent, error)
+	GetUser(ctx context.Context, keycloakID string) (KeycloakUser, error)
+	GetUserRealmRoles(ctx context.Context, keycloakID string) ([]string, error)
+	SetUserEnabled(ctx context.Context, keycloakID string, enabled bool) error
 }
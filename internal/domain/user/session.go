@@ -0,0 +1,30 @@
+package user
+
+import (
+	"context"
+	"time"
+)
+
+// Session is a locally issued login session. The session ID is embedded in the
+// JWT so the token can be revoked before it expires.
+type Session struct {
+	ID                string
+	UserID            string
+	KeycloakSessionID string
+	AuthProvider      string // provider that authenticated the login
+	ExpiresAt         time.Time
+	RevokedAt         *time.Time
+	CreatedAt         time.Time
+}
+
+// IsActive reports whether the session is neither revoked nor expired.
+func (s Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, session Session) error
+	FindByID(ctx context.Context, id string) (Session, error)
+	RevokeByKeycloakSessionID(ctx context.Context, keycloakSessionID string) (int64, error)
+	RevokeByUserID(ctx context.Context, userID string) (int64, error)
+}
@@ -0,0 +1,82 @@
+package authprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// Chain tries each provider in order and returns the first successful result.
+type Chain struct {
+	providers []user.AuthProvider
+}
+
+func NewChain(providers ...user.AuthProvider) *Chain {
+	return &Chain{providers: providers}
+}
+
+// FromConfig builds the chain from cfg.Providers. Providers that are listed but
+// not configured (e.g. "http" without a URL) are skipped.
+func FromConfig(cfg config.AuthConfig, httpCfg config.ExternalHTTPConfig) (*Chain, error) {
+	var providers []user.AuthProvider
+	for _, name := range cfg.Providers {
+		switch name {
+		case LocalProviderName:
+			providers = append(providers, NewLocalProvider())
+		case HTTPProviderName:
+			if cfg.HTTP.URL == "" {
+				log.Printf("[Auth] provider %q skipped: no URL configured", name)
+				continue
+			}
+			providers = append(providers, NewHTTPProvider(cfg.HTTP, httpCfg))
+		case LDAPProviderName:
+			if cfg.LDAP.URL == "" {
+				log.Printf("[Auth] provider %q skipped: no URL configured", name)
+				continue
+			}
+			providers = append(providers, NewLDAPProvider(cfg.LDAP))
+		default:
+			return nil, fmt.Errorf("unknown auth provider: %s", name)
+		}
+	}
+
+	if len(providers) == 0 {
+		return nil, errors.New("no auth provider enabled")
+	}
+	return NewChain(providers...), nil
+}
+
+// Authenticate returns ErrInvalidCredentials when at least one provider rejected
+// the credentials and none accepted them. If every provider failed with an
+// error, the last error is returned instead.
+func (c *Chain) Authenticate(ctx context.Context, email, password string, account *user.User) (user.AuthResult, error) {
+	var lastErr error
+	rejected := false
+
+	for _, provider := range c.providers {
+		result, err := provider.Authenticate(ctx, email, password, account)
+		if err == nil {
+			result.Provider = provider.Name()
+			return result, nil
+		}
+
+		if errors.Is(err, user.ErrInvalidCredentials) {
+			rejected = true
+			continue
+		}
+
+		log.Printf("[Auth] provider %s failed for %s: %v", provider.Name(), email, err)
+		lastErr = err
+	}
+
+	if rejected || lastErr == nil {
+		return user.AuthResult{}, user.ErrInvalidCredentials
+	}
+	return user.AuthResult{}, lastErr
+}
+
+var _ user.AuthService = (*Chain)(nil)
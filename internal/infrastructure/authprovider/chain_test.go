@@ -0,0 +1,112 @@
+package authprovider_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/authprovider"
+	"github.com/stretchr/testify/assert"
+)
+
+func newHTTPProvider(t *testing.T, handler http.HandlerFunc) *authprovider.HTTPProvider {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return authprovider.NewHTTPProvider(config.HTTPAuthProviderConfig{
+		URL:           server.URL,
+		Method:        http.MethodPost,
+		ContentType:   "json",
+		EmailField:    "username",
+		PasswordField: "password",
+		SuccessStatus: http.StatusOK,
+		SuccessField:  "data.authenticated",
+		NameField:     "data.user.name",
+		RolesField:    "data.user.roles",
+	}, config.ExternalHTTPConfig{})
+}
+
+func TestHTTPProvider(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		provider := newHTTPProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]string
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, "a@example.com", body["username"])
+			w.Write([]byte(`{"data":{"authenticated":true,"user":{"name":"A","roles":["ADMIN"]}}}`))
+		})
+
+		result, err := provider.Authenticate(context.Background(), "a@example.com", "secret", nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "A", result.Name)
+		assert.Equal(t, []string{"ADMIN"}, result.Roles)
+	})
+
+	t.Run("Rejected", func(t *testing.T) {
+		provider := newHTTPProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+
+		_, err := provider.Authenticate(context.Background(), "a@example.com", "wrong", nil)
+
+		assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	})
+
+	t.Run("SuccessFieldFalse", func(t *testing.T) {
+		provider := newHTTPProvider(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"data":{"authenticated":false}}`))
+		})
+
+		_, err := provider.Authenticate(context.Background(), "a@example.com", "wrong", nil)
+
+		assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	})
+}
+
+type stubProvider struct {
+	name string
+	err  error
+}
+
+func (p stubProvider) Name() string { return p.name }
+
+func (p stubProvider) Authenticate(context.Context, string, string, *user.User) (user.AuthResult, error) {
+	return user.AuthResult{}, p.err
+}
+
+func TestChain(t *testing.T) {
+	t.Run("FirstSuccessWins", func(t *testing.T) {
+		chain := authprovider.NewChain(
+			stubProvider{name: "http", err: user.ErrInvalidCredentials},
+			stubProvider{name: "local"},
+		)
+
+		result, err := chain.Authenticate(context.Background(), "a@example.com", "secret", nil)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "local", result.Provider)
+	})
+
+	t.Run("RejectionBeatsOutage", func(t *testing.T) {
+		chain := authprovider.NewChain(
+			stubProvider{name: "ldap", err: assert.AnError},
+			stubProvider{name: "local", err: user.ErrInvalidCredentials},
+		)
+
+		_, err := chain.Authenticate(context.Background(), "a@example.com", "secret", nil)
+
+		assert.ErrorIs(t, err, user.ErrInvalidCredentials)
+	})
+
+	t.Run("AllFailed", func(t *testing.T) {
+		chain := authprovider.NewChain(stubProvider{name: "ldap", err: assert.AnError})
+
+		_, err := chain.Authenticate(context.Background(), "a@example.com", "secret", nil)
+
+		assert.ErrorIs(t, err, assert.AnError)
+	})
+}
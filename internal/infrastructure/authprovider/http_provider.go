@@ -0,0 +1,156 @@
+package authprovider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/external"
+)
+
+const HTTPProviderName = "http"
+
+// HTTPProvider authenticates against an external auth service. The request
+// field names and the way success, name and roles are read from the response
+// are configurable.
+type HTTPProvider struct {
+	cfg    config.HTTPAuthProviderConfig
+	client *external.ResilientClient
+}
+
+func NewHTTPProvider(cfg config.HTTPAuthProviderConfig, httpCfg config.ExternalHTTPConfig) *HTTPProvider {
+	return &HTTPProvider{
+		cfg:    cfg,
+		client: external.NewResilientClient("auth service", httpCfg),
+	}
+}
+
+func (p *HTTPProvider) Name() string {
+	return HTTPProviderName
+}
+
+func (p *HTTPProvider) Authenticate(ctx context.Context, email, password string, _ *user.User) (user.AuthResult, error) {
+	req, err := p.newRequest(ctx, email, password)
+	if err != nil {
+		return user.AuthResult{}, err
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return user.AuthResult{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return user.AuthResult{}, user.ErrInvalidCredentials
+	}
+
+	if resp.StatusCode != p.cfg.SuccessStatus {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return user.AuthResult{}, apperror.NewAppError(http.StatusBadGateway, apperror.ExternalServiceError, "auth service request failed",
+			fmt.Errorf("auth service returned %s: %s", resp.Status, string(b)))
+	}
+
+	if p.cfg.SuccessField == "" && p.cfg.NameField == "" && p.cfg.RolesField == "" {
+		return user.AuthResult{}, nil
+	}
+
+	var body interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return user.AuthResult{}, apperror.NewAppError(http.StatusBadGateway, apperror.ExternalServiceError, "invalid auth service response", err)
+	}
+
+	if p.cfg.SuccessField != "" {
+		value, _ := lookupPath(body, p.cfg.SuccessField)
+		if ok, _ := value.(bool); !ok && value != "true" {
+			return user.AuthResult{}, user.ErrInvalidCredentials
+		}
+	}
+
+	var result user.AuthResult
+	if p.cfg.NameField != "" {
+		if name, ok := lookupPath(body, p.cfg.NameField); ok {
+			result.Name, _ = name.(string)
+		}
+	}
+	if p.cfg.RolesField != "" {
+		if roles, ok := lookupPath(body, p.cfg.RolesField); ok {
+			result.Roles = toStrings(roles)
+		}
+	}
+
+	return result, nil
+}
+
+func (p *HTTPProvider) newRequest(ctx context.Context, email, password string) (*http.Request, error) {
+	method := strings.ToUpper(p.cfg.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	if p.cfg.ContentType == "form" {
+		form := url.Values{}
+		form.Set(p.cfg.EmailField, email)
+		form.Set(p.cfg.PasswordField, password)
+		body = strings.NewReader(form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	} else {
+		payload, _ := json.Marshal(map[string]string{
+			p.cfg.EmailField:    email,
+			p.cfg.PasswordField: password,
+		})
+		body = bytes.NewReader(payload)
+		contentType = "application/json"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, p.cfg.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return req, nil
+}
+
+// lookupPath resolves a dot-separated path such as "data.user.roles" in a decoded JSON value.
+func lookupPath(value interface{}, path string) (interface{}, bool) {
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+func toStrings(value interface{}) []string {
+	switch v := value.(type) {
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case string:
+		return []string{v}
+	}
+	return nil
+}
+
+var _ user.AuthProvider = (*HTTPProvider)(nil)
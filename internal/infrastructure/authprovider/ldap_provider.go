@@ -0,0 +1,142 @@
+package authprovider
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const LDAPProviderName = "ldap"
+
+// LDAPProvider looks the user up by email (optionally with a service account)
+// and then binds as that user with the given password. Group memberships are
+// mapped to local roles through GroupRoleMap.
+type LDAPProvider struct {
+	cfg      config.LDAPAuthProviderConfig
+	groupMap map[string]string
+}
+
+func NewLDAPProvider(cfg config.LDAPAuthProviderConfig) *LDAPProvider {
+	groupMap := make(map[string]string, len(cfg.GroupRoleMap))
+	for group, role := range cfg.GroupRoleMap {
+		groupMap[strings.ToLower(group)] = role
+	}
+
+	return &LDAPProvider{cfg: cfg, groupMap: groupMap}
+}
+
+func (p *LDAPProvider) Name() string {
+	return LDAPProviderName
+}
+
+func (p *LDAPProvider) Authenticate(ctx context.Context, email, password string, _ *user.User) (user.AuthResult, error) {
+	// An empty password would be an unauthenticated bind, which most servers accept
+	if password == "" {
+		return user.AuthResult{}, user.ErrInvalidCredentials
+	}
+
+	conn, err := p.dial()
+	if err != nil {
+		return user.AuthResult{}, p.mapError(err)
+	}
+	defer conn.Close()
+
+	// Honour cancellation of the login request, stopped on return so no
+	// goroutine outlives an uncancelled context
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if p.cfg.BindDN != "" {
+		if err := conn.Bind(p.cfg.BindDN, p.cfg.BindPassword); err != nil {
+			return user.AuthResult{}, p.mapError(err)
+		}
+	}
+
+	search := ldap.NewSearchRequest(
+		p.cfg.BaseDN,
+		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, 0, false,
+		fmt.Sprintf(p.cfg.UserFilter, ldap.EscapeFilter(email)),
+		[]string{"dn", p.cfg.NameAttribute, p.cfg.GroupAttribute},
+		nil,
+	)
+	res, err := conn.Search(search)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+			return user.AuthResult{}, user.ErrInvalidCredentials
+		}
+		return user.AuthResult{}, p.mapError(err)
+	}
+	if len(res.Entries) != 1 {
+		return user.AuthResult{}, user.ErrInvalidCredentials
+	}
+	entry := res.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return user.AuthResult{}, user.ErrInvalidCredentials
+		}
+		return user.AuthResult{}, p.mapError(err)
+	}
+
+	return user.AuthResult{
+		Name:  entry.GetAttributeValue(p.cfg.NameAttribute),
+		Roles: p.rolesFor(entry.GetAttributeValues(p.cfg.GroupAttribute)),
+	}, nil
+}
+
+func (p *LDAPProvider) dial() (*ldap.Conn, error) {
+	dialer := &net.Dialer{Timeout: p.cfg.Timeout}
+	conn, err := ldap.DialURL(p.cfg.URL, ldap.DialWithDialer(dialer))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(p.cfg.Timeout)
+
+	if p.cfg.StartTLS {
+		host := ""
+		if u, err := url.Parse(p.cfg.URL); err == nil {
+			host = u.Hostname()
+		}
+		if err := conn.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+// rolesFor maps group DNs to local roles, ignoring unmapped groups.
+func (p *LDAPProvider) rolesFor(groups []string) []string {
+	var roles []string
+	seen := make(map[string]bool)
+	for _, group := range groups {
+		role, ok := p.groupMap[strings.ToLower(group)]
+		if !ok || seen[role] {
+			continue
+		}
+		seen[role] = true
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+func (p *LDAPProvider) mapError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return apperror.NewAppError(http.StatusGatewayTimeout, apperror.ExternalTimeout, "ldap timed out", err)
+	}
+	return apperror.NewAppError(http.StatusBadGateway, apperror.ExternalServiceError, "ldap request failed", err)
+}
+
+var _ user.AuthProvider = (*LDAPProvider)(nil)
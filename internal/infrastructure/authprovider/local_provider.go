@@ -0,0 +1,35 @@
+package authprovider
+
+import (
+	"context"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const LocalProviderName = "local"
+
+// LocalProvider checks the password against the bcrypt hash stored in users.password.
+type LocalProvider struct{}
+
+func NewLocalProvider() *LocalProvider {
+	return &LocalProvider{}
+}
+
+func (p *LocalProvider) Name() string {
+	return LocalProviderName
+}
+
+func (p *LocalProvider) Authenticate(_ context.Context, _ string, password string, account *user.User) (user.AuthResult, error) {
+	if account == nil || account.Password == "" {
+		return user.AuthResult{}, user.ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(account.Password), []byte(password)); err != nil {
+		return user.AuthResult{}, user.ErrInvalidCredentials
+	}
+
+	return user.AuthResult{}, nil
+}
+
+var _ user.AuthProvider = (*LocalProvider)(nil)
@@ -0,0 +1,213 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+)
+
+// ErrCircuitOpen is returned while an upstream is considered unavailable.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// ResilientClient wraps http.Client with per-attempt timeouts, bounded retries
+// with jittered backoff for idempotent requests and a circuit breaker for the
+// upstream it talks to. Use one client per upstream service.
+type ResilientClient struct {
+	name    string
+	client  *http.Client
+	cfg     config.ExternalHTTPConfig
+	breaker *circuitBreaker
+}
+
+func NewResilientClient(name string, cfg config.ExternalHTTPConfig) *ResilientClient {
+	return &ResilientClient{
+		name:    name,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		cfg:     cfg,
+		breaker: newCircuitBreaker(cfg.FailureThreshold, cfg.OpenTimeout),
+	}
+}
+
+// Do sends the request. Failures are returned as *apperror.AppError with the
+// ExternalTimeout or ExternalServiceError code; non-2xx responses are returned
+// to the caller as usual once retries are exhausted.
+func (c *ResilientClient) Do(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isIdempotent(req) {
+		attempts += c.cfg.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(req.Context(), attempt); err != nil {
+				return nil, c.mapError(err)
+			}
+			if req.Body != nil {
+				if req.GetBody == nil {
+					break
+				}
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, c.mapError(err)
+				}
+				req.Body = body
+			}
+		}
+
+		if !c.breaker.allow() {
+			return nil, c.mapError(ErrCircuitOpen)
+		}
+
+		resp, err := c.client.Do(req)
+		if err != nil {
+			lastErr = err
+			// The caller gave up, which says nothing about the upstream
+			if req.Context().Err() != nil {
+				c.breaker.abandon()
+				break
+			}
+			c.breaker.failure()
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests {
+			c.breaker.failure()
+		} else {
+			c.breaker.success()
+		}
+
+		if isRetryableStatus(resp.StatusCode) && attempt < attempts-1 {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%s returned %s", c.name, resp.Status)
+			continue
+		}
+
+		return resp, nil
+	}
+
+	return nil, c.mapError(lastErr)
+}
+
+// sleep waits for an exponential backoff with full jitter before the given attempt.
+func (c *ResilientClient) sleep(ctx context.Context, attempt int) error {
+	backoff := c.cfg.BaseBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > c.cfg.MaxBackoff {
+		backoff = c.cfg.MaxBackoff
+	}
+	if backoff <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(time.Duration(rand.Int63n(int64(backoff)) + 1))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (c *ResilientClient) mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return apperror.NewAppError(http.StatusGatewayTimeout, apperror.ExternalTimeout, c.name+" timed out", err)
+	}
+
+	if errors.Is(err, ErrCircuitOpen) {
+		return apperror.NewAppError(http.StatusServiceUnavailable, apperror.ExternalServiceError, c.name+" is temporarily unavailable", err)
+	}
+
+	return apperror.NewAppError(http.StatusBadGateway, apperror.ExternalServiceError, c.name+" request failed", err)
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// circuitBreaker opens after threshold consecutive failures and lets a single
+// trial request through once openTimeout has elapsed (half-open).
+type circuitBreaker struct {
+	mu          sync.Mutex
+	threshold   int
+	openTimeout time.Duration
+	failures    int
+	openedAt    time.Time
+	trial       bool
+}
+
+func newCircuitBreaker(threshold int, openTimeout time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, openTimeout: openTimeout}
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.threshold <= 0 || b.failures < b.threshold {
+		return true
+	}
+
+	if time.Since(b.openedAt) < b.openTimeout || b.trial {
+		return false
+	}
+
+	b.trial = true
+	return true
+}
+
+func (b *circuitBreaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.trial = false
+}
+
+// abandon ends a request without counting it, letting another trial through
+// when it was the trial.
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+func (b *circuitBreaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.trial = false
+	if b.failures >= b.threshold {
+		b.openedAt = time.Now()
+	}
+}
@@ -0,0 +1,138 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/stretchr/testify/assert"
+)
+
+func testHTTPConfig() config.ExternalHTTPConfig {
+	return config.ExternalHTTPConfig{
+		Timeout:          200 * time.Millisecond,
+		MaxRetries:       2,
+		BaseBackoff:      time.Millisecond,
+		MaxBackoff:       5 * time.Millisecond,
+		FailureThreshold: 3,
+		OpenTimeout:      time.Minute,
+	}
+}
+
+func TestResilientClientRetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewResilientClient("test", testHTTPConfig())
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+
+	resp, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestResilientClientDoesNotRetryPost(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client := NewResilientClient("test", testHTTPConfig())
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost, server.URL, nil)
+
+	resp, err := client.Do(req)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestResilientClientMapsTimeouts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	cfg := testHTTPConfig()
+	cfg.MaxRetries = 0
+	client := NewResilientClient("test", cfg)
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+
+	_, err := client.Do(req)
+
+	var appErr *apperror.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperror.ExternalTimeout, appErr.ErrorCode)
+}
+
+func TestResilientClientOpensCircuit(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := testHTTPConfig()
+	cfg.MaxRetries = 0
+	client := NewResilientClient("test", cfg)
+
+	for i := 0; i < 3; i++ {
+		req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	_, err := client.Do(req)
+
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	var appErr *apperror.AppError
+	assert.True(t, errors.As(err, &appErr))
+	assert.Equal(t, apperror.ExternalServiceError, appErr.ErrorCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestResilientClientIgnoresCallerCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer server.Close()
+
+	cfg := testHTTPConfig()
+	cfg.Timeout = time.Second
+	client := NewResilientClient("test", cfg)
+
+	// Clients disconnecting must not open the circuit against a healthy upstream
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		_, err := client.Do(req)
+		cancel()
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrCircuitOpen)
+	}
+
+	assert.True(t, client.breaker.allow())
+}
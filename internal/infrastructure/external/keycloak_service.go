@@ -2,31 +2,36 @@ package external
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
 	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type keycloakService struct {
 	cfg    config.KeycloakConfig
-	client *http.Client
+	client *ResilientClient
 }
 
-func NewKeycloakService(cfg config.KeycloakConfig) user.KeycloakService {
+func NewKeycloakService(cfg config.KeycloakConfig, httpCfg config.ExternalHTTPConfig) user.KeycloakService {
 	return &keycloakService{
 		cfg:    cfg,
-		client: &http.Client{Timeout: 10 * time.Second},
+		client: NewResilientClient("keycloak", httpCfg),
 	}
 }
 
-func (s *keycloakService) getAdminToken() (string, error) {
+func (s *keycloakService) getAdminToken(ctx context.Context) (string, error) {
 	data := url.Values{}
 	data.Set("client_id", "admin-cli")
 	data.Set("username", s.cfg.AdminUser)
@@ -34,14 +39,17 @@ func (s *keycloakService) getAdminToken() (string, error) {
 	data.Set("grant_type", "password")
 
 	u := fmt.Sprintf("%s/realms/master/protocol/openid-connect/token", s.cfg.URL)
-	resp, err := s.client.PostForm(u, data)
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, strings.NewReader(data.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("failed to get admin token: %s", resp.Status)
+		return "", statusError("failed to get admin token", resp)
 	}
 
 	var result struct {
@@ -54,8 +62,8 @@ func (s *keycloakService) getAdminToken() (string, error) {
 	return result.AccessToken, nil
 }
 
-func (s *keycloakService) CreateUser(email, name, password string, roles []string) (string, error) {
-	token, err := s.getAdminToken()
+func (s *keycloakService) CreateUser(ctx context.Context, email, name, password string, roles []string) (string, error) {
+	token, err := s.getAdminToken(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -75,9 +83,66 @@ func (s *keycloakService) CreateUser(email, name, password string, roles []strin
 		},
 	}
 
+	return s.createUser(ctx, token, email, userData)
+}
+
+// ImportUser creates the user in Keycloak without knowing the plaintext password.
+// Importing bcrypt hashes requires a bcrypt password hash provider in Keycloak.
+func (s *keycloakService) ImportUser(ctx context.Context, email, name, passwordHash string, roles []string, requirePasswordUpdate bool) (string, error) {
+	token, err := s.getAdminToken(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	userData := map[string]interface{}{
+		"username":      email,
+		"email":         email,
+		"enabled":       true,
+		"emailVerified": true,
+		"firstName":     name,
+	}
+
+	if requirePasswordUpdate || passwordHash == "" {
+		userData["requiredActions"] = []string{"UPDATE_PASSWORD"}
+	} else {
+		cost, err := bcrypt.Cost([]byte(passwordHash))
+		if err != nil {
+			return "", fmt.Errorf("unsupported password hash: %w", err)
+		}
+		credentialData, _ := json.Marshal(map[string]interface{}{
+			"hashIterations": cost,
+			"algorithm":      "bcrypt",
+		})
+		secretData, _ := json.Marshal(map[string]interface{}{
+			"value": passwordHash,
+		})
+		userData["credentials"] = []map[string]interface{}{
+			{
+				"type":           "password",
+				"credentialData": string(credentialData),
+				"secretData":     string(secretData),
+				"temporary":      false,
+			},
+		}
+	}
+
+	keycloakID, err := s.createUser(ctx, token, email, userData)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.assignRealmRoles(ctx, token, keycloakID, roles); err != nil {
+		return keycloakID, err
+	}
+
+	return keycloakID, nil
+}
+
+// createUser posts the user representation and returns the new (or already existing) Keycloak ID.
+func (s *keycloakService) createUser(ctx context.Context, token, email string, userData map[string]interface{}) (string, error) {
 	body, _ := json.Marshal(userData)
 	u := fmt.Sprintf("%s/admin/realms/%s/users", s.cfg.URL, s.cfg.Realm)
-	req, _ := http.NewRequest("POST", u, bytes.NewBuffer(body))
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewBuffer(body))
 	req.Header.Set("Authorization", "Bearer "+token)
 	req.Header.Set("Content-Type", "application/json")
 
@@ -89,12 +154,11 @@ func (s *keycloakService) CreateUser(email, name, password string, roles []strin
 
 	if resp.StatusCode == http.StatusConflict {
 		// User already exists, let's try to find their ID
-		return s.findUserIDByEmail(email, token)
+		return s.findUserIDByEmail(ctx, email, token)
 	}
 
 	if resp.StatusCode != http.StatusCreated {
-		b, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed to create user: %s, body: %s", resp.Status, string(b))
+		return "", statusError("failed to create user", resp)
 	}
 
 	// Keycloak returns user ID in Location header
@@ -103,9 +167,48 @@ func (s *keycloakService) CreateUser(email, name, password string, roles []strin
 	return parts[len(parts)-1], nil
 }
 
-func (s *keycloakService) findUserIDByEmail(email, token string) (string, error) {
-	u := fmt.Sprintf("%s/admin/realms/%s/users?username=%s", s.cfg.URL, s.cfg.Realm, email)
-	req, _ := http.NewRequest("GET", u, nil)
+// assignRealmRoles maps the realm roles that exist in Keycloak to the user.
+// Local roles without a Keycloak counterpart are skipped.
+func (s *keycloakService) assignRealmRoles(ctx context.Context, token, keycloakID string, roles []string) error {
+	var representations []json.RawMessage
+	for _, role := range roles {
+		var representation json.RawMessage
+		u := fmt.Sprintf("%s/admin/realms/%s/roles/%s", s.cfg.URL, s.cfg.Realm, url.PathEscape(role))
+		if err := s.getJSON(ctx, u, token, &representation); err != nil {
+			if errors.Is(err, user.ErrUserNotFound) {
+				continue
+			}
+			return err
+		}
+		representations = append(representations, representation)
+	}
+
+	if len(representations) == 0 {
+		return nil
+	}
+
+	body, _ := json.Marshal(representations)
+	u := fmt.Sprintf("%s/admin/realms/%s/users/%s/role-mappings/realm", s.cfg.URL, s.cfg.Realm, url.PathEscape(keycloakID))
+	req, _ := http.NewRequestWithContext(ctx, "POST", u, bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return statusError("failed to assign roles", resp)
+	}
+
+	return nil
+}
+
+func (s *keycloakService) findUserIDByEmail(ctx context.Context, email, token string) (string, error) {
+	u := fmt.Sprintf("%s/admin/realms/%s/users?exact=true&username=%s", s.cfg.URL, s.cfg.Realm, url.QueryEscape(email))
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 
 	resp, err := s.client.Do(req)
@@ -114,6 +217,10 @@ func (s *keycloakService) findUserIDByEmail(email, token string) (string, error)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError("failed to find user", resp)
+	}
+
 	var users []struct {
 		ID string `json:"id"`
 	}
@@ -128,9 +235,9 @@ func (s *keycloakService) findUserIDByEmail(email, token string) (string, error)
 	return users[0].ID, nil
 }
 
-func (s *keycloakService) VerifyToken(accessToken string) error {
+func (s *keycloakService) VerifyToken(ctx context.Context, accessToken string) error {
 	u := fmt.Sprintf("%s/realms/%s/protocol/openid-connect/userinfo", s.cfg.URL, s.cfg.Realm)
-	req, _ := http.NewRequest("GET", u, nil)
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 
 	resp, err := s.client.Do(req)
@@ -139,9 +246,198 @@ func (s *keycloakService) VerifyToken(accessToken string) error {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%w (Keycloak returned %d)", user.ErrKeycloakSessionInvalid, resp.StatusCode)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("session invalid (Keycloak returned %d)", resp.StatusCode)
+		return statusError("failed to verify session", resp)
+	}
+
+	return nil
+}
+
+// adminEventsPageSize is the page size used when reading the admin event log.
+const adminEventsPageSize = 100
+
+// AdminEvents returns the USER and REALM_ROLE_MAPPING admin events recorded
+// from since, inclusive, until the call started. Keycloak only filters by day
+// and pages the log newest first, so events recorded during the call shift the
+// pages: the window is applied here and events read twice are dropped.
+func (s *keycloakService) AdminEvents(ctx context.Context, since time.Time) ([]user.KeycloakAdminEvent, error) {
+	token, err := s.getAdminToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	until := time.Now()
+	type eventKey struct {
+		time          int64
+		operationType string
+		resourcePath  string
+	}
+	seen := map[eventKey]bool{}
+
+	var events []user.KeycloakAdminEvent
+	for first := 0; ; first += adminEventsPageSize {
+		query := url.Values{}
+		// A day of margin on both ends, Keycloak reads the dates in its own time zone
+		query.Set("dateFrom", since.AddDate(0, 0, -1).Format("2006-01-02"))
+		query.Set("dateTo", until.AddDate(0, 0, 1).Format("2006-01-02"))
+		query.Add("resourceTypes", user.KeycloakResourceUser)
+		query.Add("resourceTypes", user.KeycloakResourceRealmRoleMapping)
+		query.Set("first", strconv.Itoa(first))
+		query.Set("max", strconv.Itoa(adminEventsPageSize))
+
+		u := fmt.Sprintf("%s/admin/realms/%s/admin-events?%s", s.cfg.URL, s.cfg.Realm, query.Encode())
+		var page []struct {
+			Time          int64  `json:"time"`
+			OperationType string `json:"operationType"`
+			ResourceType  string `json:"resourceType"`
+			ResourcePath  string `json:"resourcePath"`
+		}
+		if err := s.getJSON(ctx, u, token, &page); err != nil {
+			return nil, err
+		}
+
+		reachedSince := false
+		for _, e := range page {
+			eventTime := time.UnixMilli(e.Time)
+			if eventTime.Before(since) {
+				// events are returned newest first
+				reachedSince = true
+				continue
+			}
+			if eventTime.After(until) {
+				// recorded during this call, left to the next one
+				continue
+			}
+
+			key := eventKey{time: e.Time, operationType: e.OperationType, resourcePath: e.ResourcePath}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			events = append(events, user.KeycloakAdminEvent{
+				Time:          eventTime,
+				OperationType: e.OperationType,
+				ResourceType:  e.ResourceType,
+				ResourcePath:  e.ResourcePath,
+			})
+		}
+
+		if reachedSince || len(page) < adminEventsPageSize {
+			return events, nil
+		}
+	}
+}
+
+func (s *keycloakService) GetUser(ctx context.Context, keycloakID string) (user.KeycloakUser, error) {
+	token, err := s.getAdminToken(ctx)
+	if err != nil {
+		return user.KeycloakUser{}, err
+	}
+
+	var kcUser struct {
+		ID        string `json:"id"`
+		Email     string `json:"email"`
+		FirstName string `json:"firstName"`
+		LastName  string `json:"lastName"`
+		Enabled   bool   `json:"enabled"`
+	}
+	u := fmt.Sprintf("%s/admin/realms/%s/users/%s", s.cfg.URL, s.cfg.Realm, url.PathEscape(keycloakID))
+	if err := s.getJSON(ctx, u, token, &kcUser); err != nil {
+		return user.KeycloakUser{}, err
+	}
+
+	return user.KeycloakUser{
+		ID:        kcUser.ID,
+		Email:     kcUser.Email,
+		FirstName: kcUser.FirstName,
+		LastName:  kcUser.LastName,
+		Enabled:   kcUser.Enabled,
+	}, nil
+}
+
+// GetUserRealmRoles returns the realm roles directly mapped to the user.
+func (s *keycloakService) GetUserRealmRoles(ctx context.Context, keycloakID string) ([]string, error) {
+	token, err := s.getAdminToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var mappings []struct {
+		Name string `json:"name"`
+	}
+	u := fmt.Sprintf("%s/admin/realms/%s/users/%s/role-mappings/realm", s.cfg.URL, s.cfg.Realm, url.PathEscape(keycloakID))
+	if err := s.getJSON(ctx, u, token, &mappings); err != nil {
+		return nil, err
+	}
+
+	roles := make([]string, 0, len(mappings))
+	for _, m := range mappings {
+		roles = append(roles, m.Name)
+	}
+	return roles, nil
+}
+
+// SetUserEnabled enables or disables the user in Keycloak. Keycloak refuses
+// logins and token refreshes of disabled users.
+func (s *keycloakService) SetUserEnabled(ctx context.Context, keycloakID string, enabled bool) error {
+	token, err := s.getAdminToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"enabled": enabled})
+	u := fmt.Sprintf("%s/admin/realms/%s/users/%s", s.cfg.URL, s.cfg.Realm, url.PathEscape(keycloakID))
+	req, _ := http.NewRequestWithContext(ctx, "PUT", u, bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return user.ErrUserNotFound
+	}
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return statusError("failed to update user", resp)
 	}
 
 	return nil
 }
+
+// getJSON performs an authorized admin GET request and decodes the JSON body into out.
+func (s *keycloakService) getJSON(ctx context.Context, u, token string, out interface{}) error {
+	req, _ := http.NewRequestWithContext(ctx, "GET", u, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		// the admin API answers 404 for unknown users and roles alike
+		return user.ErrUserNotFound
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return statusError("keycloak request failed", resp)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// statusError converts an unexpected Keycloak response into an ExternalServiceError.
+func statusError(message string, resp *http.Response) error {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return apperror.NewAppError(http.StatusBadGateway, apperror.ExternalServiceError, message,
+		fmt.Errorf("keycloak returned %s: %s", resp.Status, string(b)))
+}
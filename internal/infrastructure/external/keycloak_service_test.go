@@ -0,0 +1,80 @@
+package external
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/stretchr/testify/assert"
+)
+
+type adminEvent struct {
+	Time          int64  `json:"time"`
+	OperationType string `json:"operationType"`
+	ResourceType  string `json:"resourceType"`
+	ResourcePath  string `json:"resourcePath"`
+}
+
+func TestAdminEventsWindow(t *testing.T) {
+	since := time.Now().Add(-time.Hour).Truncate(time.Millisecond)
+
+	// newest first, as Keycloak returns them
+	var log []adminEvent
+	for i := adminEventsPageSize + 50; i >= 0; i-- {
+		log = append(log, adminEvent{
+			Time:          since.Add(time.Duration(i) * time.Second).UnixMilli(),
+			OperationType: "UPDATE",
+			ResourceType:  user.KeycloakResourceUser,
+			ResourcePath:  "users/" + strconv.Itoa(i),
+		})
+	}
+	log = append(log, adminEvent{Time: since.Add(-time.Second).UnixMilli(), OperationType: "UPDATE", ResourceType: user.KeycloakResourceUser, ResourcePath: "users/old"})
+
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			json.NewEncoder(w).Encode(map[string]string{"access_token": "token"})
+			return
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		first, _ := strconv.Atoi(r.URL.Query().Get("first"))
+		max, _ := strconv.Atoi(r.URL.Query().Get("max"))
+		end := min(first+max, len(log))
+		json.NewEncoder(w).Encode(log[min(first, end):end])
+
+		// An event recorded while paging shifts the next pages by one
+		if first == 0 {
+			log = append([]adminEvent{{
+				Time:          time.Now().Add(time.Minute).UnixMilli(),
+				OperationType: "DELETE",
+				ResourceType:  user.KeycloakResourceUser,
+				ResourcePath:  "users/new",
+			}}, log...)
+		}
+	}))
+	defer server.Close()
+
+	service := NewKeycloakService(config.KeycloakConfig{URL: server.URL, Realm: "test"}, testHTTPConfig())
+
+	events, err := service.AdminEvents(context.Background(), since)
+
+	assert.NoError(t, err)
+	assert.Len(t, events, adminEventsPageSize+51)
+	paths := map[string]bool{}
+	for _, e := range events {
+		paths[e.ResourcePath] = true
+	}
+	assert.Len(t, paths, adminEventsPageSize+51)
+	assert.True(t, paths["users/0"], "the event at since is included")
+	assert.False(t, paths["users/old"])
+	assert.False(t, paths["users/new"])
+}
@@ -0,0 +1,80 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqltx"
+)
+
+type attributeRepo struct {
+	db *sql.DB
+}
+
+func NewAttributeRepo(db *sql.DB) user.AttributeRepository {
+	return &attributeRepo{db: db}
+}
+
+// conn returns the transaction of ctx, if any, or the database.
+func (r *attributeRepo) conn(ctx context.Context) sqltx.Conn {
+	return sqltx.From(ctx, r.db)
+}
+
+func (r *attributeRepo) FindAll(ctx context.Context) ([]user.AttributeDefinition, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, "SELECT name, COALESCE(label, ''), type, required, options, created_at, updated_at FROM user_attribute_definitions ORDER BY name")
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	defs := []user.AttributeDefinition{}
+	for rows.Next() {
+		var def user.AttributeDefinition
+		var options sql.NullString
+		if err := rows.Scan(&def.Name, &def.Label, &def.Type, &def.Required, &options, &def.CreatedAt, &def.UpdatedAt); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		if options.Valid && options.String != "" {
+			if err := json.Unmarshal([]byte(options.String), &def.Options); err != nil {
+				return nil, err
+			}
+		}
+		defs = append(defs, def)
+	}
+	return defs, apperror.HandleDatabaseError(rows.Err())
+}
+
+func (r *attributeRepo) Save(ctx context.Context, def user.AttributeDefinition) error {
+	var options sql.NullString
+	if len(def.Options) > 0 {
+		b, err := json.Marshal(def.Options)
+		if err != nil {
+			return err
+		}
+		options = sql.NullString{String: string(b), Valid: true}
+	}
+
+	_, err := r.conn(ctx).ExecContext(ctx, `
+		INSERT INTO user_attribute_definitions(name, label, type, required, options) VALUES(?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE label = VALUES(label), type = VALUES(type), required = VALUES(required), options = VALUES(options), updated_at = CURRENT_TIMESTAMP
+	`, def.Name, nullString(def.Label), def.Type, def.Required, options)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *attributeRepo) Delete(ctx context.Context, name string) error {
+	res, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM user_attribute_definitions WHERE name = ?", name)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	if n == 0 {
+		return user.ErrAttributeNotFound
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package mysql
+
+import (
+	"database/sql"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/google/uuid"
+)
+
+type fileRepo struct {
+	db *sql.DB
+}
+
+func NewFileRepo(db *sql.DB) file.Repository {
+	return &fileRepo{db: db}
+}
+
+func (r *fileRepo) Save(f file.File) error {
+	if f.ID == "" {
+		f.ID = uuid.New().String()
+	}
+	_, err := r.db.Exec(
+		"INSERT INTO files(id, owner_id, name, content_type, size, checksum, storage_key) VALUES(?, ?, ?, ?, ?, ?, ?)",
+		f.ID, f.OwnerID, f.Name, f.ContentType, f.Size, nullString(f.Checksum), f.StorageKey,
+	)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *fileRepo) FindByID(id string) (file.File, error) {
+	var f file.File
+	err := r.db.QueryRow(
+		"SELECT id, owner_id, name, content_type, size, COALESCE(checksum, ''), storage_key, created_at FROM files WHERE id = ?",
+		id,
+	).Scan(&f.ID, &f.OwnerID, &f.Name, &f.ContentType, &f.Size, &f.Checksum, &f.StorageKey, &f.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return f, file.ErrFileNotFound
+		}
+		return f, apperror.HandleDatabaseError(err)
+	}
+	return f, nil
+}
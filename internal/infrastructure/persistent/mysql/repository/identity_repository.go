@@ -0,0 +1,71 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqltx"
+	"github.com/google/uuid"
+)
+
+type identityRepo struct {
+	db *sql.DB
+}
+
+func NewIdentityRepo(db *sql.DB) user.IdentityRepository {
+	return &identityRepo{db: db}
+}
+
+// conn returns the transaction of ctx, if any, or the database.
+func (r *identityRepo) conn(ctx context.Context) sqltx.Conn {
+	return sqltx.From(ctx, r.db)
+}
+
+func (r *identityRepo) Create(ctx context.Context, i user.Identity) error {
+	if i.ID == "" {
+		i.ID = uuid.New().String()
+	}
+	_, err := r.conn(ctx).ExecContext(ctx,
+		"INSERT INTO user_identities(id, user_id, provider, subject, email) VALUES(?, ?, ?, ?, ?)",
+		i.ID, i.UserID, i.Provider, i.Subject, nullString(i.Email),
+	)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *identityRepo) FindByProviderSubject(ctx context.Context, provider, subject string) (user.Identity, error) {
+	var i user.Identity
+	err := r.conn(ctx).QueryRowContext(ctx,
+		"SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at FROM user_identities WHERE provider = ? AND subject = ?",
+		provider, subject,
+	).Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return i, user.ErrIdentityNotFound
+		}
+		return i, apperror.HandleDatabaseError(err)
+	}
+	return i, nil
+}
+
+func (r *identityRepo) FindByUserID(ctx context.Context, userID string) ([]user.Identity, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx,
+		"SELECT id, user_id, provider, subject, COALESCE(email, ''), created_at FROM user_identities WHERE user_id = ? ORDER BY created_at",
+		userID,
+	)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	identities := []user.Identity{}
+	for rows.Next() {
+		var i user.Identity
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		identities = append(identities, i)
+	}
+	return identities, apperror.HandleDatabaseError(rows.Err())
+}
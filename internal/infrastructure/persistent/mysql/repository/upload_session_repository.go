@@ -0,0 +1,107 @@
+package mysql
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+)
+
+const uploadSessionColumns = "id, owner_id, name, content_type, size, part_size, COALESCE(checksum, ''), status, storage_key, upload_id, expires_at, created_at"
+
+type uploadSessionRepo struct {
+	db *sql.DB
+}
+
+func NewUploadSessionRepo(db *sql.DB) file.UploadSessionRepository {
+	return &uploadSessionRepo{db: db}
+}
+
+func (r *uploadSessionRepo) Create(s file.UploadSession) error {
+	_, err := r.db.Exec(
+		"INSERT INTO upload_sessions(id, owner_id, name, content_type, size, part_size, checksum, status, storage_key, upload_id, expires_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		s.ID, s.OwnerID, s.Name, s.ContentType, s.Size, s.PartSize, nullString(s.Checksum), s.Status, s.StorageKey, s.UploadID, s.ExpiresAt,
+	)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *uploadSessionRepo) FindByID(id string) (file.UploadSession, error) {
+	s, err := scanUploadSession(r.db.QueryRow("SELECT "+uploadSessionColumns+" FROM upload_sessions WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return s, file.ErrUploadSessionNotFound
+		}
+		return s, apperror.HandleDatabaseError(err)
+	}
+
+	rows, err := r.db.Query(
+		"SELECT number, size, etag, checksum FROM upload_session_parts WHERE session_id = ? ORDER BY number",
+		id,
+	)
+	if err != nil {
+		return s, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	s.Parts = []file.UploadedPart{}
+	for rows.Next() {
+		var p file.UploadedPart
+		if err := rows.Scan(&p.Number, &p.Size, &p.ETag, &p.Checksum); err != nil {
+			return s, apperror.HandleDatabaseError(err)
+		}
+		s.Parts = append(s.Parts, p)
+	}
+	return s, apperror.HandleDatabaseError(rows.Err())
+}
+
+func (r *uploadSessionRepo) SavePart(sessionID string, p file.UploadedPart) error {
+	_, err := r.db.Exec(
+		`INSERT INTO upload_session_parts(session_id, number, size, etag, checksum) VALUES(?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE size = VALUES(size), etag = VALUES(etag), checksum = VALUES(checksum)`,
+		sessionID, p.Number, p.Size, p.ETag, p.Checksum,
+	)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *uploadSessionRepo) UpdateStatus(id, status string) error {
+	res, err := r.db.Exec("UPDATE upload_sessions SET status = ? WHERE id = ?", status, id)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return file.ErrUploadSessionNotFound
+	}
+	return nil
+}
+
+func (r *uploadSessionRepo) FindExpired(now time.Time, limit int) ([]file.UploadSession, error) {
+	rows, err := r.db.Query(
+		"SELECT "+uploadSessionColumns+" FROM upload_sessions WHERE status = ? AND expires_at < ? ORDER BY expires_at LIMIT ?",
+		file.UploadPending, now, limit,
+	)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	sessions := []file.UploadSession{}
+	for rows.Next() {
+		s, err := scanUploadSession(rows)
+		if err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, apperror.HandleDatabaseError(rows.Err())
+}
+
+func scanUploadSession(row interface{ Scan(...any) error }) (file.UploadSession, error) {
+	var s file.UploadSession
+	err := row.Scan(&s.ID, &s.OwnerID, &s.Name, &s.ContentType, &s.Size, &s.PartSize, &s.Checksum,
+		&s.Status, &s.StorageKey, &s.UploadID, &s.ExpiresAt, &s.CreatedAt)
+	if err == nil {
+		s.TotalParts = file.PartCount(s.Size, s.PartSize)
+	}
+	return s, err
+}
@@ -0,0 +1,145 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqltx"
+	"github.com/google/uuid"
+)
+
+type usageRepo struct {
+	db *sql.DB
+}
+
+func NewUsageRepo(db *sql.DB) file.UsageRepository {
+	return &usageRepo{db: db}
+}
+
+func (r *usageRepo) Usage(ownerID string, now time.Time) (file.Usage, error) {
+	return ownerUsage(context.Background(), r.db, ownerID, now)
+}
+
+// ownerUsage reads the usage of owner through conn, see Usage.
+func ownerUsage(ctx context.Context, conn sqltx.Conn, ownerID string, now time.Time) (file.Usage, error) {
+	u := file.Usage{OwnerID: ownerID}
+	var quota sql.NullInt64
+	err := conn.QueryRowContext(ctx,
+		`SELECT
+			(SELECT COUNT(*) FROM files WHERE owner_id = ?),
+			(SELECT COALESCE(SUM(size), 0) FROM files WHERE owner_id = ?),
+			(SELECT COALESCE(SUM(size), 0) FROM upload_sessions WHERE owner_id = ? AND status = ? AND expires_at > ?)
+				+ (SELECT COALESCE(SUM(size), 0) FROM storage_reservations WHERE owner_id = ? AND expires_at > ?),
+			(SELECT quota_bytes FROM storage_quotas WHERE user_id = ?)`,
+		ownerID, ownerID, ownerID, file.UploadPending, now, ownerID, now, ownerID,
+	).Scan(&u.Files, &u.UsedBytes, &u.ReservedBytes, &quota)
+	if err != nil {
+		return u, apperror.HandleDatabaseError(err)
+	}
+	u.QuotaBytes, u.CustomQuota = quota.Int64, quota.Valid
+	return u, nil
+}
+
+func (r *usageRepo) Reserve(ownerID string, size int64, now, expiresAt time.Time, allow func(file.Usage) bool) (string, error) {
+	id := uuid.New().String()
+	err := sqltx.WithinTx(context.Background(), r.db, func(ctx context.Context) error {
+		conn := sqltx.From(ctx, r.db)
+
+		// Locking the owner makes concurrent reservations wait for this one
+		var locked string
+		err := conn.QueryRowContext(ctx, "SELECT id FROM users WHERE id = ? FOR UPDATE", ownerID).Scan(&locked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return file.ErrOwnerNotFound
+		}
+		if err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+		if _, err := conn.ExecContext(ctx, "DELETE FROM storage_reservations WHERE owner_id = ? AND expires_at <= ?", ownerID, now); err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+
+		usage, err := ownerUsage(ctx, conn, ownerID, now)
+		if err != nil {
+			return err
+		}
+		if !allow(usage) {
+			return file.ErrQuotaExceeded
+		}
+
+		_, err = conn.ExecContext(ctx,
+			"INSERT INTO storage_reservations(id, owner_id, size, expires_at) VALUES(?, ?, ?, ?)",
+			id, ownerID, size, expiresAt,
+		)
+		return apperror.HandleDatabaseError(err)
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (r *usageRepo) Release(id string) error {
+	_, err := r.db.Exec("DELETE FROM storage_reservations WHERE id = ?", id)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *usageRepo) ListUsage(limit, offset int) ([]file.Usage, int64, error) {
+	var total int64
+	if err := r.db.QueryRow("SELECT COUNT(DISTINCT owner_id) FROM files").Scan(&total); err != nil {
+		return nil, 0, apperror.HandleDatabaseError(err)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT f.owner_id, COALESCE(u.email, ''), COUNT(*), SUM(f.size), q.quota_bytes
+		FROM files f
+		LEFT JOIN users u ON u.id = f.owner_id
+		LEFT JOIN storage_quotas q ON q.user_id = f.owner_id
+		GROUP BY f.owner_id, u.email, q.quota_bytes
+		ORDER BY SUM(f.size) DESC, f.owner_id
+		LIMIT ? OFFSET ?`,
+		limit, offset,
+	)
+	if err != nil {
+		return nil, 0, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	usages := []file.Usage{}
+	for rows.Next() {
+		var u file.Usage
+		var quota sql.NullInt64
+		if err := rows.Scan(&u.OwnerID, &u.Email, &u.Files, &u.UsedBytes, &quota); err != nil {
+			return nil, 0, apperror.HandleDatabaseError(err)
+		}
+		u.QuotaBytes, u.CustomQuota = quota.Int64, quota.Valid
+		usages = append(usages, u)
+	}
+	return usages, total, apperror.HandleDatabaseError(rows.Err())
+}
+
+func (r *usageRepo) SetQuota(ownerID string, quotaBytes int64) error {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE id = ? AND deleted_at IS NULL)", ownerID).Scan(&exists)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	if !exists {
+		return file.ErrOwnerNotFound
+	}
+
+	_, err = r.db.Exec(
+		`INSERT INTO storage_quotas(user_id, quota_bytes) VALUES(?, ?)
+		ON DUPLICATE KEY UPDATE quota_bytes = VALUES(quota_bytes), updated_at = CURRENT_TIMESTAMP`,
+		ownerID, quotaBytes,
+	)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *usageRepo) DeleteQuota(ownerID string) error {
+	_, err := r.db.Exec("DELETE FROM storage_quotas WHERE user_id = ?", ownerID)
+	return apperror.HandleDatabaseError(err)
+}
@@ -0,0 +1,140 @@
+package mysql
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// userSortColumns whitelists the ORDER BY columns of user list queries.
+var userSortColumns = map[string]string{
+	user.SortByName:      "name",
+	user.SortByEmail:     "email",
+	user.SortByCreatedAt: "created_at",
+	user.SortByIsActive:  "is_active",
+}
+
+func userSortColumn(field string) string {
+	if column, ok := userSortColumns[field]; ok {
+		return column
+	}
+	return "created_at"
+}
+
+func sortDirection(dir string) string {
+	if dir == user.SortDesc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// userFilter builds the WHERE clause of user list queries and its arguments.
+func userFilter(q user.ListQuery) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	if q.Trashed {
+		conds = append(conds, "deleted_at IS NOT NULL")
+	} else {
+		conds = append(conds, "deleted_at IS NULL")
+	}
+
+	if q.Search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(q.Search)) + "%"
+		conds = append(conds, "(LOWER(name) LIKE ? OR LOWER(email) LIKE ?)")
+		args = append(args, pattern, pattern)
+	}
+	if q.Email != "" {
+		conds = append(conds, "LOWER(email) = ?")
+		args = append(args, strings.ToLower(q.Email))
+	}
+	if q.Role != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = users.id AND r.name = ?)")
+		args = append(args, q.Role)
+	}
+	if q.IsActive != nil {
+		conds = append(conds, "is_active = ?")
+		args = append(args, *q.IsActive)
+	}
+	if !q.CreatedFrom.IsZero() {
+		conds = append(conds, "created_at >= ?")
+		args = append(args, q.CreatedFrom)
+	}
+	if !q.CreatedTo.IsZero() {
+		conds = append(conds, "created_at < ?")
+		args = append(args, q.CreatedTo)
+	}
+	if q.Department != "" {
+		conds = append(conds, "LOWER(department) = ?")
+		args = append(args, strings.ToLower(q.Department))
+	}
+	if q.Locale != "" {
+		conds = append(conds, "locale = ?")
+		args = append(args, q.Locale)
+	}
+	if q.Timezone != "" {
+		conds = append(conds, "timezone = ?")
+		args = append(args, q.Timezone)
+	}
+	// Sorted so the same filters always produce the same statement
+	for _, name := range slices.Sorted(maps.Keys(q.Attributes)) {
+		conds = append(conds, "JSON_UNQUOTE(JSON_EXTRACT(custom_attributes, ?)) = ?")
+		args = append(args, `$."`+name+`"`, q.Attributes[name])
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// userPage builds the WHERE, ORDER BY and LIMIT clauses of a page of users.
+// With q.After set the page starts after the cursor (keyset) instead of at
+// q.Offset; id breaks ties so the order is total.
+func userPage(q user.ListQuery) (string, []interface{}, error) {
+	where, args := userFilter(q)
+	column, dir := userSortColumn(q.SortBy), sortDirection(q.SortDir)
+
+	if q.After != nil {
+		value, err := q.After.SortValue()
+		if err != nil {
+			return "", nil, err
+		}
+
+		op := ">"
+		if dir == "DESC" {
+			op = "<"
+		}
+		keyset := fmt.Sprintf("(%s %s ? OR (%s = ? AND id %s ?))", column, op, column, op)
+		if where == "" {
+			where = " WHERE " + keyset
+		} else {
+			where += " AND " + keyset
+		}
+		args = append(args, value, value, q.After.ID)
+	}
+
+	clause := where + userOrder(q) + " LIMIT ?"
+	args = append(args, q.Limit)
+	if q.After == nil {
+		clause += " OFFSET ?"
+		args = append(args, q.Offset)
+	}
+	return clause, args, nil
+}
+
+// userOrder returns the ORDER BY clause of q, id breaks ties so the order is total.
+func userOrder(q user.ListQuery) string {
+	column, dir := userSortColumn(q.SortBy), sortDirection(q.SortDir)
+	return " ORDER BY " + column + " " + dir + ", id " + dir
+}
+
+// escapeLike escapes the LIKE wildcards so the search term is matched literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
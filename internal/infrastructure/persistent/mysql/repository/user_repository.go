@@ -1,10 +1,15 @@
 package mysql
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
 	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqltx"
 	"github.com/google/uuid"
 )
 
@@ -16,114 +21,370 @@ func NewUserRepo(db *sql.DB) user.UserRepository {
 	return &userRepo{db: db}
 }
 
-func (r *userRepo) FindAll(limit, offset int) ([]user.User, error) {
-	rows, err := r.db.Query("SELECT id, COALESCE(keycloak_id, ''), name, email, is_active FROM users LIMIT ? OFFSET ?", limit, offset)
+// conn returns the transaction of ctx, if any, or the database.
+func (r *userRepo) conn(ctx context.Context) sqltx.Conn {
+	return sqltx.From(ctx, r.db)
+}
+
+func (r *userRepo) FindAll(ctx context.Context, q user.ListQuery) ([]user.User, error) {
+	page, args, err := userPage(q)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, COALESCE(keycloak_id, ''), name, email, is_active, deactivate_at, created_at, updated_at, COALESCE(created_by, ''), COALESCE(updated_by, ''), deleted_at, version, " + profileColumns + " FROM users" + page
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, apperror.HandleDatabaseError(err)
 	}
 	defer rows.Close()
 
-	var users []user.User
+	users := []user.User{}
 	for rows.Next() {
 		var u user.User
-		rows.Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.IsActive)
-		//fetch roles for user
-		roleRows, err := r.db.Query(`
-			SELECT r.name
-			FROM roles r
-			JOIN user_roles ur ON ur.role_id = r.id
-			WHERE ur.user_id = ?
-		`, u.ID)
-		if err != nil {
+		var deactivateAt, deletedAt sql.NullTime
+		var attributes sql.NullString
+		dest := append([]interface{}{&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.IsActive, &deactivateAt, &u.CreatedAt, &u.UpdatedAt, &u.CreatedBy, &u.UpdatedBy, &deletedAt, &u.Version}, profileDest(&u.Profile, &attributes)...)
+		if err := rows.Scan(dest...); err != nil {
 			return nil, apperror.HandleDatabaseError(err)
 		}
-		defer roleRows.Close()
-
-		var roles []string
-		for roleRows.Next() {
-			var role string
-			if err := roleRows.Scan(&role); err != nil {
-				return nil, err
-			}
-			roles = append(roles, role)
+		if u.Profile.Attributes, err = decodeAttributes(attributes); err != nil {
+			return nil, err
+		}
+		if deactivateAt.Valid {
+			u.DeactivateAt = &deactivateAt.Time
+		}
+		if deletedAt.Valid {
+			u.DeletedAt = &deletedAt.Time
 		}
-
-		u.Roles = roles
 		users = append(users, u)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+
+	for i := range users {
+		roles, err := r.findRoles(ctx, users[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		users[i].Roles = roles
+	}
 	return users, nil
 }
 
-func (r *userRepo) FindByID(id string) (user.User, error) {
+// Stream calls fn for every user matching the filters of q, in the order of q,
+// reading one row at a time (Limit, Offset and After are ignored). Roles are
+// aggregated in the query so no other query runs while the rows are open.
+// Stream stops at the first error returned by fn.
+func (r *userRepo) Stream(ctx context.Context, q user.ListQuery, fn func(user.User) error) error {
+	where, args := userFilter(q)
+	query := `
+		SELECT id, COALESCE(keycloak_id, ''), name, email, is_active, created_at, updated_at,
+			COALESCE(created_by, ''), COALESCE(updated_by, ''), deleted_at, version, ` + profileColumns + `,
+			COALESCE((
+				SELECT GROUP_CONCAT(r.name ORDER BY r.name SEPARATOR ',')
+				FROM user_roles ur
+				JOIN roles r ON r.id = ur.role_id
+				WHERE ur.user_id = users.id
+			), '')
+		FROM users` + where + userOrder(q)
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u user.User
+		var deletedAt sql.NullTime
+		var attributes sql.NullString
+		var roles string
+		dest := append([]interface{}{&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
+			&u.CreatedBy, &u.UpdatedBy, &deletedAt, &u.Version}, profileDest(&u.Profile, &attributes)...)
+		if err := rows.Scan(append(dest, &roles)...); err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+		var err error
+		if u.Profile.Attributes, err = decodeAttributes(attributes); err != nil {
+			return err
+		}
+		if deletedAt.Valid {
+			u.DeletedAt = &deletedAt.Time
+		}
+		u.Roles = []string{}
+		if roles != "" {
+			u.Roles = strings.Split(roles, ",")
+		}
+
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return apperror.HandleDatabaseError(rows.Err())
+}
+
+// Count returns the number of users matching the filters of q (sort and paging are ignored).
+func (r *userRepo) Count(ctx context.Context, q user.ListQuery) (int64, error) {
+	where, args := userFilter(q)
+
+	var total int64
+	if err := r.conn(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return 0, apperror.HandleDatabaseError(err)
+	}
+	return total, nil
+}
+
+func (r *userRepo) FindByID(ctx context.Context, id string) (user.User, error) {
 	var u user.User
-	err := r.db.QueryRow("SELECT id, COALESCE(keycloak_id, ''), name, email, password FROM users WHERE id = ?", id).Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.Password)
+	var deactivateAt sql.NullTime
+	var attributes sql.NullString
+	dest := append([]interface{}{&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.Password, &u.IsActive, &deactivateAt, &u.CreatedAt, &u.UpdatedAt, &u.CreatedBy, &u.UpdatedBy, &u.Version}, profileDest(&u.Profile, &attributes)...)
+	err := r.conn(ctx).QueryRowContext(ctx, "SELECT id, COALESCE(keycloak_id, ''), name, email, password, is_active, deactivate_at, created_at, updated_at, COALESCE(created_by, ''), COALESCE(updated_by, ''), version, "+profileColumns+" FROM users WHERE id = ? AND deleted_at IS NULL", id).
+		Scan(dest...)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return u, user.ErrUserNotFound
 		}
 		return u, apperror.HandleDatabaseError(err)
 	}
-	return u, nil
+	if deactivateAt.Valid {
+		u.DeactivateAt = &deactivateAt.Time
+	}
+	if u.Profile.Attributes, err = decodeAttributes(attributes); err != nil {
+		return u, err
+	}
+
+	u.Roles, err = r.findRoles(ctx, u.ID)
+	return u, err
+}
+
+// Save inserts the user and its roles in a transaction.
+func (r *userRepo) Save(ctx context.Context, u user.User) error {
+	return sqltx.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		return r.insert(ctx, u, map[string]string{})
+	})
+}
+
+// SaveAll inserts users and their roles in a single transaction, either all of
+// them are created or none.
+func (r *userRepo) SaveAll(ctx context.Context, users []user.User) error {
+	return sqltx.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		roleIDs := map[string]string{}
+		for _, u := range users {
+			if err := r.insert(ctx, u, roleIDs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-func (r *userRepo) Save(u user.User) error {
+// insert inserts u with a new id and its roles, caching role ids in roleIDs.
+func (r *userRepo) insert(ctx context.Context, u user.User, roleIDs map[string]string) error {
 	id := uuid.New().String()
-	_, err := r.db.Exec(
-		"INSERT INTO users(id, keycloak_id, name, email, password) VALUES(?, ?, ?, ?, ?)",
-		id, u.KeycloakID, u.Name, u.Email, u.Password,
+	_, err := r.conn(ctx).ExecContext(ctx,
+		"INSERT INTO users(id, keycloak_id, name, email, password, created_by, updated_by) VALUES(?, ?, ?, ?, ?, ?, ?)",
+		id, nullString(u.KeycloakID), u.Name, u.Email, u.Password, nullableActor(u.CreatedBy), nullableActor(u.CreatedBy),
 	)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	return r.insertRoles(ctx, id, u.Roles, roleIDs)
+}
 
-	for _, role := range u.Roles {
-		var roleID string
-		err := r.db.QueryRow("SELECT id FROM roles WHERE name = ?", role).Scan(&roleID)
-		if err != nil {
+// Update saves u and replaces its roles in a transaction.
+func (r *userRepo) Update(ctx context.Context, u user.User) error {
+	return sqltx.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		// Optimistic lock: the row only matches while it still has the version that was read
+		res, err := r.conn(ctx).ExecContext(ctx,
+			"UPDATE users SET version = version + 1, keycloak_id = ?, name = ?, email = ?, password = ?, updated_at = ?, updated_by = ? WHERE id = ? AND version = ? AND deleted_at IS NULL",
+			nullString(u.KeycloakID), u.Name, u.Email, u.Password, time.Now(), nullableActor(u.UpdatedBy), u.ID, u.Version,
+		)
+		if err := affectedOrConflict(res, err); err != nil {
 			return err
 		}
-		_, err = r.db.Exec("INSERT INTO user_roles(user_id, role_id) VALUES(?, ?)", id, roleID)
-		if err != nil {
+
+		if _, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = ?", u.ID); err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+		return r.insertRoles(ctx, u.ID, u.Roles, map[string]string{})
+	})
+}
+
+// insertRoles grants roles to a user, caching role ids in roleIDs.
+func (r *userRepo) insertRoles(ctx context.Context, userID string, roles []string, roleIDs map[string]string) error {
+	for _, role := range roles {
+		roleID, ok := roleIDs[role]
+		if !ok {
+			if err := r.conn(ctx).QueryRowContext(ctx, "SELECT id FROM roles WHERE name = ?", role).Scan(&roleID); err != nil {
+				return apperror.HandleDatabaseError(err)
+			}
+			roleIDs[role] = roleID
+		}
+		if _, err := r.conn(ctx).ExecContext(ctx, "INSERT INTO user_roles(user_id, role_id) VALUES(?, ?)", userID, roleID); err != nil {
 			return apperror.HandleDatabaseError(err)
 		}
 	}
+	return nil
+}
 
+// UpdateProfile replaces the profile columns, with the same optimistic lock as Update.
+func (r *userRepo) UpdateProfile(ctx context.Context, u user.User) error {
+	attributes, err := encodeAttributes(u.Profile.Attributes)
+	if err != nil {
+		return err
+	}
+
+	p := u.Profile
+	res, err := r.conn(ctx).ExecContext(ctx,
+		"UPDATE users SET version = version + 1, phone = ?, department = ?, job_title = ?, avatar_url = ?, locale = ?, timezone = ?, custom_attributes = ?, updated_at = ?, updated_by = ? WHERE id = ? AND version = ? AND deleted_at IS NULL",
+		nullString(p.Phone), nullString(p.Department), nullString(p.JobTitle), nullString(p.AvatarURL), nullString(p.Locale), nullString(p.Timezone),
+		attributes, time.Now(), nullableActor(u.UpdatedBy), u.ID, u.Version,
+	)
+	return affectedOrConflict(res, err)
+}
+
+func (r *userRepo) Delete(ctx context.Context, id string, actor string) error {
+	now := time.Now()
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, deleted_at = ?, updated_at = ?, updated_by = ? WHERE id = ? AND deleted_at IS NULL", now, now, nullableActor(actor), id)
 	return apperror.HandleDatabaseError(err)
 }
 
-func (r *userRepo) Update(u user.User) error {
+// Restore clears deleted_at of a soft-deleted user, unless another user
+// registered its email in the meantime.
+func (r *userRepo) Restore(ctx context.Context, id string, actor string) error {
+	return sqltx.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		var email string
+		err := r.conn(ctx).QueryRowContext(ctx, "SELECT email FROM users WHERE id = ? AND deleted_at IS NOT NULL", id).Scan(&email)
+		if errors.Is(err, sql.ErrNoRows) {
+			return user.ErrUserNotFound
+		}
+		if err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
 
-	_, err := r.db.Exec(
-		"UPDATE users SET keycloak_id = ?, name = ?, email = ?, password = ? WHERE id = ?",
-		u.KeycloakID, u.Name, u.Email, u.Password, u.ID,
-	)
+		var taken int
+		if err := r.conn(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE email = ? AND deleted_at IS NULL", email).Scan(&taken); err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+		if taken > 0 {
+			return user.ErrEmailTaken
+		}
 
-	// Update roles
-	_, err = r.db.Exec("DELETE FROM user_roles WHERE user_id = ?", u.ID)
+		res, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, deleted_at = NULL, updated_at = ?, updated_by = ? WHERE id = ? AND deleted_at IS NOT NULL", time.Now(), nullableActor(actor), id)
+		return affectedOrNotFound(res, err)
+	})
+}
+
+// Purge permanently deletes a soft-deleted user with its roles, sessions,
+// identities and storage quota.
+func (r *userRepo) Purge(ctx context.Context, id string) error {
+	n, err := r.purge(ctx, "id = ? AND deleted_at IS NOT NULL", id)
 	if err != nil {
 		return err
 	}
+	if n == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
+}
 
-	for _, role := range u.Roles {
-		var roleID string
-		err := r.db.QueryRow("SELECT id FROM roles WHERE name = ?", role).Scan(&roleID)
-		if err != nil {
-			return err
+// PurgeDeletedBefore permanently deletes the users soft-deleted before cutoff,
+// like Purge.
+func (r *userRepo) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return r.purge(ctx, "deleted_at IS NOT NULL AND deleted_at < ?", cutoff)
+}
+
+// userChildTables reference users.id. Their REFERENCES are column-level, which
+// InnoDB parses but ignores, so ON DELETE CASCADE does not apply on MySQL.
+var userChildTables = []string{"user_roles", "user_sessions", "user_identities", "storage_quotas"}
+
+// purge deletes the users matching cond and their rows in userChildTables in
+// a transaction, returning the number of users deleted.
+func (r *userRepo) purge(ctx context.Context, cond string, args ...interface{}) (int64, error) {
+	var n int64
+	err := sqltx.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		for _, table := range userChildTables {
+			if _, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id IN (SELECT id FROM users WHERE "+cond+")", args...); err != nil {
+				return apperror.HandleDatabaseError(err)
+			}
 		}
-		_, err = r.db.Exec("INSERT INTO user_roles(user_id, role_id) VALUES(?, ?)", u.ID, roleID)
+
+		res, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM users WHERE "+cond, args...)
 		if err != nil {
-			return err
+			return apperror.HandleDatabaseError(err)
 		}
+		n, err = res.RowsAffected()
+		return apperror.HandleDatabaseError(err)
+	})
+	return n, err
+}
+
+// nullableActor stores system actions (no authenticated user) as NULL.
+func nullableActor(actor string) sql.NullString {
+	return sql.NullString{String: actor, Valid: actor != ""}
+}
+
+// affectedOrConflict reports ErrVersionConflict when no row had the expected version.
+func affectedOrConflict(res sql.Result, err error) error {
+	if err := affectedOrNotFound(res, err); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return user.ErrVersionConflict
+		}
+		return err
 	}
+	return nil
+}
 
-	return err
+func affectedOrNotFound(res sql.Result, err error) error {
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	if n == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
 }
 
-func (r *userRepo) Delete(id string) error {
-	_, err := r.db.Exec("DELETE FROM users WHERE id = ?", id)
-	return apperror.HandleDatabaseError(err)
+// FindRegisteredEmails returns those of emails used by a user that is not
+// deleted, in a single query.
+func (r *userRepo) FindRegisteredEmails(ctx context.Context, emails []string) ([]string, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(emails))
+	args := make([]interface{}, len(emails))
+	for i, email := range emails {
+		placeholders[i] = "?"
+		args[i] = email
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, "SELECT email FROM users WHERE email IN ("+strings.Join(placeholders, ", ")+") AND deleted_at IS NULL", args...)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var registered []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		registered = append(registered, email)
+	}
+	return registered, apperror.HandleDatabaseError(rows.Err())
 }
 
-func (r *userRepo) FindByEmail(email string) (user.User, error) {
+func (r *userRepo) FindByEmail(ctx context.Context, email string) (user.User, error) {
 	var u user.User
-	err := r.db.QueryRow("SELECT id, COALESCE(keycloak_id, ''), name, email, password FROM users WHERE email = ?", email).Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.Password)
+	err := r.conn(ctx).QueryRowContext(ctx, "SELECT id, COALESCE(keycloak_id, ''), name, email, password, is_active, version FROM users WHERE email = ? AND deleted_at IS NULL", email).Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.Password, &u.IsActive, &u.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return u, user.ErrUserNotFound
@@ -131,7 +392,7 @@ func (r *userRepo) FindByEmail(email string) (user.User, error) {
 		return u, apperror.HandleDatabaseError(err)
 	}
 
-	rows, err := r.db.Query(`
+	rows, err := r.conn(ctx).QueryContext(ctx, `
 		SELECT r.name
 		FROM roles r
 		JOIN user_roles ur ON ur.role_id = r.id
@@ -160,8 +421,8 @@ func (r *userRepo) FindByEmail(email string) (user.User, error) {
 	return u, nil
 }
 
-func (r *userRepo) ChangePassword(id string, newPassword string) error {
-	_, err := r.db.Exec("UPDATE users SET password = ? WHERE id = ?", newPassword, id)
+func (r *userRepo) ChangePassword(ctx context.Context, id string, newPassword string, actor string) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, password = ?, updated_at = ?, updated_by = ? WHERE id = ?", newPassword, time.Now(), nullableActor(actor), id)
 	if err != nil {
 		return apperror.HandleDatabaseError(err)
 	}
@@ -169,9 +430,9 @@ func (r *userRepo) ChangePassword(id string, newPassword string) error {
 	return nil
 }
 
-func (r *userRepo) FindByKeycloakID(keycloakID string) (user.User, error) {
+func (r *userRepo) FindByKeycloakID(ctx context.Context, keycloakID string) (user.User, error) {
 	var u user.User
-	err := r.db.QueryRow("SELECT id, COALESCE(keycloak_id, ''), name, email, password FROM users WHERE keycloak_id = ?", keycloakID).Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.Password)
+	err := r.conn(ctx).QueryRowContext(ctx, "SELECT id, COALESCE(keycloak_id, ''), name, email, password, is_active, version FROM users WHERE keycloak_id = ? AND deleted_at IS NULL", keycloakID).Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.Password, &u.IsActive, &u.Version)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return u, user.ErrUserNotFound
@@ -180,7 +441,7 @@ func (r *userRepo) FindByKeycloakID(keycloakID string) (user.User, error) {
 	}
 
 	// fetch roles
-	roleRows, err := r.db.Query(`
+	roleRows, err := r.conn(ctx).QueryContext(ctx, `
 		SELECT r.name
 		FROM roles r
 		JOIN user_roles ur ON ur.role_id = r.id
@@ -204,7 +465,133 @@ func (r *userRepo) FindByKeycloakID(keycloakID string) (user.User, error) {
 	return u, nil
 }
 
-func (r *userRepo) UpdateKeycloakID(id string, keycloakID string) error {
-	_, err := r.db.Exec("UPDATE users SET keycloak_id = ? WHERE id = ?", keycloakID, id)
+func (r *userRepo) UpdateKeycloakID(ctx context.Context, id string, keycloakID string) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, keycloak_id = ? WHERE id = ?", nullString(keycloakID), id)
+	return apperror.HandleDatabaseError(err)
+}
+
+// UpdateActive enables or disables a user and cancels any scheduled deactivation.
+func (r *userRepo) UpdateActive(ctx context.Context, id string, active bool, actor string) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, is_active = ?, deactivate_at = NULL, updated_at = ?, updated_by = ? WHERE id = ?", active, time.Now(), nullableActor(actor), id)
 	return apperror.HandleDatabaseError(err)
 }
+
+// ScheduleDeactivation sets or, with a nil at, clears the scheduled deactivation of a user.
+func (r *userRepo) ScheduleDeactivation(ctx context.Context, id string, at *time.Time, actor string) error {
+	res, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, deactivate_at = ?, updated_at = ?, updated_by = ? WHERE id = ? AND deleted_at IS NULL", at, time.Now(), nullableActor(actor), id)
+	return affectedOrNotFound(res, err)
+}
+
+// FindDueDeactivations returns up to limit active users whose scheduled
+// deactivation is due at now, oldest schedule first.
+func (r *userRepo) FindDueDeactivations(ctx context.Context, now time.Time, limit int) ([]user.User, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT id, COALESCE(keycloak_id, ''), name, email, is_active, deactivate_at
+		FROM users
+		WHERE is_active = TRUE AND deactivate_at IS NOT NULL AND deactivate_at <= ? AND deleted_at IS NULL
+		ORDER BY deactivate_at
+		LIMIT ?
+	`, now, limit)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var users []user.User
+	for rows.Next() {
+		var u user.User
+		var deactivateAt time.Time
+		if err := rows.Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.IsActive, &deactivateAt); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		u.DeactivateAt = &deactivateAt
+		users = append(users, u)
+	}
+	return users, apperror.HandleDatabaseError(rows.Err())
+}
+
+func (r *userRepo) FindAllRoles(ctx context.Context) ([]string, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, "SELECT name FROM roles ORDER BY name")
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	return roles, nil
+}
+
+// FindWithoutKeycloakID returns up to limit users not yet linked to Keycloak,
+// ordered by id and starting after afterID so callers can page through them.
+func (r *userRepo) FindWithoutKeycloakID(ctx context.Context, afterID string, limit int) ([]user.User, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT id, name, email, password, is_active
+		FROM users
+		WHERE (keycloak_id IS NULL OR keycloak_id = '') AND id > ? AND deleted_at IS NULL
+		ORDER BY id
+		LIMIT ?
+	`, afterID, limit)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var users []user.User
+	for rows.Next() {
+		var u user.User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.IsActive); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+
+	for i := range users {
+		roles, err := r.findRoles(ctx, users[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		users[i].Roles = roles
+	}
+	return users, nil
+}
+
+func (r *userRepo) findRoles(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT r.name
+		FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = ?
+	`, userID)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	return roles, nil
+}
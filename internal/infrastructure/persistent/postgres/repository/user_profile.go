@@ -0,0 +1,40 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// profileColumns selects a user.Profile, scanned into profileDest.
+const profileColumns = "COALESCE(phone, ''), COALESCE(department, ''), COALESCE(job_title, ''), COALESCE(avatar_url, ''), COALESCE(locale, ''), COALESCE(timezone, ''), custom_attributes"
+
+// profileDest returns the scan destinations of profileColumns. The custom
+// attributes are scanned as JSON text, see decodeAttributes.
+func profileDest(p *user.Profile, attributes *sql.NullString) []interface{} {
+	return []interface{}{&p.Phone, &p.Department, &p.JobTitle, &p.AvatarURL, &p.Locale, &p.Timezone, attributes}
+}
+
+func decodeAttributes(attributes sql.NullString) (map[string]interface{}, error) {
+	if !attributes.Valid || attributes.String == "" {
+		return nil, nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(attributes.String), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// encodeAttributes stores an empty attribute map as NULL.
+func encodeAttributes(m map[string]interface{}) (sql.NullString, error) {
+	if len(m) == 0 {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(b), Valid: true}, nil
+}
@@ -0,0 +1,68 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	repository "github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/postgres/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// usersTable is a driver enforcing the UNIQUE constraint of users.keycloak_id,
+// NULL values not conflicting.
+type usersTable struct {
+	mu          sync.Mutex
+	keycloakIDs map[driver.Value]bool
+}
+
+func (t *usersTable) Connect(ctx context.Context) (driver.Conn, error) { return t, nil }
+func (t *usersTable) Driver() driver.Driver                            { return nil }
+func (t *usersTable) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+func (t *usersTable) Close() error              { return nil }
+func (t *usersTable) Begin() (driver.Tx, error) { return t, nil }
+func (t *usersTable) Commit() error             { return nil }
+func (t *usersTable) Rollback() error           { return nil }
+
+func (t *usersTable) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if !strings.HasPrefix(query, "INSERT INTO users") {
+		return nil, errors.New("unexpected statement: " + query)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if keycloakID := args[1].Value; keycloakID != nil {
+		if t.keycloakIDs[keycloakID] {
+			return nil, gorm.ErrDuplicatedKey
+		}
+		t.keycloakIDs[keycloakID] = true
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func TestSaveWithoutKeycloakID(t *testing.T) {
+	db := sql.OpenDB(&usersTable{keycloakIDs: map[driver.Value]bool{}})
+	t.Cleanup(func() { db.Close() })
+	repo := repository.NewUserRepo(db)
+	ctx := context.Background()
+
+	// Users provisioned from Google or GitHub are not linked to Keycloak
+	require.NoError(t, repo.Save(ctx, user.User{Name: "A", Email: "a@example.com"}))
+	require.NoError(t, repo.Save(ctx, user.User{Name: "B", Email: "b@example.com"}))
+	require.NoError(t, repo.SaveAll(ctx, []user.User{{Name: "C", Email: "c@example.com"}, {Name: "D", Email: "d@example.com"}}))
+
+	require.NoError(t, repo.Save(ctx, user.User{KeycloakID: "kc-1", Name: "E", Email: "e@example.com"}))
+	var appErr *apperror.AppError
+	require.ErrorAs(t, repo.Save(ctx, user.User{KeycloakID: "kc-1", Name: "F", Email: "f@example.com"}), &appErr)
+	assert.Equal(t, http.StatusConflict, appErr.Code)
+}
@@ -0,0 +1,79 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqltx"
+)
+
+type sessionRepo struct {
+	db *sql.DB
+}
+
+func NewSessionRepo(db *sql.DB) user.SessionRepository {
+	return &sessionRepo{db: db}
+}
+
+// conn returns the transaction of ctx, if any, or the database.
+func (r *sessionRepo) conn(ctx context.Context) sqltx.Conn {
+	return sqltx.From(ctx, r.db)
+}
+
+func (r *sessionRepo) Create(ctx context.Context, s user.Session) error {
+	_, err := r.conn(ctx).ExecContext(ctx,
+		"INSERT INTO user_sessions(id, user_id, keycloak_session_id, auth_provider, expires_at) VALUES(@p1, @p2, @p3, @p4, @p5)",
+		s.ID, s.UserID, nullString(s.KeycloakSessionID), nullString(s.AuthProvider), s.ExpiresAt,
+	)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *sessionRepo) FindByID(ctx context.Context, id string) (user.Session, error) {
+	var s user.Session
+	var revokedAt sql.NullTime
+	err := r.conn(ctx).QueryRowContext(ctx,
+		"SELECT id, user_id, COALESCE(keycloak_session_id, ''), COALESCE(auth_provider, ''), expires_at, revoked_at, created_at FROM user_sessions WHERE id = @p1",
+		id,
+	).Scan(&s.ID, &s.UserID, &s.KeycloakSessionID, &s.AuthProvider, &s.ExpiresAt, &revokedAt, &s.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return s, user.ErrSessionNotFound
+		}
+		return s, apperror.HandleDatabaseError(err)
+	}
+
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+	return s, nil
+}
+
+func (r *sessionRepo) RevokeByKeycloakSessionID(ctx context.Context, keycloakSessionID string) (int64, error) {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		"UPDATE user_sessions SET revoked_at = @p1 WHERE keycloak_session_id = @p2 AND revoked_at IS NULL",
+		time.Now(), keycloakSessionID,
+	)
+	if err != nil {
+		return 0, apperror.HandleDatabaseError(err)
+	}
+	return res.RowsAffected()
+}
+
+func (r *sessionRepo) RevokeByUserID(ctx context.Context, userID string) (int64, error) {
+	res, err := r.conn(ctx).ExecContext(ctx,
+		"UPDATE user_sessions SET revoked_at = @p1 WHERE user_id = @p2 AND revoked_at IS NULL",
+		time.Now(), userID,
+	)
+	if err != nil {
+		return 0, apperror.HandleDatabaseError(err)
+	}
+	return res.RowsAffected()
+}
+
+// nullString stores empty strings as NULL so optional unique/indexed columns stay sparse.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
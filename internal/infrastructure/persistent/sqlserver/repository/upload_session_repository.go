@@ -0,0 +1,110 @@
+package sqlserver
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+)
+
+const uploadSessionColumns = "id, owner_id, name, content_type, size, part_size, COALESCE(checksum, ''), status, storage_key, upload_id, expires_at, created_at"
+
+type uploadSessionRepo struct {
+	db *sql.DB
+}
+
+func NewUploadSessionRepo(db *sql.DB) file.UploadSessionRepository {
+	return &uploadSessionRepo{db: db}
+}
+
+func (r *uploadSessionRepo) Create(s file.UploadSession) error {
+	_, err := r.db.Exec(
+		"INSERT INTO upload_sessions(id, owner_id, name, content_type, size, part_size, checksum, status, storage_key, upload_id, expires_at) VALUES(@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9, @p10, @p11)",
+		s.ID, s.OwnerID, s.Name, s.ContentType, s.Size, s.PartSize, nullString(s.Checksum), s.Status, s.StorageKey, s.UploadID, s.ExpiresAt,
+	)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *uploadSessionRepo) FindByID(id string) (file.UploadSession, error) {
+	s, err := scanUploadSession(r.db.QueryRow("SELECT "+uploadSessionColumns+" FROM upload_sessions WHERE id = @p1", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return s, file.ErrUploadSessionNotFound
+		}
+		return s, apperror.HandleDatabaseError(err)
+	}
+
+	rows, err := r.db.Query(
+		"SELECT number, size, etag, checksum FROM upload_session_parts WHERE session_id = @p1 ORDER BY number",
+		id,
+	)
+	if err != nil {
+		return s, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	s.Parts = []file.UploadedPart{}
+	for rows.Next() {
+		var p file.UploadedPart
+		if err := rows.Scan(&p.Number, &p.Size, &p.ETag, &p.Checksum); err != nil {
+			return s, apperror.HandleDatabaseError(err)
+		}
+		s.Parts = append(s.Parts, p)
+	}
+	return s, apperror.HandleDatabaseError(rows.Err())
+}
+
+func (r *uploadSessionRepo) SavePart(sessionID string, p file.UploadedPart) error {
+	_, err := r.db.Exec(
+		`MERGE upload_session_parts WITH (HOLDLOCK) AS t
+		USING (SELECT @p1 AS session_id, @p2 AS number, @p3 AS size, @p4 AS etag, @p5 AS checksum) AS s
+		ON t.session_id = s.session_id AND t.number = s.number
+		WHEN MATCHED THEN UPDATE SET size = s.size, etag = s.etag, checksum = s.checksum
+		WHEN NOT MATCHED THEN INSERT(session_id, number, size, etag, checksum) VALUES(s.session_id, s.number, s.size, s.etag, s.checksum);`,
+		sessionID, p.Number, p.Size, p.ETag, p.Checksum,
+	)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *uploadSessionRepo) UpdateStatus(id, status string) error {
+	res, err := r.db.Exec("UPDATE upload_sessions SET status = @p1 WHERE id = @p2", status, id)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return file.ErrUploadSessionNotFound
+	}
+	return nil
+}
+
+func (r *uploadSessionRepo) FindExpired(now time.Time, limit int) ([]file.UploadSession, error) {
+	rows, err := r.db.Query(
+		"SELECT TOP (@p3) "+uploadSessionColumns+" FROM upload_sessions WHERE status = @p1 AND expires_at < @p2 ORDER BY expires_at",
+		file.UploadPending, now, limit,
+	)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	sessions := []file.UploadSession{}
+	for rows.Next() {
+		s, err := scanUploadSession(rows)
+		if err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, apperror.HandleDatabaseError(rows.Err())
+}
+
+func scanUploadSession(row interface{ Scan(...any) error }) (file.UploadSession, error) {
+	var s file.UploadSession
+	err := row.Scan(&s.ID, &s.OwnerID, &s.Name, &s.ContentType, &s.Size, &s.PartSize, &s.Checksum,
+		&s.Status, &s.StorageKey, &s.UploadID, &s.ExpiresAt, &s.CreatedAt)
+	if err == nil {
+		s.TotalParts = file.PartCount(s.Size, s.PartSize)
+	}
+	return s, err
+}
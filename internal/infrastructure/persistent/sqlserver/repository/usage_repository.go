@@ -0,0 +1,147 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqltx"
+	"github.com/google/uuid"
+)
+
+type usageRepo struct {
+	db *sql.DB
+}
+
+func NewUsageRepo(db *sql.DB) file.UsageRepository {
+	return &usageRepo{db: db}
+}
+
+func (r *usageRepo) Usage(ownerID string, now time.Time) (file.Usage, error) {
+	return ownerUsage(context.Background(), r.db, ownerID, now)
+}
+
+// ownerUsage reads the usage of owner through conn, see Usage.
+func ownerUsage(ctx context.Context, conn sqltx.Conn, ownerID string, now time.Time) (file.Usage, error) {
+	u := file.Usage{OwnerID: ownerID}
+	var quota sql.NullInt64
+	err := conn.QueryRowContext(ctx,
+		`SELECT
+			(SELECT COUNT(*) FROM files WHERE owner_id = @p1),
+			(SELECT COALESCE(SUM(size), 0) FROM files WHERE owner_id = @p1),
+			(SELECT COALESCE(SUM(size), 0) FROM upload_sessions WHERE owner_id = @p1 AND status = @p2 AND expires_at > @p3)
+				+ (SELECT COALESCE(SUM(size), 0) FROM storage_reservations WHERE owner_id = @p1 AND expires_at > @p3),
+			(SELECT quota_bytes FROM storage_quotas WHERE user_id = @p1)`,
+		ownerID, file.UploadPending, now,
+	).Scan(&u.Files, &u.UsedBytes, &u.ReservedBytes, &quota)
+	if err != nil {
+		return u, apperror.HandleDatabaseError(err)
+	}
+	u.QuotaBytes, u.CustomQuota = quota.Int64, quota.Valid
+	return u, nil
+}
+
+func (r *usageRepo) Reserve(ownerID string, size int64, now, expiresAt time.Time, allow func(file.Usage) bool) (string, error) {
+	id := uuid.New().String()
+	err := sqltx.WithinTx(context.Background(), r.db, func(ctx context.Context) error {
+		conn := sqltx.From(ctx, r.db)
+
+		// Locking the owner makes concurrent reservations wait for this one
+		var locked string
+		err := conn.QueryRowContext(ctx, "SELECT id FROM users WITH (UPDLOCK, HOLDLOCK) WHERE id = @p1", ownerID).Scan(&locked)
+		if errors.Is(err, sql.ErrNoRows) {
+			return file.ErrOwnerNotFound
+		}
+		if err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+		if _, err := conn.ExecContext(ctx, "DELETE FROM storage_reservations WHERE owner_id = @p1 AND expires_at <= @p2", ownerID, now); err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+
+		usage, err := ownerUsage(ctx, conn, ownerID, now)
+		if err != nil {
+			return err
+		}
+		if !allow(usage) {
+			return file.ErrQuotaExceeded
+		}
+
+		_, err = conn.ExecContext(ctx,
+			"INSERT INTO storage_reservations(id, owner_id, size, expires_at) VALUES(@p1, @p2, @p3, @p4)",
+			id, ownerID, size, expiresAt,
+		)
+		return apperror.HandleDatabaseError(err)
+	})
+	if err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+func (r *usageRepo) Release(id string) error {
+	_, err := r.db.Exec("DELETE FROM storage_reservations WHERE id = @p1", id)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *usageRepo) ListUsage(limit, offset int) ([]file.Usage, int64, error) {
+	var total int64
+	if err := r.db.QueryRow("SELECT COUNT(DISTINCT owner_id) FROM files").Scan(&total); err != nil {
+		return nil, 0, apperror.HandleDatabaseError(err)
+	}
+
+	rows, err := r.db.Query(
+		`SELECT f.owner_id, COALESCE(u.email, ''), COUNT(*), SUM(f.size), q.quota_bytes
+		FROM files f
+		LEFT JOIN users u ON u.id = f.owner_id
+		LEFT JOIN storage_quotas q ON q.user_id = f.owner_id
+		GROUP BY f.owner_id, u.email, q.quota_bytes
+		ORDER BY SUM(f.size) DESC, f.owner_id
+		OFFSET @p1 ROWS FETCH NEXT @p2 ROWS ONLY`,
+		offset, limit,
+	)
+	if err != nil {
+		return nil, 0, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	usages := []file.Usage{}
+	for rows.Next() {
+		var u file.Usage
+		var quota sql.NullInt64
+		if err := rows.Scan(&u.OwnerID, &u.Email, &u.Files, &u.UsedBytes, &quota); err != nil {
+			return nil, 0, apperror.HandleDatabaseError(err)
+		}
+		u.QuotaBytes, u.CustomQuota = quota.Int64, quota.Valid
+		usages = append(usages, u)
+	}
+	return usages, total, apperror.HandleDatabaseError(rows.Err())
+}
+
+func (r *usageRepo) SetQuota(ownerID string, quotaBytes int64) error {
+	var exists bool
+	err := r.db.QueryRow("SELECT CASE WHEN EXISTS(SELECT 1 FROM users WHERE id = @p1 AND deleted_at IS NULL) THEN 1 ELSE 0 END", ownerID).Scan(&exists)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	if !exists {
+		return file.ErrOwnerNotFound
+	}
+
+	_, err = r.db.Exec(
+		`MERGE storage_quotas WITH (HOLDLOCK) AS t
+		USING (SELECT @p1 AS user_id, @p2 AS quota_bytes) AS s ON t.user_id = s.user_id
+		WHEN MATCHED THEN UPDATE SET quota_bytes = s.quota_bytes, updated_at = CURRENT_TIMESTAMP
+		WHEN NOT MATCHED THEN INSERT(user_id, quota_bytes) VALUES(s.user_id, s.quota_bytes);`,
+		ownerID, quotaBytes,
+	)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *usageRepo) DeleteQuota(ownerID string) error {
+	_, err := r.db.Exec("DELETE FROM storage_quotas WHERE user_id = @p1", ownerID)
+	return apperror.HandleDatabaseError(err)
+}
@@ -0,0 +1,140 @@
+package sqlserver
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// userSortColumns whitelists the ORDER BY columns of user list queries.
+var userSortColumns = map[string]string{
+	user.SortByName:      "name",
+	user.SortByEmail:     "email",
+	user.SortByCreatedAt: "created_at",
+	user.SortByIsActive:  "is_active",
+}
+
+func userSortColumn(field string) string {
+	if column, ok := userSortColumns[field]; ok {
+		return column
+	}
+	return "created_at"
+}
+
+func sortDirection(dir string) string {
+	if dir == user.SortDesc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// userFilter builds the WHERE clause of user list queries and its arguments.
+// Placeholders are numbered from @p1.
+func userFilter(q user.ListQuery) (string, []interface{}) {
+	var (
+		conds []string
+		args  []interface{}
+	)
+
+	if q.Trashed {
+		conds = append(conds, "deleted_at IS NOT NULL")
+	} else {
+		conds = append(conds, "deleted_at IS NULL")
+	}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("@p%d", len(args))
+	}
+
+	if q.Search != "" {
+		pattern := arg("%" + escapeLike(strings.ToLower(q.Search)) + "%")
+		conds = append(conds, fmt.Sprintf(`(LOWER(name) LIKE %s ESCAPE '\' OR LOWER(email) LIKE %s ESCAPE '\')`, pattern, pattern))
+	}
+	if q.Email != "" {
+		conds = append(conds, "LOWER(email) = "+arg(strings.ToLower(q.Email)))
+	}
+	if q.Role != "" {
+		conds = append(conds, "EXISTS (SELECT 1 FROM user_roles ur JOIN roles r ON r.id = ur.role_id WHERE ur.user_id = users.id AND r.name = "+arg(q.Role)+")")
+	}
+	if q.IsActive != nil {
+		conds = append(conds, "is_active = "+arg(*q.IsActive))
+	}
+	if !q.CreatedFrom.IsZero() {
+		conds = append(conds, "created_at >= "+arg(q.CreatedFrom))
+	}
+	if !q.CreatedTo.IsZero() {
+		conds = append(conds, "created_at < "+arg(q.CreatedTo))
+	}
+	if q.Department != "" {
+		conds = append(conds, "LOWER(department) = "+arg(strings.ToLower(q.Department)))
+	}
+	if q.Locale != "" {
+		conds = append(conds, "locale = "+arg(q.Locale))
+	}
+	if q.Timezone != "" {
+		conds = append(conds, "timezone = "+arg(q.Timezone))
+	}
+	// Sorted so the same filters always produce the same statement
+	for _, name := range slices.Sorted(maps.Keys(q.Attributes)) {
+		conds = append(conds, fmt.Sprintf("JSON_VALUE(custom_attributes, %s) = %s", arg(`$."`+name+`"`), arg(q.Attributes[name])))
+	}
+
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// userPage builds the WHERE, ORDER BY and OFFSET FETCH clauses of a page of users.
+// With q.After set the page starts after the cursor (keyset) instead of at
+// q.Offset; id breaks ties so the order is total.
+func userPage(q user.ListQuery) (string, []interface{}, error) {
+	where, args := userFilter(q)
+	column, dir := userSortColumn(q.SortBy), sortDirection(q.SortDir)
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("@p%d", len(args))
+	}
+
+	if q.After != nil {
+		value, err := q.After.SortValue()
+		if err != nil {
+			return "", nil, err
+		}
+
+		op := ">"
+		if dir == "DESC" {
+			op = "<"
+		}
+		v := arg(value)
+		keyset := fmt.Sprintf("(%s %s %s OR (%s = %s AND id %s %s))", column, op, v, column, v, op, arg(q.After.ID))
+		if where == "" {
+			where = " WHERE " + keyset
+		} else {
+			where += " AND " + keyset
+		}
+	}
+
+	// OFFSET is required by FETCH, a keyset page always starts at 0
+	offset := 0
+	if q.After == nil {
+		offset = q.Offset
+	}
+	clause := where + userOrder(q) + " OFFSET " + arg(offset) + " ROWS FETCH NEXT " + arg(q.Limit) + " ROWS ONLY"
+	return clause, args, nil
+}
+
+// userOrder returns the ORDER BY clause of q, id breaks ties so the order is total.
+func userOrder(q user.ListQuery) string {
+	column, dir := userSortColumn(q.SortBy), sortDirection(q.SortDir)
+	return " ORDER BY " + column + " " + dir + ", id " + dir
+}
+
+// escapeLike escapes the LIKE wildcards so the search term is matched literally.
+// Unlike MySQL and PostgreSQL, SQL Server also treats [ as a wildcard.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `[`, `\[`).Replace(s)
+}
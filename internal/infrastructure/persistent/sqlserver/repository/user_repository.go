@@ -0,0 +1,572 @@
+package sqlserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqltx"
+	"github.com/google/uuid"
+)
+
+type userRepo struct {
+	db *sql.DB
+}
+
+func NewUserRepo(db *sql.DB) user.UserRepository {
+	return &userRepo{db: db}
+}
+
+// conn returns the transaction of ctx, if any, or the database.
+func (r *userRepo) conn(ctx context.Context) sqltx.Conn {
+	return sqltx.From(ctx, r.db)
+}
+
+func (r *userRepo) FindAll(ctx context.Context, q user.ListQuery) ([]user.User, error) {
+	page, args, err := userPage(q)
+	if err != nil {
+		return nil, err
+	}
+	query := "SELECT id, COALESCE(keycloak_id, ''), name, email, is_active, deactivate_at, created_at, updated_at, COALESCE(created_by, ''), COALESCE(updated_by, ''), deleted_at, version, " + profileColumns + " FROM users" + page
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	users := []user.User{}
+	for rows.Next() {
+		var u user.User
+		var deactivateAt, deletedAt sql.NullTime
+		var attributes sql.NullString
+		dest := append([]interface{}{&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.IsActive, &deactivateAt, &u.CreatedAt, &u.UpdatedAt, &u.CreatedBy, &u.UpdatedBy, &deletedAt, &u.Version}, profileDest(&u.Profile, &attributes)...)
+		if err := rows.Scan(dest...); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		if u.Profile.Attributes, err = decodeAttributes(attributes); err != nil {
+			return nil, err
+		}
+		if deactivateAt.Valid {
+			u.DeactivateAt = &deactivateAt.Time
+		}
+		if deletedAt.Valid {
+			u.DeletedAt = &deletedAt.Time
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+
+	for i := range users {
+		roles, err := r.findRoles(ctx, users[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		users[i].Roles = roles
+	}
+	return users, nil
+}
+
+// Stream calls fn for every user matching the filters of q, in the order of q,
+// reading one row at a time (Limit, Offset and After are ignored). Roles are
+// aggregated in the query so no other query runs while the rows are open.
+// Stream stops at the first error returned by fn.
+func (r *userRepo) Stream(ctx context.Context, q user.ListQuery, fn func(user.User) error) error {
+	where, args := userFilter(q)
+	query := `
+		SELECT id, COALESCE(keycloak_id, ''), name, email, is_active, created_at, updated_at,
+			COALESCE(created_by, ''), COALESCE(updated_by, ''), deleted_at, version, ` + profileColumns + `,
+			COALESCE((
+				SELECT STRING_AGG(r.name, ',') WITHIN GROUP (ORDER BY r.name)
+				FROM user_roles ur
+				JOIN roles r ON r.id = ur.role_id
+				WHERE ur.user_id = users.id
+			), '')
+		FROM users` + where + userOrder(q)
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u user.User
+		var deletedAt sql.NullTime
+		var attributes sql.NullString
+		var roles string
+		dest := append([]interface{}{&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.IsActive, &u.CreatedAt, &u.UpdatedAt,
+			&u.CreatedBy, &u.UpdatedBy, &deletedAt, &u.Version}, profileDest(&u.Profile, &attributes)...)
+		if err := rows.Scan(append(dest, &roles)...); err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+		var err error
+		if u.Profile.Attributes, err = decodeAttributes(attributes); err != nil {
+			return err
+		}
+		if deletedAt.Valid {
+			u.DeletedAt = &deletedAt.Time
+		}
+		u.Roles = []string{}
+		if roles != "" {
+			u.Roles = strings.Split(roles, ",")
+		}
+
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return apperror.HandleDatabaseError(rows.Err())
+}
+
+// Count returns the number of users matching the filters of q (sort and paging are ignored).
+func (r *userRepo) Count(ctx context.Context, q user.ListQuery) (int64, error) {
+	where, args := userFilter(q)
+
+	var total int64
+	if err := r.conn(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total); err != nil {
+		return 0, apperror.HandleDatabaseError(err)
+	}
+	return total, nil
+}
+
+func (r *userRepo) FindByID(ctx context.Context, id string) (user.User, error) {
+	var u user.User
+	var deactivateAt sql.NullTime
+	var attributes sql.NullString
+	dest := append([]interface{}{&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.Password, &u.IsActive, &deactivateAt, &u.CreatedAt, &u.UpdatedAt, &u.CreatedBy, &u.UpdatedBy, &u.Version}, profileDest(&u.Profile, &attributes)...)
+	err := r.conn(ctx).QueryRowContext(ctx, "SELECT id, COALESCE(keycloak_id, ''), name, email, password, is_active, deactivate_at, created_at, updated_at, COALESCE(created_by, ''), COALESCE(updated_by, ''), version, "+profileColumns+" FROM users WHERE id = @p1 AND deleted_at IS NULL", id).
+		Scan(dest...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return u, user.ErrUserNotFound
+		}
+		return u, apperror.HandleDatabaseError(err)
+	}
+	if deactivateAt.Valid {
+		u.DeactivateAt = &deactivateAt.Time
+	}
+	if u.Profile.Attributes, err = decodeAttributes(attributes); err != nil {
+		return u, err
+	}
+
+	u.Roles, err = r.findRoles(ctx, u.ID)
+	return u, err
+}
+
+// Save inserts the user and its roles in a transaction.
+func (r *userRepo) Save(ctx context.Context, u user.User) error {
+	return sqltx.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		return r.insert(ctx, u, map[string]string{})
+	})
+}
+
+// SaveAll inserts users and their roles in a single transaction, either all of
+// them are created or none.
+func (r *userRepo) SaveAll(ctx context.Context, users []user.User) error {
+	return sqltx.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		roleIDs := map[string]string{}
+		for _, u := range users {
+			if err := r.insert(ctx, u, roleIDs); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// insert inserts u with a new id and its roles, caching role ids in roleIDs.
+func (r *userRepo) insert(ctx context.Context, u user.User, roleIDs map[string]string) error {
+	id := uuid.New().String()
+	_, err := r.conn(ctx).ExecContext(ctx,
+		"INSERT INTO users(id, keycloak_id, name, email, password, created_by, updated_by) VALUES(@p1, @p2, @p3, @p4, @p5, @p6, @p6)",
+		id, nullString(u.KeycloakID), u.Name, u.Email, u.Password, nullableActor(u.CreatedBy),
+	)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	return r.insertRoles(ctx, id, u.Roles, roleIDs)
+}
+
+// Update saves u and replaces its roles in a transaction.
+func (r *userRepo) Update(ctx context.Context, u user.User) error {
+	return sqltx.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		// Optimistic lock: the row only matches while it still has the version that was read
+		res, err := r.conn(ctx).ExecContext(ctx,
+			"UPDATE users SET version = version + 1, keycloak_id = @p1, name = @p2, email = @p3, password = @p4, updated_at = @p5, updated_by = @p6 WHERE id = @p7 AND version = @p8 AND deleted_at IS NULL",
+			nullString(u.KeycloakID), u.Name, u.Email, u.Password, time.Now(), nullableActor(u.UpdatedBy), u.ID, u.Version,
+		)
+		if err := affectedOrConflict(res, err); err != nil {
+			return err
+		}
+
+		if _, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM user_roles WHERE user_id = @p1", u.ID); err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+		return r.insertRoles(ctx, u.ID, u.Roles, map[string]string{})
+	})
+}
+
+// insertRoles grants roles to a user, caching role ids in roleIDs.
+func (r *userRepo) insertRoles(ctx context.Context, userID string, roles []string, roleIDs map[string]string) error {
+	for _, role := range roles {
+		roleID, ok := roleIDs[role]
+		if !ok {
+			if err := r.conn(ctx).QueryRowContext(ctx, "SELECT id FROM roles WHERE name = @p1", role).Scan(&roleID); err != nil {
+				return apperror.HandleDatabaseError(err)
+			}
+			roleIDs[role] = roleID
+		}
+		if _, err := r.conn(ctx).ExecContext(ctx, "INSERT INTO user_roles(user_id, role_id) VALUES(@p1, @p2)", userID, roleID); err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+	}
+	return nil
+}
+
+// UpdateProfile replaces the profile columns, with the same optimistic lock as Update.
+func (r *userRepo) UpdateProfile(ctx context.Context, u user.User) error {
+	attributes, err := encodeAttributes(u.Profile.Attributes)
+	if err != nil {
+		return err
+	}
+
+	p := u.Profile
+	res, err := r.conn(ctx).ExecContext(ctx,
+		"UPDATE users SET version = version + 1, phone = @p1, department = @p2, job_title = @p3, avatar_url = @p4, locale = @p5, timezone = @p6, custom_attributes = @p7, updated_at = @p8, updated_by = @p9 WHERE id = @p10 AND version = @p11 AND deleted_at IS NULL",
+		nullString(p.Phone), nullString(p.Department), nullString(p.JobTitle), nullString(p.AvatarURL), nullString(p.Locale), nullString(p.Timezone),
+		attributes, time.Now(), nullableActor(u.UpdatedBy), u.ID, u.Version,
+	)
+	return affectedOrConflict(res, err)
+}
+
+func (r *userRepo) Delete(ctx context.Context, id string, actor string) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, deleted_at = @p1, updated_at = @p1, updated_by = @p2 WHERE id = @p3 AND deleted_at IS NULL", time.Now(), nullableActor(actor), id)
+	return apperror.HandleDatabaseError(err)
+}
+
+// Restore clears deleted_at of a soft-deleted user, unless another user
+// registered its email in the meantime.
+func (r *userRepo) Restore(ctx context.Context, id string, actor string) error {
+	return sqltx.WithinTx(ctx, r.db, func(ctx context.Context) error {
+		var email string
+		err := r.conn(ctx).QueryRowContext(ctx, "SELECT email FROM users WHERE id = @p1 AND deleted_at IS NOT NULL", id).Scan(&email)
+		if errors.Is(err, sql.ErrNoRows) {
+			return user.ErrUserNotFound
+		}
+		if err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+
+		var taken int
+		if err := r.conn(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE email = @p1 AND deleted_at IS NULL", email).Scan(&taken); err != nil {
+			return apperror.HandleDatabaseError(err)
+		}
+		if taken > 0 {
+			return user.ErrEmailTaken
+		}
+
+		res, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, deleted_at = NULL, updated_at = @p1, updated_by = @p2 WHERE id = @p3 AND deleted_at IS NOT NULL", time.Now(), nullableActor(actor), id)
+		return affectedOrNotFound(res, err)
+	})
+}
+
+// Purge permanently deletes a soft-deleted user. Roles, sessions and identities
+// are removed by their ON DELETE CASCADE foreign keys.
+func (r *userRepo) Purge(ctx context.Context, id string) error {
+	res, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM users WHERE id = @p1 AND deleted_at IS NOT NULL", id)
+	return affectedOrNotFound(res, err)
+}
+
+// PurgeDeletedBefore permanently deletes the users soft-deleted before cutoff.
+func (r *userRepo) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < @p1", cutoff)
+	if err != nil {
+		return 0, apperror.HandleDatabaseError(err)
+	}
+	return res.RowsAffected()
+}
+
+// nullableActor stores system actions (no authenticated user) as NULL.
+func nullableActor(actor string) sql.NullString {
+	return sql.NullString{String: actor, Valid: actor != ""}
+}
+
+// affectedOrConflict reports ErrVersionConflict when no row had the expected version.
+func affectedOrConflict(res sql.Result, err error) error {
+	if err := affectedOrNotFound(res, err); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return user.ErrVersionConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func affectedOrNotFound(res sql.Result, err error) error {
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	if n == 0 {
+		return user.ErrUserNotFound
+	}
+	return nil
+}
+
+// FindRegisteredEmails returns those of emails used by a user that is not
+// deleted, in a single query.
+func (r *userRepo) FindRegisteredEmails(ctx context.Context, emails []string) ([]string, error) {
+	if len(emails) == 0 {
+		return nil, nil
+	}
+	placeholders := make([]string, len(emails))
+	args := make([]interface{}, len(emails))
+	for i, email := range emails {
+		placeholders[i] = fmt.Sprintf("@p%d", i+1)
+		args[i] = email
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, "SELECT email FROM users WHERE email IN ("+strings.Join(placeholders, ", ")+") AND deleted_at IS NULL", args...)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var registered []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		registered = append(registered, email)
+	}
+	return registered, apperror.HandleDatabaseError(rows.Err())
+}
+
+func (r *userRepo) FindByEmail(ctx context.Context, email string) (user.User, error) {
+	var u user.User
+
+	query := `
+		SELECT id, COALESCE(keycloak_id, ''), name, email, password, is_active, version
+		FROM users
+		WHERE email = @p1 AND deleted_at IS NULL
+	`
+
+	err := r.conn(ctx).QueryRowContext(ctx, query, email).
+		Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.Password, &u.IsActive, &u.Version)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return u, user.ErrUserNotFound
+		}
+		return u, apperror.HandleDatabaseError(err)
+	}
+
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT r.name
+		FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = @p1
+	`, u.ID)
+
+	if err != nil {
+		return u, err
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return u, apperror.HandleDatabaseError(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return u, apperror.HandleDatabaseError(err)
+	}
+
+	u.Roles = roles
+	return u, nil
+}
+
+func (r *userRepo) ChangePassword(ctx context.Context, id string, newPassword string, actor string) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, password = @p1, updated_at = @p2, updated_by = @p3 WHERE id = @p4", newPassword, time.Now(), nullableActor(actor), id)
+	return apperror.HandleDatabaseError(err)
+}
+
+func (r *userRepo) FindByKeycloakID(ctx context.Context, keycloakID string) (user.User, error) {
+	var u user.User
+	err := r.conn(ctx).QueryRowContext(ctx, "SELECT id, COALESCE(keycloak_id, ''), name, email, password, is_active, version FROM users WHERE keycloak_id = @p1 AND deleted_at IS NULL", keycloakID).Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.Password, &u.IsActive, &u.Version)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return u, user.ErrUserNotFound
+		}
+		return u, apperror.HandleDatabaseError(err)
+	}
+
+	// fetch roles
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT r.name
+		FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = @p1
+	`, u.ID)
+	if err != nil {
+		return u, err
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return u, apperror.HandleDatabaseError(err)
+		}
+		roles = append(roles, role)
+	}
+	u.Roles = roles
+
+	return u, nil
+}
+
+func (r *userRepo) UpdateKeycloakID(ctx context.Context, id string, keycloakID string) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, keycloak_id = @p1 WHERE id = @p2", nullString(keycloakID), id)
+	return apperror.HandleDatabaseError(err)
+}
+
+// UpdateActive enables or disables a user and cancels any scheduled deactivation.
+func (r *userRepo) UpdateActive(ctx context.Context, id string, active bool, actor string) error {
+	_, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, is_active = @p1, deactivate_at = NULL, updated_at = @p2, updated_by = @p3 WHERE id = @p4", active, time.Now(), nullableActor(actor), id)
+	return apperror.HandleDatabaseError(err)
+}
+
+// ScheduleDeactivation sets or, with a nil at, clears the scheduled deactivation of a user.
+func (r *userRepo) ScheduleDeactivation(ctx context.Context, id string, at *time.Time, actor string) error {
+	res, err := r.conn(ctx).ExecContext(ctx, "UPDATE users SET version = version + 1, deactivate_at = @p1, updated_at = @p2, updated_by = @p3 WHERE id = @p4 AND deleted_at IS NULL", at, time.Now(), nullableActor(actor), id)
+	return affectedOrNotFound(res, err)
+}
+
+// FindDueDeactivations returns up to limit active users whose scheduled
+// deactivation is due at now, oldest schedule first.
+func (r *userRepo) FindDueDeactivations(ctx context.Context, now time.Time, limit int) ([]user.User, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT TOP (@p2) id, COALESCE(keycloak_id, ''), name, email, is_active, deactivate_at
+		FROM users
+		WHERE is_active = 1 AND deactivate_at IS NOT NULL AND deactivate_at <= @p1 AND deleted_at IS NULL
+		ORDER BY deactivate_at
+	`, now, limit)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var users []user.User
+	for rows.Next() {
+		var u user.User
+		var deactivateAt time.Time
+		if err := rows.Scan(&u.ID, &u.KeycloakID, &u.Name, &u.Email, &u.IsActive, &deactivateAt); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		u.DeactivateAt = &deactivateAt
+		users = append(users, u)
+	}
+	return users, apperror.HandleDatabaseError(rows.Err())
+}
+
+func (r *userRepo) FindAllRoles(ctx context.Context) ([]string, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, "SELECT name FROM roles ORDER BY name")
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	return roles, nil
+}
+
+// FindWithoutKeycloakID returns up to limit users not yet linked to Keycloak,
+// ordered by id and starting after afterID so callers can page through them.
+func (r *userRepo) FindWithoutKeycloakID(ctx context.Context, afterID string, limit int) ([]user.User, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT TOP (@p2) id, name, email, password, is_active
+		FROM users
+		WHERE (keycloak_id IS NULL OR keycloak_id = '') AND id > @p1 AND deleted_at IS NULL
+		ORDER BY id
+	`, afterID, limit)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var users []user.User
+	for rows.Next() {
+		var u user.User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Password, &u.IsActive); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+
+	for i := range users {
+		roles, err := r.findRoles(ctx, users[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		users[i].Roles = roles
+	}
+	return users, nil
+}
+
+func (r *userRepo) findRoles(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, `
+		SELECT r.name
+		FROM roles r
+		JOIN user_roles ur ON ur.role_id = r.id
+		WHERE ur.user_id = @p1
+	`, userID)
+	if err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, apperror.HandleDatabaseError(err)
+		}
+		roles = append(roles, role)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, apperror.HandleDatabaseError(err)
+	}
+	return roles, nil
+}
@@ -0,0 +1,77 @@
+// Package sqltx carries database/sql transactions in contexts, so that the
+// repositories of every driver take part in the transaction of a unit of work.
+package sqltx
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/transaction"
+)
+
+// Conn runs statements, either directly on the database or in a transaction.
+type Conn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+// boundTx is a transaction with the database it was started on, so that a
+// context never lends a transaction to the repositories of another database.
+type boundTx struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// Manager starts the transactions of units of work on a database.
+type Manager struct {
+	db *sql.DB
+}
+
+func NewManager(db *sql.DB) *Manager {
+	return &Manager{db: db}
+}
+
+func (m *Manager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return WithinTx(ctx, m.db, fn)
+}
+
+// WithinTx runs fn in a transaction on db, joining the transaction of ctx if
+// it has one on db. The transaction is committed when fn returns nil and
+// rolled back otherwise, including when fn panics.
+func WithinTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) error {
+	if current(ctx, db) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return apperror.HandleDatabaseError(err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, &boundTx{db: db, tx: tx})); err != nil {
+		return err
+	}
+	return apperror.HandleDatabaseError(tx.Commit())
+}
+
+// From returns the transaction of ctx on db, or db outside of a transaction.
+func From(ctx context.Context, db *sql.DB) Conn {
+	if tx := current(ctx, db); tx != nil {
+		return tx
+	}
+	return db
+}
+
+func current(ctx context.Context, db *sql.DB) *sql.Tx {
+	if bound, ok := ctx.Value(txKey{}).(*boundTx); ok && bound.db == db {
+		return bound.tx
+	}
+	return nil
+}
+
+var _ transaction.Manager = (*Manager)(nil)
@@ -0,0 +1,155 @@
+package sqltx_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/persistent/sqltx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a driver logging the transactions and the statements run.
+type recorder struct {
+	mu  sync.Mutex
+	log []string
+}
+
+func (r *recorder) record(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.log = append(r.log, event)
+}
+
+func (r *recorder) events() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.log...)
+}
+
+func (r *recorder) Open(name string) (driver.Conn, error) {
+	return &recorderConn{r: r}, nil
+}
+
+type recorderConn struct {
+	r *recorder
+}
+
+func (c *recorderConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *recorderConn) Close() error { return nil }
+
+func (c *recorderConn) Begin() (driver.Tx, error) {
+	c.r.record("begin")
+	return &recorderTx{r: c.r}, nil
+}
+
+func (c *recorderConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.r.record(query)
+	return driver.RowsAffected(1), nil
+}
+
+type recorderTx struct {
+	r *recorder
+}
+
+func (t *recorderTx) Commit() error {
+	t.r.record("commit")
+	return nil
+}
+
+func (t *recorderTx) Rollback() error {
+	t.r.record("rollback")
+	return nil
+}
+
+func open(t *testing.T) (*sql.DB, *recorder) {
+	r := &recorder{}
+	db := sql.OpenDB(connector{r})
+	t.Cleanup(func() { db.Close() })
+	return db, r
+}
+
+type connector struct {
+	r *recorder
+}
+
+func (c connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.r.Open("")
+}
+
+func (c connector) Driver() driver.Driver {
+	return c.r
+}
+
+func exec(ctx context.Context, db *sql.DB, query string) error {
+	_, err := sqltx.From(ctx, db).ExecContext(ctx, query)
+	return err
+}
+
+func TestWithinTx(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Commit", func(t *testing.T) {
+		db, r := open(t)
+		manager := sqltx.NewManager(db)
+
+		err := manager.WithinTx(ctx, func(ctx context.Context) error {
+			if err := exec(ctx, db, "insert user"); err != nil {
+				return err
+			}
+			// A nested unit of work joins the transaction
+			return manager.WithinTx(ctx, func(ctx context.Context) error {
+				return exec(ctx, db, "insert role")
+			})
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"begin", "insert user", "insert role", "commit"}, r.events())
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		db, r := open(t)
+
+		err := sqltx.WithinTx(ctx, db, func(ctx context.Context) error {
+			if err := exec(ctx, db, "insert user"); err != nil {
+				return err
+			}
+			return errors.New("role not found")
+		})
+
+		assert.EqualError(t, err, "role not found")
+		assert.Equal(t, []string{"begin", "insert user", "rollback"}, r.events())
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		db, r := open(t)
+
+		assert.Panics(t, func() {
+			_ = sqltx.WithinTx(ctx, db, func(ctx context.Context) error {
+				panic("boom")
+			})
+		})
+		assert.Equal(t, []string{"begin", "rollback"}, r.events())
+	})
+
+	t.Run("OtherDatabase", func(t *testing.T) {
+		db, r := open(t)
+		other, otherRecorder := open(t)
+
+		err := sqltx.WithinTx(ctx, db, func(ctx context.Context) error {
+			// The transaction of db is not used for other
+			return exec(ctx, other, "insert audit")
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"begin", "commit"}, r.events())
+		assert.Equal(t, []string{"insert audit"}, otherRecorder.events())
+	})
+}
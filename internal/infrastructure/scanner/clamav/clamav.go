@@ -0,0 +1,202 @@
+// Package clamav scans content for malware with a ClamAV daemon, speaking the
+// clamd protocol over TCP or a unix socket.
+package clamav
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/pkg/filecheck"
+)
+
+// chunkSize is the size of the INSTREAM chunks sent to clamd.
+const chunkSize = 64 << 10
+
+var (
+	// ErrSizeLimit is returned for content larger than the StreamMaxLength of
+	// clamd.
+	ErrSizeLimit = errors.New("clamav: content exceeds the stream size limit of clamd")
+	// ErrUnexpectedReply is returned for a reply of clamd not understood.
+	ErrUnexpectedReply = errors.New("clamav: unexpected reply")
+)
+
+// Client sends content to clamd for scanning, one connection per scan.
+type Client struct {
+	network string
+	address string
+	timeout time.Duration
+}
+
+// New creates a client of the clamd listening on address, either
+// "tcp://host:port", "unix:///path/to/clamd.sock" or "host:port". timeout
+// bounds a whole scan.
+func New(address string, timeout time.Duration) (*Client, error) {
+	c := &Client{network: "tcp", address: address, timeout: timeout}
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return nil, fmt.Errorf("clamav: invalid address %q: %w", address, err)
+		}
+		switch u.Scheme {
+		case "tcp":
+			c.address = u.Host
+		case "unix":
+			c.network, c.address = "unix", u.Path
+		default:
+			return nil, fmt.Errorf("clamav: unsupported address scheme %q", u.Scheme)
+		}
+	}
+	return c, nil
+}
+
+// Ping checks that clamd is reachable.
+func (c *Client) Ping(ctx context.Context) error {
+	reply, err := c.command(ctx, "PING", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("%w: %q", ErrUnexpectedReply, reply)
+	}
+	return nil
+}
+
+// Scan streams r to clamd and returns the name of the threat found, empty
+// when r is clean.
+func (c *Client) Scan(ctx context.Context, r io.Reader) (string, error) {
+	reply, err := c.command(ctx, "INSTREAM", func(w io.Writer) error {
+		return writeChunks(w, r)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Replies are "stream: OK", "stream: <threat> FOUND" or "<reason> ERROR"
+	result := strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case result == "OK":
+		return "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return strings.TrimSuffix(result, " FOUND"), nil
+	case strings.Contains(result, "size limit exceeded"):
+		return "", ErrSizeLimit
+	case strings.HasSuffix(result, " ERROR"):
+		return "", fmt.Errorf("clamav: %s", strings.TrimSuffix(result, " ERROR"))
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnexpectedReply, reply)
+	}
+}
+
+// command sends a null-terminated command, then the payload written by body
+// if any, and returns the reply of clamd.
+func (c *Client) command(ctx context.Context, name string, body func(w io.Writer) error) (string, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, c.network, c.address)
+	if err != nil {
+		return "", fmt.Errorf("clamav: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	// Close the connection when ctx is cancelled to unblock reads and writes
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	w := bufio.NewWriterSize(conn, chunkSize+4)
+	if _, err := w.WriteString("z" + name + "\x00"); err != nil {
+		return "", c.connError(ctx, err)
+	}
+	if body != nil {
+		if err := body(w); err != nil {
+			var contentErr *contentError
+			if errors.As(err, &contentErr) {
+				return "", fmt.Errorf("clamav: reading content: %w", contentErr.err)
+			}
+			// clamd stops reading past its size limit and replies at once
+			if reply, replyErr := readReply(conn); replyErr == nil {
+				return reply, nil
+			}
+			return "", c.connError(ctx, err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return "", c.connError(ctx, err)
+	}
+
+	reply, err := readReply(conn)
+	if err != nil {
+		return "", c.connError(ctx, err)
+	}
+	return reply, nil
+}
+
+func (c *Client) connError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("clamav: %w", ctxErr)
+	}
+	return fmt.Errorf("clamav: %w", err)
+}
+
+// writeChunks writes r as length-prefixed chunks ended by an empty chunk.
+func writeChunks(w io.Writer, r io.Reader) error {
+	buf := make([]byte, chunkSize)
+	size := make([]byte, 4)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, werr := w.Write(size); werr != nil {
+				return werr
+			}
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return &contentError{err: err}
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	_, err := w.Write(size)
+	return err
+}
+
+// contentError is a failure to read the scanned content, as opposed to a
+// failure to send it.
+type contentError struct {
+	err error
+}
+
+func (e *contentError) Error() string {
+	return e.err.Error()
+}
+
+// readReply reads a null-terminated reply.
+func readReply(r io.Reader) (string, error) {
+	reply, err := bufio.NewReader(r).ReadString(0)
+	if err != nil && !(errors.Is(err, io.EOF) && reply != "") {
+		return "", err
+	}
+	return strings.TrimSpace(strings.TrimSuffix(reply, "\x00")), nil
+}
+
+var _ filecheck.Scanner = (*Client)(nil)
@@ -0,0 +1,128 @@
+package clamav_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/scanner/clamav"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// eicar is the standard antivirus test file.
+const eicar = `X5O!P%@AP[4\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*`
+
+// fakeClamd serves the PING and INSTREAM commands of clamd, finding the
+// EICAR test file and refusing streams larger than maxLength.
+func fakeClamd(t *testing.T, maxLength int) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveClamd(conn, maxLength)
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func serveClamd(conn net.Conn, maxLength int) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	command, err := r.ReadString(0)
+	if err != nil {
+		return
+	}
+	switch command {
+	case "zPING\x00":
+		conn.Write([]byte("PONG\x00"))
+	case "zINSTREAM\x00":
+		var content bytes.Buffer
+		size := make([]byte, 4)
+		for {
+			if _, err := io.ReadFull(r, size); err != nil {
+				return
+			}
+			n := binary.BigEndian.Uint32(size)
+			if n == 0 {
+				break
+			}
+			if _, err := io.CopyN(&content, r, int64(n)); err != nil {
+				return
+			}
+		}
+		switch {
+		case content.Len() > maxLength:
+			conn.Write([]byte("INSTREAM size limit exceeded. ERROR\x00"))
+		case strings.Contains(content.String(), eicar):
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+		default:
+			conn.Write([]byte("stream: OK\x00"))
+		}
+	default:
+		conn.Write([]byte("UNKNOWN COMMAND\x00"))
+	}
+}
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+	client, err := clamav.New("tcp://"+fakeClamd(t, 1<<20), 5*time.Second)
+	require.NoError(t, err)
+
+	require.NoError(t, client.Ping(ctx))
+
+	threat, err := client.Scan(ctx, strings.NewReader("hello"))
+	require.NoError(t, err)
+	assert.Empty(t, threat)
+
+	// The content spans several chunks
+	infected := strings.Repeat("a", 100<<10) + eicar
+	threat, err = client.Scan(ctx, strings.NewReader(infected))
+	require.NoError(t, err)
+	assert.Equal(t, "Eicar-Test-Signature", threat)
+
+	_, err = client.Scan(ctx, bytes.NewReader(make([]byte, 2<<20)))
+	assert.ErrorIs(t, err, clamav.ErrSizeLimit)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("download interrupted")
+}
+
+func TestScanErrors(t *testing.T) {
+	ctx := context.Background()
+
+	client, err := clamav.New(fakeClamd(t, 1<<20), 5*time.Second)
+	require.NoError(t, err)
+	_, err = client.Scan(ctx, failingReader{})
+	assert.ErrorContains(t, err, "download interrupted")
+
+	// Nothing listens on the port of a closed listener
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	listener.Close()
+	client, err = clamav.New(address, time.Second)
+	require.NoError(t, err)
+	_, err = client.Scan(ctx, strings.NewReader("hello"))
+	assert.Error(t, err)
+
+	_, err = clamav.New("http://localhost:3310", time.Second)
+	assert.Error(t, err)
+}
@@ -0,0 +1,534 @@
+// Package local implements storage.IS3Service on the local filesystem, for
+// development and single-node deployments. Presigned URLs are served by the
+// storage routes of the API.
+package local
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+	"github.com/google/uuid"
+)
+
+// metaDir holds the attributes of the objects, next to their content. Keys
+// cannot start with it.
+const metaDir = ".meta"
+
+// multipartDir holds the parts of the multipart uploads in progress, one
+// directory per upload. Keys cannot start with it.
+const multipartDir = ".multipart"
+
+// tempPrefix starts the names of the files of uploads in progress.
+const tempPrefix = ".upload-"
+
+// maxListKeys matches the largest page S3 returns.
+const maxListKeys = 1000
+
+// attributes are the attributes of an object stored as JSON in metaDir.
+type attributes struct {
+	ContentType  string            `json:"content_type,omitempty"`
+	CacheControl string            `json:"cache_control,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// Storage keeps the objects of one bucket as files under root.
+type Storage struct {
+	root    string
+	bucket  string
+	baseURL string
+	signer  *signedurl.Signer
+}
+
+// New creates root when missing. baseURL is the URL prefix objects are served
+// from, the storage routes of signer when empty.
+func New(bucket, root, baseURL string, signer *signedurl.Signer) (*Storage, error) {
+	if root == "" {
+		return nil, errors.New("local storage: path is required")
+	}
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("local storage: %w", err)
+	}
+	if baseURL == "" {
+		baseURL = signer.BucketURL(bucket)
+	}
+	return &Storage{
+		root:    root,
+		bucket:  bucket,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		signer:  signer,
+	}, nil
+}
+
+// paths returns the content and attribute files of key, refusing keys that
+// would escape root.
+func (s *Storage) paths(key string) (string, string, error) {
+	clean := path.Clean("/" + key)[1:]
+	if key == "" || clean != key || strings.HasSuffix(key, "/") || strings.ContainsRune(key, '\\') ||
+		reserved(clean) || strings.HasPrefix(path.Base(clean), tempPrefix) {
+		return "", "", fmt.Errorf("%w: %q", storage.ErrInvalidKey, key)
+	}
+	return filepath.Join(s.root, filepath.FromSlash(clean)),
+		filepath.Join(s.root, metaDir, filepath.FromSlash(clean)+".json"), nil
+}
+
+func (s *Storage) Upload(ctx context.Context, key string, body io.Reader, opts ...storage.UploadOption) error {
+	file, metaFile, err := s.paths(key)
+	if err != nil {
+		return err
+	}
+
+	o := storage.ApplyUploadOptions(opts)
+	if o.ContentType == "" {
+		o.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+
+	hash := md5.New()
+	sniffed := &sniffer{}
+	if err := writeAtomic(file, io.TeeReader(body, io.MultiWriter(hash, sniffed))); err != nil {
+		return err
+	}
+	if o.ContentType == "" {
+		o.ContentType = http.DetectContentType(sniffed.head)
+	}
+
+	attrs, err := json.Marshal(attributes{
+		ContentType:  o.ContentType,
+		CacheControl: o.CacheControl,
+		ETag:         hex.EncodeToString(hash.Sum(nil)),
+		Metadata:     lowerKeys(o.Metadata),
+	})
+	if err != nil {
+		return err
+	}
+	return writeAtomic(metaFile, strings.NewReader(string(attrs)))
+}
+
+func (s *Storage) Download(ctx context.Context, key string) (io.ReadCloser, storage.Object, error) {
+	file, _, err := s.paths(key)
+	if err != nil {
+		return nil, storage.Object{}, err
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, storage.Object{}, mapError(err)
+	}
+	info, err := s.Head(ctx, key)
+	if err != nil {
+		f.Close()
+		return nil, storage.Object{}, err
+	}
+	return f, info, nil
+}
+
+func (s *Storage) Head(ctx context.Context, key string) (storage.Object, error) {
+	file, metaFile, err := s.paths(key)
+	if err != nil {
+		return storage.Object{}, err
+	}
+
+	stat, err := os.Stat(file)
+	if err != nil {
+		return storage.Object{}, mapError(err)
+	}
+	if stat.IsDir() {
+		return storage.Object{}, storage.ErrObjectNotFound
+	}
+
+	var attrs attributes
+	if data, err := os.ReadFile(metaFile); err == nil {
+		if err := json.Unmarshal(data, &attrs); err != nil {
+			return storage.Object{}, fmt.Errorf("local storage: attributes of %q: %w", key, err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return storage.Object{}, err
+	}
+
+	return storage.Object{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  attrs.ContentType,
+		CacheControl: attrs.CacheControl,
+		ETag:         attrs.ETag,
+		LastModified: stat.ModTime().UTC().Truncate(time.Second),
+		Metadata:     attrs.Metadata,
+	}, nil
+}
+
+func (s *Storage) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.Head(ctx, key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	file, metaFile, err := s.paths(key)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range []string{file, metaFile} {
+		if err := os.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// List pages through the keys in lexical order, the cursor being the last
+// key of the previous page.
+func (s *Storage) List(ctx context.Context, prefix, cursor string, limit int) (storage.ListPage, error) {
+	if limit <= 0 || limit > maxListKeys {
+		limit = maxListKeys
+	}
+
+	var keys []string
+	err := filepath.WalkDir(s.root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(s.root, name)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if d.IsDir() {
+			if key == metaDir || key == multipartDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), tempPrefix) {
+			return nil
+		}
+		if strings.HasPrefix(key, prefix) && key > cursor {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return storage.ListPage{}, err
+	}
+	sort.Strings(keys)
+
+	page := storage.ListPage{Objects: []storage.Object{}}
+	for i, key := range keys {
+		if i == limit {
+			page.NextCursor = keys[i-1]
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return storage.ListPage{}, err
+		}
+		info, err := s.Head(ctx, key)
+		if errors.Is(err, storage.ErrObjectNotFound) {
+			continue // deleted meanwhile
+		}
+		if err != nil {
+			return storage.ListPage{}, err
+		}
+		page.Objects = append(page.Objects, info)
+	}
+	return page, nil
+}
+
+func (s *Storage) Copy(ctx context.Context, srcKey, dstKey string) error {
+	body, info, err := s.Download(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return s.Upload(ctx, dstKey, body,
+		storage.WithContentType(info.ContentType),
+		storage.WithCacheControl(info.CacheControl),
+		storage.WithMetadata(info.Metadata))
+}
+
+func (s *Storage) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	if _, _, err := s.paths(key); err != nil {
+		return "", err
+	}
+	return s.signer.Sign(http.MethodGet, s.bucket, key, expiry), nil
+}
+
+func (s *Storage) PresignPut(ctx context.Context, key string, expiry time.Duration, opts ...storage.UploadOption) (string, error) {
+	if _, _, err := s.paths(key); err != nil {
+		return "", err
+	}
+	return s.signer.Sign(http.MethodPut, s.bucket, key, expiry), nil
+}
+
+// URL returns the public URL of the object stored under key.
+func (s *Storage) URL(key string) string {
+	return s.baseURL + "/" + signedurl.EscapeKey(key)
+}
+
+// multipartUpload is the state of a multipart upload, stored as JSON in its
+// directory.
+type multipartUpload struct {
+	Key          string            `json:"key"`
+	ContentType  string            `json:"content_type,omitempty"`
+	CacheControl string            `json:"cache_control,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// uploadDir returns the directory of a multipart upload, refusing ids that
+// would escape it.
+func (s *Storage) uploadDir(uploadID string) (string, error) {
+	if uploadID == "" || strings.ContainsAny(uploadID, `/\.`) {
+		return "", storage.ErrUploadNotFound
+	}
+	return filepath.Join(s.root, multipartDir, uploadID), nil
+}
+
+// loadUpload returns the directory and the state of the upload of key.
+func (s *Storage) loadUpload(key, uploadID string) (string, multipartUpload, error) {
+	var upload multipartUpload
+	dir, err := s.uploadDir(uploadID)
+	if err != nil {
+		return "", upload, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "upload.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", upload, storage.ErrUploadNotFound
+	}
+	if err != nil {
+		return "", upload, err
+	}
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return "", upload, err
+	}
+	if upload.Key != key {
+		return "", upload, storage.ErrUploadNotFound
+	}
+	return dir, upload, nil
+}
+
+func (s *Storage) CreateMultipartUpload(ctx context.Context, key string, opts ...storage.UploadOption) (string, error) {
+	if _, _, err := s.paths(key); err != nil {
+		return "", err
+	}
+
+	o := storage.ApplyUploadOptions(opts)
+	data, err := json.Marshal(multipartUpload{
+		Key:          key,
+		ContentType:  o.ContentType,
+		CacheControl: o.CacheControl,
+		Metadata:     o.Metadata,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	uploadID := uuid.New().String()
+	dir, _ := s.uploadDir(uploadID)
+	if err := writeAtomic(filepath.Join(dir, "upload.json"), bytes.NewReader(data)); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+func (s *Storage) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (storage.Part, error) {
+	dir, _, err := s.loadUpload(key, uploadID)
+	if err != nil {
+		return storage.Part{}, err
+	}
+
+	hash := md5.New()
+	counter := &countingWriter{}
+	name := filepath.Join(dir, strconv.Itoa(number))
+	if err := writeAtomic(name, io.TeeReader(body, io.MultiWriter(hash, counter))); err != nil {
+		return storage.Part{}, err
+	}
+	if counter.n != size {
+		os.Remove(name)
+		return storage.Part{}, fmt.Errorf("part %d has %d bytes, expected %d", number, counter.n, size)
+	}
+
+	part := storage.Part{Number: number, ETag: hex.EncodeToString(hash.Sum(nil)), Size: size}
+	if err := writeAtomic(name+".etag", strings.NewReader(part.ETag)); err != nil {
+		return storage.Part{}, err
+	}
+	return part, nil
+}
+
+func (s *Storage) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []storage.Part) error {
+	dir, upload, err := s.loadUpload(key, uploadID)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(parts))
+	for _, part := range parts {
+		name := filepath.Join(dir, strconv.Itoa(part.Number))
+		etag, err := os.ReadFile(name + ".etag")
+		if err != nil || string(etag) != part.ETag {
+			return fmt.Errorf("part %d was not uploaded", part.Number)
+		}
+		names = append(names, name)
+	}
+
+	content := &partsReader{names: names}
+	defer content.Close()
+
+	err = s.Upload(ctx, key, content,
+		storage.WithContentType(upload.ContentType),
+		storage.WithCacheControl(upload.CacheControl),
+		storage.WithMetadata(upload.Metadata))
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+func (s *Storage) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	dir, _, err := s.loadUpload(key, uploadID)
+	if errors.Is(err, storage.ErrUploadNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// reserved tells whether a clean key falls in one of the directories of the
+// storage itself.
+func reserved(key string) bool {
+	for _, dir := range []string{metaDir, multipartDir} {
+		if key == dir || strings.HasPrefix(key, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// partsReader reads the part files one after the other, with one file open
+// at a time.
+type partsReader struct {
+	names   []string
+	current *os.File
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if len(r.names) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(r.names[0])
+			if err != nil {
+				return 0, err
+			}
+			r.current, r.names = f, r.names[1:]
+		}
+
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.current == nil {
+		return nil
+	}
+	return r.current.Close()
+}
+
+// countingWriter counts the bytes written to it.
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// writeAtomic writes r to name through a temporary file, so readers never see
+// a partial object.
+func writeAtomic(name string, r io.Reader) error {
+	dir := filepath.Dir(name)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, tempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func mapError(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return errors.Join(storage.ErrObjectNotFound, err)
+	}
+	return err
+}
+
+// sniffer keeps the first bytes written to it, enough to detect a content type.
+type sniffer struct {
+	head []byte
+}
+
+func (s *sniffer) Write(p []byte) (int, error) {
+	if room := 512 - len(s.head); room > 0 {
+		s.head = append(s.head, p[:min(room, len(p))]...)
+	}
+	return len(p), nil
+}
+
+// lowerKeys lowercases metadata names the way S3 does.
+func lowerKeys(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	lowered := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		lowered[strings.ToLower(k)] = v
+	}
+	return lowered
+}
+
+var _ storage.IS3Service = (*Storage)(nil)
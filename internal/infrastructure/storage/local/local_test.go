@@ -0,0 +1,155 @@
+package local_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/local"
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStorage(t *testing.T) *local.Storage {
+	s, err := local.New("public", t.TempDir(), "", signedurl.New("secret", "http://localhost/api/v1/storage"))
+	require.NoError(t, err)
+	return s
+}
+
+func TestUploadAndDownload(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+
+	err := s.Upload(ctx, "docs/report.pdf", strings.NewReader("%PDF-1.7"),
+		storage.WithCacheControl("no-store"), storage.WithMetadata(map[string]string{"Owner": "1"}))
+	require.NoError(t, err)
+
+	body, obj, err := s.Download(ctx, "docs/report.pdf")
+	require.NoError(t, err)
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+
+	assert.Equal(t, "%PDF-1.7", string(data))
+	assert.Equal(t, int64(8), obj.Size)
+	assert.Equal(t, "application/pdf", obj.ContentType)
+	assert.Equal(t, "no-store", obj.CacheControl)
+	assert.Equal(t, map[string]string{"owner": "1"}, obj.Metadata)
+	assert.Len(t, obj.ETag, 32)
+
+	require.NoError(t, s.Upload(ctx, "notes", strings.NewReader("plain text")))
+	obj, err = s.Head(ctx, "notes")
+	require.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", obj.ContentType)
+}
+
+func TestMissingObject(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+
+	_, err := s.Head(ctx, "missing.txt")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+
+	_, _, err = s.Download(ctx, "missing.txt")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+
+	exists, err := s.Exists(ctx, "missing.txt")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	assert.NoError(t, s.Delete(ctx, "missing.txt"))
+	assert.ErrorIs(t, s.Copy(ctx, "missing.txt", "copy.txt"), storage.ErrObjectNotFound)
+}
+
+func TestInvalidKeys(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+
+	for _, key := range []string{"", "../escape.txt", "a/../../escape.txt", "/abs.txt", "dir/", ".meta/a.json", "a/.upload-123"} {
+		err := s.Upload(ctx, key, strings.NewReader("x"))
+		assert.ErrorIs(t, err, storage.ErrInvalidKey, key)
+	}
+}
+
+func TestListCopyDelete(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+
+	for _, key := range []string{"avatars/1/a.png", "avatars/2/b.png", "avatars/3/c.png", "docs/d.pdf"} {
+		require.NoError(t, s.Upload(ctx, key, strings.NewReader(key)))
+	}
+
+	page, err := s.List(ctx, "avatars/", "", 2)
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 2)
+	assert.Equal(t, "avatars/1/a.png", page.Objects[0].Key)
+	assert.Equal(t, "avatars/2/b.png", page.Objects[1].Key)
+	require.NotEmpty(t, page.NextCursor)
+
+	page, err = s.List(ctx, "avatars/", page.NextCursor, 2)
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "avatars/3/c.png", page.Objects[0].Key)
+	assert.Empty(t, page.NextCursor)
+
+	require.NoError(t, s.Copy(ctx, "docs/d.pdf", "archive/d.pdf"))
+	copied, err := s.Head(ctx, "archive/d.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", copied.ContentType)
+
+	require.NoError(t, s.Delete(ctx, "docs/d.pdf"))
+	exists, err := s.Exists(ctx, "docs/d.pdf")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestURLs(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+
+	assert.Equal(t, "http://localhost/api/v1/storage/public/avatars/1/a%20b.png", s.URL("avatars/1/a b.png"))
+
+	signed, err := s.PresignGet(ctx, "avatars/1/a.png", 0)
+	require.NoError(t, err)
+	assert.Contains(t, signed, "http://localhost/api/v1/storage/public/avatars/1/a.png?expires=")
+
+	_, err = s.PresignPut(ctx, "../a.png", 0)
+	assert.ErrorIs(t, err, storage.ErrInvalidKey)
+}
+
+func TestMultipartUpload(t *testing.T) {
+	ctx := context.Background()
+	s := newStorage(t)
+
+	uploadID, err := s.CreateMultipartUpload(ctx, "files/1/a", storage.WithContentType("text/plain"))
+	require.NoError(t, err)
+
+	first, err := s.UploadPart(ctx, "files/1/a", uploadID, 1, strings.NewReader("hello "), 6)
+	require.NoError(t, err)
+	second, err := s.UploadPart(ctx, "files/1/a", uploadID, 2, strings.NewReader("world"), 5)
+	require.NoError(t, err)
+
+	// Pending uploads are not listed
+	page, err := s.List(ctx, "", "", 0)
+	require.NoError(t, err)
+	assert.Empty(t, page.Objects)
+
+	require.NoError(t, s.CompleteMultipartUpload(ctx, "files/1/a", uploadID, []storage.Part{first, second}))
+	body, obj, err := s.Download(ctx, "files/1/a")
+	require.NoError(t, err)
+	defer body.Close()
+	data, _ := io.ReadAll(body)
+	assert.Equal(t, "hello world", string(data))
+	assert.Equal(t, "text/plain", obj.ContentType)
+
+	err = s.CompleteMultipartUpload(ctx, "files/1/a", uploadID, []storage.Part{first, second})
+	assert.ErrorIs(t, err, storage.ErrUploadNotFound)
+
+	uploadID, err = s.CreateMultipartUpload(ctx, "files/1/b")
+	require.NoError(t, err)
+	require.NoError(t, s.AbortMultipartUpload(ctx, "files/1/b", uploadID))
+	_, err = s.UploadPart(ctx, "files/1/b", uploadID, 1, strings.NewReader("a"), 1)
+	assert.ErrorIs(t, err, storage.ErrUploadNotFound)
+}
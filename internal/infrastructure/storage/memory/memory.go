@@ -0,0 +1,264 @@
+// Package memory implements storage.IS3Service in memory, for tests and local
+// development. Presigned URLs are served by the storage routes of the API.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+	"github.com/google/uuid"
+)
+
+// maxListKeys matches the largest page S3 returns.
+const maxListKeys = 1000
+
+type object struct {
+	data []byte
+	info storage.Object
+}
+
+type multipartUpload struct {
+	key   string
+	opts  []storage.UploadOption
+	parts map[int]object
+}
+
+// Storage keeps the objects of one bucket in memory.
+type Storage struct {
+	mu      sync.RWMutex
+	objects map[string]object
+	uploads map[string]*multipartUpload
+	bucket  string
+	baseURL string
+	signer  *signedurl.Signer
+}
+
+// New creates an empty bucket. baseURL is the URL prefix objects are served
+// from, the storage routes of signer when empty.
+func New(bucket, baseURL string, signer *signedurl.Signer) *Storage {
+	if baseURL == "" {
+		baseURL = signer.BucketURL(bucket)
+	}
+	return &Storage{
+		objects: map[string]object{},
+		uploads: map[string]*multipartUpload{},
+		bucket:  bucket,
+		baseURL: strings.TrimRight(baseURL, "/"),
+		signer:  signer,
+	}
+}
+
+func (s *Storage) Upload(ctx context.Context, key string, body io.Reader, opts ...storage.UploadOption) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	o := storage.ApplyUploadOptions(opts)
+	if o.ContentType == "" {
+		o.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if o.ContentType == "" {
+		o.ContentType = http.DetectContentType(data)
+	}
+	sum := md5.Sum(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.objects[key] = object{
+		data: data,
+		info: storage.Object{
+			Key:          key,
+			Size:         int64(len(data)),
+			ContentType:  o.ContentType,
+			CacheControl: o.CacheControl,
+			ETag:         hex.EncodeToString(sum[:]),
+			LastModified: time.Now().UTC().Truncate(time.Second),
+			Metadata:     lowerKeys(o.Metadata),
+		},
+	}
+	return nil
+}
+
+func (s *Storage) Download(ctx context.Context, key string) (io.ReadCloser, storage.Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	obj, ok := s.objects[key]
+	if !ok {
+		return nil, storage.Object{}, storage.ErrObjectNotFound
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), obj.info, nil
+}
+
+func (s *Storage) Head(ctx context.Context, key string) (storage.Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	obj, ok := s.objects[key]
+	if !ok {
+		return storage.Object{}, storage.ErrObjectNotFound
+	}
+	return obj.info, nil
+}
+
+func (s *Storage) Exists(ctx context.Context, key string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.objects[key]
+	return ok, nil
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.objects, key)
+	return nil
+}
+
+// List pages through the keys in lexical order, the cursor being the last
+// key of the previous page.
+func (s *Storage) List(ctx context.Context, prefix, cursor string, limit int) (storage.ListPage, error) {
+	if limit <= 0 || limit > maxListKeys {
+		limit = maxListKeys
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]string, 0, len(s.objects))
+	for key := range s.objects {
+		if strings.HasPrefix(key, prefix) && key > cursor {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	page := storage.ListPage{Objects: []storage.Object{}}
+	for i, key := range keys {
+		if i == limit {
+			page.NextCursor = keys[i-1]
+			break
+		}
+		page.Objects = append(page.Objects, s.objects[key].info)
+	}
+	return page, nil
+}
+
+func (s *Storage) Copy(ctx context.Context, srcKey, dstKey string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	obj, ok := s.objects[srcKey]
+	if !ok {
+		return storage.ErrObjectNotFound
+	}
+	obj.info.Key = dstKey
+	obj.info.LastModified = time.Now().UTC().Truncate(time.Second)
+	s.objects[dstKey] = obj
+	return nil
+}
+
+func (s *Storage) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	return s.signer.Sign(http.MethodGet, s.bucket, key, expiry), nil
+}
+
+func (s *Storage) PresignPut(ctx context.Context, key string, expiry time.Duration, opts ...storage.UploadOption) (string, error) {
+	return s.signer.Sign(http.MethodPut, s.bucket, key, expiry), nil
+}
+
+// URL returns the public URL of the object stored under key.
+func (s *Storage) URL(key string) string {
+	return s.baseURL + "/" + signedurl.EscapeKey(key)
+}
+
+func (s *Storage) CreateMultipartUpload(ctx context.Context, key string, opts ...storage.UploadOption) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	uploadID := uuid.New().String()
+	s.uploads[uploadID] = &multipartUpload{key: key, opts: opts, parts: map[int]object{}}
+	return uploadID, nil
+}
+
+func (s *Storage) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (storage.Part, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return storage.Part{}, err
+	}
+	if int64(len(data)) != size {
+		return storage.Part{}, fmt.Errorf("part %d has %d bytes, expected %d", number, len(data), size)
+	}
+	sum := md5.Sum(data)
+	part := storage.Part{Number: number, ETag: hex.EncodeToString(sum[:]), Size: size}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	upload, ok := s.uploads[uploadID]
+	if !ok || upload.key != key {
+		return storage.Part{}, storage.ErrUploadNotFound
+	}
+	upload.parts[number] = object{data: data, info: storage.Object{ETag: part.ETag, Size: size}}
+	return part, nil
+}
+
+func (s *Storage) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []storage.Part) error {
+	s.mu.Lock()
+	upload, ok := s.uploads[uploadID]
+	if !ok || upload.key != key {
+		s.mu.Unlock()
+		return storage.ErrUploadNotFound
+	}
+
+	var content bytes.Buffer
+	for _, part := range parts {
+		uploaded, ok := upload.parts[part.Number]
+		if !ok || uploaded.info.ETag != part.ETag {
+			s.mu.Unlock()
+			return fmt.Errorf("part %d was not uploaded", part.Number)
+		}
+		content.Write(uploaded.data)
+	}
+	delete(s.uploads, uploadID)
+	s.mu.Unlock()
+
+	return s.Upload(ctx, key, &content, upload.opts...)
+}
+
+func (s *Storage) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.uploads, uploadID)
+	return nil
+}
+
+// lowerKeys lowercases metadata names the way S3 does.
+func lowerKeys(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+	lowered := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		lowered[strings.ToLower(k)] = v
+	}
+	return lowered
+}
+
+var _ storage.IS3Service = (*Storage)(nil)
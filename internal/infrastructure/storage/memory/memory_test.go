@@ -0,0 +1,88 @@
+package memory_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/memory"
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New("public", "https://cdn.example.com", signedurl.New("secret", "http://localhost/api/v1/storage"))
+
+	require.NoError(t, s.Upload(ctx, "avatars/1/a.png", strings.NewReader("a"), storage.WithContentType("image/png")))
+	require.NoError(t, s.Upload(ctx, "avatars/2/b.png", strings.NewReader("bb")))
+	require.NoError(t, s.Upload(ctx, "docs/c.pdf", strings.NewReader("ccc"), storage.WithMetadata(map[string]string{"Owner": "1"})))
+
+	body, obj, err := s.Download(ctx, "docs/c.pdf")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	assert.Equal(t, "ccc", string(data))
+	assert.Equal(t, "application/pdf", obj.ContentType)
+	assert.Equal(t, map[string]string{"owner": "1"}, obj.Metadata)
+
+	page, err := s.List(ctx, "avatars/", "", 1)
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, "avatars/1/a.png", page.Objects[0].Key)
+
+	page, err = s.List(ctx, "avatars/", page.NextCursor, 1)
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 1)
+	assert.Equal(t, int64(2), page.Objects[0].Size)
+	assert.Empty(t, page.NextCursor)
+
+	require.NoError(t, s.Copy(ctx, "docs/c.pdf", "archive/c.pdf"))
+	copied, err := s.Head(ctx, "archive/c.pdf")
+	require.NoError(t, err)
+	assert.Equal(t, "archive/c.pdf", copied.Key)
+	assert.Equal(t, obj.ETag, copied.ETag)
+
+	require.NoError(t, s.Delete(ctx, "docs/c.pdf"))
+	_, err = s.Head(ctx, "docs/c.pdf")
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+
+	assert.Equal(t, "https://cdn.example.com/avatars/1/a.png", s.URL("avatars/1/a.png"))
+	signed, err := s.PresignPut(ctx, "docs/new.pdf", 0)
+	require.NoError(t, err)
+	assert.Contains(t, signed, "http://localhost/api/v1/storage/public/docs/new.pdf?expires=")
+}
+
+func TestMultipartUpload(t *testing.T) {
+	ctx := context.Background()
+	s := memory.New("private", "", signedurl.New("secret", "http://localhost/api/v1/storage"))
+
+	uploadID, err := s.CreateMultipartUpload(ctx, "files/1/a", storage.WithContentType("text/plain"))
+	require.NoError(t, err)
+
+	second, err := s.UploadPart(ctx, "files/1/a", uploadID, 2, strings.NewReader("world"), 5)
+	require.NoError(t, err)
+	first, err := s.UploadPart(ctx, "files/1/a", uploadID, 1, strings.NewReader("hello "), 6)
+	require.NoError(t, err)
+
+	_, err = s.UploadPart(ctx, "files/1/a", uploadID, 3, strings.NewReader("short"), 6)
+	assert.Error(t, err)
+
+	require.NoError(t, s.CompleteMultipartUpload(ctx, "files/1/a", uploadID, []storage.Part{first, second}))
+	body, obj, err := s.Download(ctx, "files/1/a")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	assert.Equal(t, "hello world", string(data))
+	assert.Equal(t, "text/plain", obj.ContentType)
+
+	err = s.CompleteMultipartUpload(ctx, "files/1/a", uploadID, []storage.Part{first, second})
+	assert.ErrorIs(t, err, storage.ErrUploadNotFound)
+
+	uploadID, err = s.CreateMultipartUpload(ctx, "files/1/b")
+	require.NoError(t, err)
+	require.NoError(t, s.AbortMultipartUpload(ctx, "files/1/b", uploadID))
+	_, err = s.UploadPart(ctx, "files/1/b", uploadID, 1, strings.NewReader("a"), 1)
+	assert.ErrorIs(t, err, storage.ErrUploadNotFound)
+}
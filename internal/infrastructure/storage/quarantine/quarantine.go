@@ -0,0 +1,49 @@
+// Package quarantine keeps rejected uploads in a private bucket, under the
+// quarantine/ prefix, with the reason of their rejection as metadata.
+package quarantine
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/google/uuid"
+)
+
+// Prefix is the key prefix of quarantined objects.
+const Prefix = "quarantine/"
+
+type Quarantine struct {
+	store storage.IS3Service
+}
+
+// New creates a quarantine in store, which must not be publicly readable.
+func New(store storage.IS3Service) *Quarantine {
+	return &Quarantine{store: store}
+}
+
+// Put stores body under a key dated by day. It is stored as an opaque
+// stream, so that no client renders it when downloaded for review.
+func (q *Quarantine) Put(ctx context.Context, r file.Rejected, body io.Reader) (string, error) {
+	key := fmt.Sprintf("%s%s/%s", Prefix, time.Now().UTC().Format("2006-01-02"), uuid.New().String())
+
+	// Metadata must be ASCII
+	err := q.store.Upload(ctx, key, body,
+		storage.WithContentType("application/octet-stream"),
+		storage.WithMetadata(map[string]string{
+			"owner":        r.OwnerID,
+			"name":         url.QueryEscape(r.Name),
+			"content-type": r.ContentType,
+			"reason":       url.QueryEscape(r.Reason),
+		}))
+	if err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+var _ file.Quarantine = (*Quarantine)(nil)
@@ -0,0 +1,38 @@
+package quarantine_test
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/memory"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/quarantine"
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPut(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New("private", "", signedurl.New("secret", "http://localhost/api/v1/storage"))
+	q := quarantine.New(store)
+
+	key, err := q.Put(ctx, file.Rejected{
+		OwnerID:     "owner-1",
+		Name:        "résumé.exe",
+		ContentType: "application/octet-stream",
+		Reason:      "file contains malware (Eicar-Test-Signature)",
+	}, strings.NewReader("MZ"))
+
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(key, quarantine.Prefix))
+	body, obj, err := store.Download(ctx, key)
+	require.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	assert.Equal(t, "MZ", string(data))
+	assert.Equal(t, "application/octet-stream", obj.ContentType)
+	assert.Equal(t, "owner-1", obj.Metadata["owner"])
+	assert.Equal(t, "r%C3%A9sum%C3%A9.exe", obj.Metadata["name"])
+}
@@ -2,23 +2,39 @@ package s3
 
 import (
 	"context"
+	"errors"
 	"io"
+	"mime"
+	"net/url"
+	"path"
+	"strings"
+	"time"
 
 	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 )
 
+// maxListKeys is the largest page S3 returns.
+const maxListKeys = 1000
+
 // S3Service implements the IS3Service interface for S3 storage operations.
 type S3Service struct {
-	client *s3.Client
-	bucket string
+	client  *s3.Client
+	presign *s3.PresignClient
+	bucket  string
+	baseURL string
 }
 
-// NewUploader creates a new instance of S3Service.
-func NewUploader(client *s3.Client, bucket string) *S3Service {
+// NewUploader creates a new instance of S3Service. baseURL is the URL prefix
+// the objects of the bucket are served from.
+func NewUploader(client *s3.Client, bucket, baseURL string) *S3Service {
 	return &S3Service{
-		client: client,
-		bucket: bucket,
+		client:  client,
+		presign: s3.NewPresignClient(client),
+		bucket:  bucket,
+		baseURL: strings.TrimRight(baseURL, "/"),
 	}
 }
 
@@ -26,13 +42,248 @@ func (s *S3Service) Upload(
 	ctx context.Context,
 	key string,
 	body io.Reader,
+	opts ...storage.UploadOption,
 ) error {
-	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+	_, err := s.client.PutObject(ctx, s.putObjectInput(key, body, opts))
+	return err
+}
+
+func (s *S3Service) putObjectInput(key string, body io.Reader, opts []storage.UploadOption) *s3.PutObjectInput {
+	o := storage.ApplyUploadOptions(opts)
+	// Served as is to browsers, so keep a meaningful content type
+	if o.ContentType == "" {
+		o.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+
+	return &s3.PutObjectInput{
+		Bucket:       &s.bucket,
+		Key:          &key,
+		Body:         body,
+		ContentType:  optional(o.ContentType),
+		CacheControl: optional(o.CacheControl),
+		Metadata:     o.Metadata,
+	}
+}
+
+func (s *S3Service) Download(ctx context.Context, key string) (io.ReadCloser, storage.Object, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, storage.Object{}, mapError(err)
+	}
+
+	return out.Body, storage.Object{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		CacheControl: aws.ToString(out.CacheControl),
+		ETag:         strings.Trim(aws.ToString(out.ETag), `"`),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+func (s *S3Service) Head(ctx context.Context, key string) (storage.Object, error) {
+	out, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return storage.Object{}, mapError(err)
+	}
+
+	return storage.Object{
+		Key:          key,
+		Size:         aws.ToInt64(out.ContentLength),
+		ContentType:  aws.ToString(out.ContentType),
+		CacheControl: aws.ToString(out.CacheControl),
+		ETag:         strings.Trim(aws.ToString(out.ETag), `"`),
+		LastModified: aws.ToTime(out.LastModified),
+		Metadata:     out.Metadata,
+	}, nil
+}
+
+func (s *S3Service) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := s.Head(ctx, key)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (s *S3Service) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err = mapError(err); errors.Is(err, storage.ErrObjectNotFound) {
+		return nil
+	}
+	return err
+}
+
+func (s *S3Service) List(ctx context.Context, prefix, cursor string, limit int) (storage.ListPage, error) {
+	if limit <= 0 || limit > maxListKeys {
+		limit = maxListKeys
+	}
+
+	out, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:            &s.bucket,
+		Prefix:            optional(prefix),
+		ContinuationToken: optional(cursor),
+		MaxKeys:           aws.Int32(int32(limit)),
+	})
+	if err != nil {
+		return storage.ListPage{}, mapError(err)
+	}
+
+	page := storage.ListPage{Objects: make([]storage.Object, 0, len(out.Contents))}
+	for _, obj := range out.Contents {
+		page.Objects = append(page.Objects, storage.Object{
+			Key:          aws.ToString(obj.Key),
+			Size:         aws.ToInt64(obj.Size),
+			ETag:         strings.Trim(aws.ToString(obj.ETag), `"`),
+			LastModified: aws.ToTime(obj.LastModified),
+		})
+	}
+	if aws.ToBool(out.IsTruncated) {
+		page.NextCursor = aws.ToString(out.NextContinuationToken)
+	}
+	return page, nil
+}
+
+func (s *S3Service) Copy(ctx context.Context, srcKey, dstKey string) error {
+	_, err := s.client.CopyObject(ctx, &s3.CopyObjectInput{
+		Bucket:     &s.bucket,
+		Key:        &dstKey,
+		CopySource: aws.String(s.copySource(srcKey)),
+	})
+	return mapError(err)
+}
+
+// copySource returns the URL-encoded bucket/key CopyObject expects.
+func (s *S3Service) copySource(key string) string {
+	segments := strings.Split(s.bucket+"/"+key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+func (s *S3Service) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
 		Bucket: &s.bucket,
 		Key:    &key,
-		Body:   body,
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+func (s *S3Service) PresignPut(ctx context.Context, key string, expiry time.Duration, opts ...storage.UploadOption) (string, error) {
+	req, err := s.presign.PresignPutObject(ctx, s.putObjectInput(key, nil, opts), s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", err
+	}
+	return req.URL, nil
+}
+
+// URL returns the public URL of the object stored under key.
+func (s *S3Service) URL(key string) string {
+	return s.baseURL + "/" + strings.TrimLeft(key, "/")
+}
+
+func (s *S3Service) CreateMultipartUpload(ctx context.Context, key string, opts ...storage.UploadOption) (string, error) {
+	put := s.putObjectInput(key, nil, opts)
+	out, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:       &s.bucket,
+		Key:          &key,
+		ContentType:  put.ContentType,
+		CacheControl: put.CacheControl,
+		Metadata:     put.Metadata,
+	})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.UploadId), nil
+}
+
+func (s *S3Service) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (storage.Part, error) {
+	out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        &s.bucket,
+		Key:           &key,
+		UploadId:      &uploadID,
+		PartNumber:    aws.Int32(int32(number)),
+		Body:          body,
+		ContentLength: aws.Int64(size),
 	})
+	if err != nil {
+		return storage.Part{}, mapError(err)
+	}
+	return storage.Part{
+		Number: number,
+		ETag:   strings.Trim(aws.ToString(out.ETag), `"`),
+		Size:   size,
+	}, nil
+}
+
+func (s *S3Service) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []storage.Part) error {
+	completed := make([]types.CompletedPart, len(parts))
+	for i, part := range parts {
+		completed[i] = types.CompletedPart{
+			ETag:       aws.String(`"` + part.ETag + `"`),
+			PartNumber: aws.Int32(int32(part.Number)),
+		}
+	}
+
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          &s.bucket,
+		Key:             &key,
+		UploadId:        &uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	return mapError(err)
+}
+
+func (s *S3Service) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &s.bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+	})
+	if err = mapError(err); errors.Is(err, storage.ErrUploadNotFound) {
+		return nil
+	}
 	return err
 }
 
+// mapError translates the missing object and upload errors of S3 into
+// storage.ErrObjectNotFound and storage.ErrUploadNotFound.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var noSuchKey *types.NoSuchKey
+	var notFound *types.NotFound
+	if errors.As(err, &noSuchKey) || errors.As(err, &notFound) {
+		return errors.Join(storage.ErrObjectNotFound, err)
+	}
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return errors.Join(storage.ErrUploadNotFound, err)
+	}
+	return err
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
 var _ storage.IS3Service = (*S3Service)(nil)
@@ -0,0 +1,176 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	appconfig "github.com/afandimsr/go-gin-api/internal/config"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T, handler http.HandlerFunc) *S3Service {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	client, err := New(context.Background(), appconfig.S3Config{
+		Endpoint:  server.URL,
+		Region:    "us-east-1",
+		AccessKey: "access",
+		SecretKey: "secret",
+		Bucket:    "bucket",
+	})
+	require.NoError(t, err)
+	return NewUploader(client, "bucket", "https://cdn.example.com/")
+}
+
+func TestS3ServiceUploadSendsOptions(t *testing.T) {
+	var got http.Header
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.Equal(t, "/bucket/docs/report.pdf", r.URL.Path)
+		got = r.Header.Clone()
+	})
+
+	err := service.Upload(context.Background(), "docs/report.pdf", strings.NewReader("%PDF"),
+		storage.WithCacheControl("no-store"), storage.WithMetadata(map[string]string{"owner": "1"}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "application/pdf", got.Get("Content-Type"))
+	assert.Equal(t, "no-store", got.Get("Cache-Control"))
+	assert.Equal(t, "1", got.Get("X-Amz-Meta-Owner"))
+}
+
+func TestS3ServiceMissingObject(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`<Error><Code>NoSuchKey</Code><Message>missing</Message></Error>`))
+	})
+
+	_, err := service.Head(context.Background(), "missing")
+	assert.True(t, errors.Is(err, storage.ErrObjectNotFound))
+
+	exists, err := service.Exists(context.Background(), "missing")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	_, _, err = service.Download(context.Background(), "missing")
+	assert.True(t, errors.Is(err, storage.ErrObjectNotFound))
+}
+
+func TestS3ServiceList(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "avatars/", r.URL.Query().Get("prefix"))
+		assert.Equal(t, "2", r.URL.Query().Get("max-keys"))
+		assert.Equal(t, "token", r.URL.Query().Get("continuation-token"))
+
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<ListBucketResult>
+	<IsTruncated>true</IsTruncated>
+	<NextContinuationToken>next</NextContinuationToken>
+	<Contents><Key>avatars/1.png</Key><Size>10</Size><ETag>"a"</ETag><LastModified>2026-01-02T03:04:05.000Z</LastModified></Contents>
+	<Contents><Key>avatars/2.png</Key><Size>20</Size><ETag>"b"</ETag><LastModified>2026-01-02T03:04:05.000Z</LastModified></Contents>
+</ListBucketResult>`))
+	})
+
+	page, err := service.List(context.Background(), "avatars/", "token", 2)
+
+	require.NoError(t, err)
+	require.Len(t, page.Objects, 2)
+	assert.Equal(t, "avatars/1.png", page.Objects[0].Key)
+	assert.Equal(t, int64(20), page.Objects[1].Size)
+	assert.Equal(t, "b", page.Objects[1].ETag)
+	assert.Equal(t, "next", page.NextCursor)
+}
+
+func TestS3ServiceCopyEncodesSource(t *testing.T) {
+	var source string
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		source = r.Header.Get("X-Amz-Copy-Source")
+		w.Header().Set("Content-Type", "application/xml")
+		_, _ = w.Write([]byte(`<CopyObjectResult><ETag>"a"</ETag></CopyObjectResult>`))
+	})
+
+	err := service.Copy(context.Background(), "docs/annual report.pdf", "archive/report.pdf")
+
+	require.NoError(t, err)
+	assert.Equal(t, "bucket/docs/annual%20report.pdf", source)
+}
+
+func TestS3ServiceMultipartUpload(t *testing.T) {
+	var completion string
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		query := r.URL.Query()
+		switch {
+		case r.Method == http.MethodPost && query.Has("uploads"):
+			_, _ = w.Write([]byte(`<InitiateMultipartUploadResult><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`))
+		case r.Method == http.MethodPut:
+			assert.Equal(t, "upload-1", query.Get("uploadId"))
+			assert.Equal(t, "1", query.Get("partNumber"))
+			w.Header().Set("ETag", `"etag-1"`)
+		case r.Method == http.MethodPost:
+			body, _ := io.ReadAll(r.Body)
+			completion = string(body)
+			_, _ = w.Write([]byte(`<CompleteMultipartUploadResult><ETag>"final"</ETag></CompleteMultipartUploadResult>`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<Error><Code>NoSuchUpload</Code><Message>missing</Message></Error>`))
+		}
+	})
+	ctx := context.Background()
+
+	uploadID, err := service.CreateMultipartUpload(ctx, "files/1/a", storage.WithContentType("video/mp4"))
+	require.NoError(t, err)
+	assert.Equal(t, "upload-1", uploadID)
+
+	part, err := service.UploadPart(ctx, "files/1/a", uploadID, 1, strings.NewReader("data"), 4)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Part{Number: 1, ETag: "etag-1", Size: 4}, part)
+
+	require.NoError(t, service.CompleteMultipartUpload(ctx, "files/1/a", uploadID, []storage.Part{part}))
+	assert.Contains(t, completion, `<ETag>&#34;etag-1&#34;</ETag>`)
+
+	// An upload already gone is not an error
+	assert.NoError(t, service.AbortMultipartUpload(ctx, "files/1/a", uploadID))
+}
+
+func TestS3ServicePresign(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("presigning must not call S3")
+	})
+
+	getURL, err := service.PresignGet(context.Background(), "docs/report.pdf", 5*time.Minute)
+	require.NoError(t, err)
+	parsed, err := url.Parse(getURL)
+	require.NoError(t, err)
+	assert.Equal(t, "/bucket/docs/report.pdf", parsed.Path)
+	assert.Equal(t, "300", parsed.Query().Get("X-Amz-Expires"))
+
+	putURL, err := service.PresignPut(context.Background(), "docs/report.pdf", time.Minute,
+		storage.WithMetadata(map[string]string{"owner": "1"}))
+	require.NoError(t, err)
+	parsed, err = url.Parse(putURL)
+	require.NoError(t, err)
+	assert.Equal(t, "60", parsed.Query().Get("X-Amz-Expires"))
+	assert.Contains(t, parsed.Query().Get("X-Amz-SignedHeaders"), "x-amz-meta-owner")
+}
+
+func TestS3ServiceURL(t *testing.T) {
+	service := newTestService(t, nil)
+
+	assert.Equal(t, "https://cdn.example.com/avatars/1.png", service.URL("/avatars/1.png"))
+}
@@ -0,0 +1,60 @@
+package cursor
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrInvalidCursor is returned for malformed or tampered cursors.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+var secretKey []byte
+
+func SetSecret(secret string) {
+	secretKey = []byte(secret)
+}
+
+// Encode serializes v into an opaque token of the form payload.signature, both
+// base64url encoded. The HMAC prevents clients from crafting cursors.
+func Encode(v interface{}) (string, error) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." +
+		base64.RawURLEncoding.EncodeToString(sign(payload)), nil
+}
+
+// Decode verifies token and unmarshals its payload into v.
+func Decode(token string, v interface{}) error {
+	encodedPayload, encodedSig, ok := strings.Cut(token, ".")
+	if !ok {
+		return ErrInvalidCursor
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(encodedSig)
+	if err != nil || !hmac.Equal(sig, sign(payload)) {
+		return ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(payload, v); err != nil {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+func sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, secretKey)
+	mac.Write([]byte("cursor:"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
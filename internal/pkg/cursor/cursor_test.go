@@ -0,0 +1,41 @@
+package cursor_test
+
+import (
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/pkg/cursor"
+	"github.com/stretchr/testify/assert"
+)
+
+type position struct {
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+func TestCursor(t *testing.T) {
+	cursor.SetSecret("test-secret")
+
+	token, err := cursor.Encode(position{Value: "2026-01-01T00:00:00Z", ID: "42"})
+	assert.NoError(t, err)
+
+	t.Run("RoundTrip", func(t *testing.T) {
+		var p position
+		assert.NoError(t, cursor.Decode(token, &p))
+		assert.Equal(t, position{Value: "2026-01-01T00:00:00Z", ID: "42"}, p)
+	})
+
+	t.Run("Tampered", func(t *testing.T) {
+		forged, _ := cursor.Encode(position{ID: "43"})
+		var p position
+		assert.ErrorIs(t, cursor.Decode(forged[:len(forged)-2]+token[len(token)-2:], &p), cursor.ErrInvalidCursor)
+		assert.ErrorIs(t, cursor.Decode("not-a-cursor", &p), cursor.ErrInvalidCursor)
+	})
+
+	t.Run("OtherSecret", func(t *testing.T) {
+		cursor.SetSecret("other-secret")
+		defer cursor.SetSecret("test-secret")
+
+		var p position
+		assert.ErrorIs(t, cursor.Decode(token, &p), cursor.ErrInvalidCursor)
+	})
+}
@@ -0,0 +1,243 @@
+// Package filecheck runs uploaded content through a pipeline of checks before
+// it is published: the type detected from the content, the extension of the
+// file name, the dimensions of images and a malware scan.
+package filecheck
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	// Decoders of the images whose dimensions are checked
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// sniffLen is the number of bytes the type of the content is detected from.
+const sniffLen = 512
+
+// Content is a file going through the pipeline. Checks read it as many times
+// as they need through Open.
+type Content struct {
+	Name string
+	Size int64
+	// Type is detected from the first bytes of the content, the file name and
+	// the declared type are not trusted
+	Type string
+	open func() (io.ReadCloser, error)
+}
+
+// NewContent detects the type of the content open returns.
+func NewContent(name string, size int64, open func() (io.ReadCloser, error)) (*Content, error) {
+	c := &Content{Name: name, Size: size, open: open}
+
+	r, err := c.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	c.Type = DetectType(head[:n])
+	return c, nil
+}
+
+// Bytes returns the content of an in-memory file.
+func Bytes(name string, data []byte) *Content {
+	c, _ := NewContent(name, int64(len(data)), func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	})
+	return c
+}
+
+// Open returns a reader of the content from its start.
+func (c *Content) Open() (io.ReadCloser, error) {
+	return c.open()
+}
+
+// Extension returns the lowercased extension of the file name, with its dot.
+func (c *Content) Extension() string {
+	return strings.ToLower(path.Ext(c.Name))
+}
+
+// DetectType returns the media type of content from its first bytes, without
+// parameters.
+func DetectType(head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+// Rejection reports content failing a check. Other errors of a check mean
+// the content could not be checked.
+type Rejection struct {
+	Reason string
+}
+
+func (r *Rejection) Error() string {
+	return r.Reason
+}
+
+// Reject returns a Rejection of the given reason.
+func Reject(format string, args ...any) error {
+	return &Rejection{Reason: fmt.Sprintf(format, args...)}
+}
+
+// AsRejection returns the Rejection err wraps, if any.
+func AsRejection(err error) (*Rejection, bool) {
+	var rejection *Rejection
+	ok := errors.As(err, &rejection)
+	return rejection, ok
+}
+
+// Check inspects content before it is published.
+type Check interface {
+	Check(ctx context.Context, c *Content) error
+}
+
+// CheckFunc adapts a function to Check.
+type CheckFunc func(ctx context.Context, c *Content) error
+
+func (f CheckFunc) Check(ctx context.Context, c *Content) error {
+	return f(ctx, c)
+}
+
+// Pipeline runs checks in order, stopping at the first failure.
+type Pipeline struct {
+	checks []Check
+}
+
+// New creates a pipeline of checks, nil checks are skipped.
+func New(checks ...Check) *Pipeline {
+	p := &Pipeline{}
+	for _, check := range checks {
+		if check != nil {
+			p.checks = append(p.checks, check)
+		}
+	}
+	return p
+}
+
+// Run checks content. A nil pipeline accepts everything.
+func (p *Pipeline) Run(ctx context.Context, c *Content) error {
+	if p == nil {
+		return nil
+	}
+	for _, check := range p.checks {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := check.Check(ctx, c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Extensions accepts the file names with one of exts, matched case
+// insensitively. No extensions accept every name.
+func Extensions(exts ...string) Check {
+	if len(exts) == 0 {
+		return nil
+	}
+	allowed := make([]string, len(exts))
+	for i, ext := range exts {
+		allowed[i] = "." + strings.TrimPrefix(strings.ToLower(ext), ".")
+	}
+	return CheckFunc(func(ctx context.Context, c *Content) error {
+		if !slices.Contains(allowed, c.Extension()) {
+			return Reject("file extension %q is not allowed", c.Extension())
+		}
+		return nil
+	})
+}
+
+// Types accepts the content of one of the detected media types, "image/*"
+// matching every image type. No types accept every content.
+func Types(types ...string) Check {
+	if len(types) == 0 {
+		return nil
+	}
+	return CheckFunc(func(ctx context.Context, c *Content) error {
+		for _, t := range types {
+			if t == c.Type || strings.HasSuffix(t, "/*") && strings.HasPrefix(c.Type, strings.TrimSuffix(t, "*")) {
+				return nil
+			}
+		}
+		return Reject("file type %s is not allowed", c.Type)
+	})
+}
+
+// MaxDimensions rejects images wider than width or taller than height pixels,
+// and images whose header cannot be decoded. Other content is not affected.
+func MaxDimensions(width, height int) Check {
+	if width <= 0 && height <= 0 {
+		return nil
+	}
+	return CheckFunc(func(ctx context.Context, c *Content) error {
+		if !strings.HasPrefix(c.Type, "image/") {
+			return nil
+		}
+		r, err := c.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		cfg, _, err := image.DecodeConfig(r)
+		if err != nil {
+			return Reject("image is invalid")
+		}
+		if width > 0 && cfg.Width > width || height > 0 && cfg.Height > height {
+			return Reject("image must not exceed %dx%d pixels", width, height)
+		}
+		return nil
+	})
+}
+
+// Scanner detects malware.
+type Scanner interface {
+	// Scan returns the name of the threat found in r, empty when r is clean.
+	Scan(ctx context.Context, r io.Reader) (string, error)
+}
+
+// Scan rejects content in which scanner finds a threat. A nil scanner
+// accepts every content.
+func Scan(scanner Scanner) Check {
+	if scanner == nil {
+		return nil
+	}
+	return CheckFunc(func(ctx context.Context, c *Content) error {
+		r, err := c.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+
+		threat, err := scanner.Scan(ctx, r)
+		if err != nil {
+			return fmt.Errorf("malware scan failed: %w", err)
+		}
+		if threat != "" {
+			return Reject("file contains malware (%s)", threat)
+		}
+		return nil
+	})
+}
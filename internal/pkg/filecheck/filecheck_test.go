@@ -0,0 +1,94 @@
+package filecheck_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/pkg/filecheck"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, w, h))))
+	return buf.Bytes()
+}
+
+type fakeScanner struct {
+	threat string
+	err    error
+}
+
+func (s fakeScanner) Scan(ctx context.Context, r io.Reader) (string, error) {
+	_, _ = io.Copy(io.Discard, r)
+	return s.threat, s.err
+}
+
+func TestDetectType(t *testing.T) {
+	assert.Equal(t, "image/png", filecheck.Bytes("photo.jpg", encodePNG(t, 1, 1)).Type)
+	assert.Equal(t, "text/plain", filecheck.Bytes("a.csv", []byte("a,b\n1,2\n")).Type)
+	assert.Equal(t, "application/pdf", filecheck.Bytes("a", []byte("%PDF-1.7")).Type)
+	assert.Equal(t, "application/octet-stream", filecheck.Bytes("a.exe", []byte{0x4d, 0x5a, 0x90, 0x00, 0x03}).Type)
+}
+
+func TestPipeline(t *testing.T) {
+	ctx := context.Background()
+	pipeline := filecheck.New(
+		filecheck.Extensions("PNG", ".pdf"),
+		filecheck.Types("image/*", "application/pdf"),
+		filecheck.MaxDimensions(100, 50),
+		filecheck.Scan(fakeScanner{}),
+	)
+
+	tests := []struct {
+		name    string
+		content *filecheck.Content
+		reason  string
+	}{
+		{name: "Accepted", content: filecheck.Bytes("a.PNG", encodePNG(t, 100, 50))},
+		{name: "Extension", content: filecheck.Bytes("a.exe", encodePNG(t, 1, 1)), reason: `file extension ".exe" is not allowed`},
+		{name: "DetectedType", content: filecheck.Bytes("a.pdf", []byte("<html></html>")), reason: "file type text/html is not allowed"},
+		{name: "Dimensions", content: filecheck.Bytes("a.png", encodePNG(t, 100, 51)), reason: "image must not exceed 100x50 pixels"},
+		{name: "CorruptImage", content: filecheck.Bytes("a.png", encodePNG(t, 1, 1)[:20]), reason: "image is invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := pipeline.Run(ctx, tt.content)
+
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+			rejection, ok := filecheck.AsRejection(err)
+			require.True(t, ok, "got %v", err)
+			assert.Equal(t, tt.reason, rejection.Reason)
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	ctx := context.Background()
+	content := filecheck.Bytes("a.txt", []byte("hello"))
+
+	err := filecheck.New(filecheck.Scan(fakeScanner{threat: "Eicar-Test-Signature"})).Run(ctx, content)
+	rejection, ok := filecheck.AsRejection(err)
+	require.True(t, ok)
+	assert.True(t, strings.Contains(rejection.Reason, "Eicar-Test-Signature"))
+
+	// A scanner failure is not a rejection, the content was not checked
+	err = filecheck.New(filecheck.Scan(fakeScanner{err: errors.New("connection refused")})).Run(ctx, content)
+	require.Error(t, err)
+	_, ok = filecheck.AsRejection(err)
+	assert.False(t, ok)
+
+	var nilPipeline *filecheck.Pipeline
+	assert.NoError(t, nilPipeline.Run(ctx, content))
+	assert.NoError(t, filecheck.New(filecheck.Scan(nil), filecheck.Extensions(), filecheck.Types()).Run(ctx, content))
+}
@@ -9,6 +9,9 @@ import (
 
 var secretKey []byte
 
+// TokenTTL is how long an issued token stays valid.
+const TokenTTL = 24 * time.Hour
+
 func SetSecret(secret string) {
 	secretKey = []byte(secret)
 }
@@ -28,14 +31,21 @@ func GenerateToken(userID string, email string, name string, roles []string, key
 		kcToken = keycloakToken[0]
 	}
 
+	return GenerateSessionToken("", userID, email, name, roles, kcToken)
+}
+
+// GenerateSessionToken issues a token bound to a local session. The session ID is
+// stored in the "jti" claim so the token can be revoked server side.
+func GenerateSessionToken(sessionID string, userID string, email string, name string, roles []string, keycloakToken string) (string, error) {
 	claims := &Claims{
 		UserID:        userID,
 		Email:         email,
 		Name:          name,
 		Roles:         roles,
-		KeycloakToken: kcToken,
+		KeycloakToken: keycloakToken,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        sessionID,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(TokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
@@ -0,0 +1,125 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"golang.org/x/oauth2"
+)
+
+// githubEmailsURL lists the addresses of a GitHub user with their verification state.
+const githubEmailsURL = "https://api.github.com/user/emails"
+
+// OAuth2Provider is a provider without OIDC support. The identity is read from
+// its userinfo endpoint.
+type OAuth2Provider struct {
+	name        string
+	config      oauth2.Config
+	userInfoURL string
+	emailsURL   string
+	trustEmail  bool
+}
+
+func (p *OAuth2Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL ignores the nonce, plain OAuth2 has no ID token to carry it.
+func (p *OAuth2Provider) AuthCodeURL(state, _ string) string {
+	return p.config.AuthCodeURL(state)
+}
+
+func (p *OAuth2Provider) Exchange(ctx context.Context, code, _ string) (*UserInfo, error) {
+	token, err := p.config.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+	client := p.config.Client(ctx, token)
+
+	var claims map[string]interface{}
+	if err := getJSON(ctx, client, p.userInfoURL, &claims); err != nil {
+		return nil, err
+	}
+
+	info := &UserInfo{
+		Subject:       stringClaim(claims, "sub", "id"),
+		Email:         stringClaim(claims, "email"),
+		EmailVerified: isTrue(claims["email_verified"]) || isTrue(claims["verified_email"]),
+		Name:          stringClaim(claims, "name", "login"),
+		AccessToken:   token.AccessToken,
+	}
+
+	if p.emailsURL != "" {
+		if err := p.primaryEmail(ctx, client, info); err != nil {
+			return nil, err
+		}
+	}
+
+	info.EmailVerified = info.EmailVerified || p.trustEmail
+	if info.Subject == "" {
+		return nil, fmt.Errorf("%s userinfo has no subject", p.name)
+	}
+	return info, nil
+}
+
+// primaryEmail replaces the profile email with the primary verified address,
+// GitHub only returns the public one (if any) from /user.
+func (p *OAuth2Provider) primaryEmail(ctx context.Context, client *http.Client, info *UserInfo) error {
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := getJSON(ctx, client, p.emailsURL, &emails); err != nil {
+		return err
+	}
+
+	for _, e := range emails {
+		if e.Primary && e.Verified {
+			info.Email = e.Email
+			info.EmailVerified = true
+			return nil
+		}
+	}
+	return nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GET %s returned %s: %s", url, resp.Status, string(b))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// stringClaim returns the first non-empty claim of keys. Numeric IDs (GitHub)
+// are formatted without exponent.
+func stringClaim(claims map[string]interface{}, keys ...string) string {
+	for _, key := range keys {
+		switch v := claims[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
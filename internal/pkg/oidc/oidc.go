@@ -2,6 +2,8 @@ package oidc
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/afandimsr/go-gin-api/internal/config"
@@ -14,29 +16,140 @@ type OIDCProvider struct {
 	IssuerURL    string
 	OAuth2Config oauth2.Config
 	Verifier     *oidc.IDTokenVerifier
+
+	name       string
+	trustEmail bool
 }
 
+// NewOIDCProvider creates the Keycloak realm provider, registered as "keycloak".
 func NewOIDCProvider(ctx context.Context, cfg config.KeycloakConfig, redirectURL string) (*OIDCProvider, error) {
 	issuer := fmt.Sprintf("%s/realms/%s", cfg.URL, cfg.Realm)
+	p, err := newOIDCProvider(ctx, KeycloakProviderName, issuer, cfg.ClientID, cfg.ClientSecret, redirectURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keycloak is our own identity provider, its emails are trusted for account linking
+	p.trustEmail = true
+	return p, nil
+}
+
+func newOIDCProvider(ctx context.Context, name, issuer, clientID, clientSecret, redirectURL string, scopes []string) (*OIDCProvider, error) {
 	provider, err := oidc.NewProvider(ctx, issuer)
 	if err != nil {
 		return nil, err
 	}
 
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "profile", "email"}
+	}
+
 	oauth2Config := oauth2.Config{
-		ClientID:     cfg.ClientID,
-		ClientSecret: cfg.ClientSecret,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
 		Endpoint:     provider.Endpoint(),
 		RedirectURL:  redirectURL,
-		Scopes:       []string{oidc.ScopeOpenID, "profile", "email"},
+		Scopes:       scopes,
 	}
 
-	verifier := provider.Verifier(&oidc.Config{ClientID: cfg.ClientID})
+	verifier := provider.Verifier(&oidc.Config{ClientID: clientID})
 
 	return &OIDCProvider{
 		Provider:     provider,
 		IssuerURL:    issuer,
 		OAuth2Config: oauth2Config,
 		Verifier:     verifier,
+		name:         name,
+	}, nil
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.name
+}
+
+func (p *OIDCProvider) AuthCodeURL(state, nonce string) string {
+	return p.OAuth2Config.AuthCodeURL(state, oidc.Nonce(nonce))
+}
+
+// Exchange redeems the authorization code and verifies the returned ID token,
+// including the nonce sent with the authorization request.
+func (p *OIDCProvider) Exchange(ctx context.Context, code, nonce string) (*UserInfo, error) {
+	oauth2Token, err := p.OAuth2Config.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("token exchange failed: %w", err)
+	}
+
+	rawIDToken, ok := oauth2Token.Extra("id_token").(string)
+	if !ok {
+		return nil, errors.New("no id_token in token response")
+	}
+
+	idToken, err := p.Verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+
+	var claims struct {
+		Email         string      `json:"email"`
+		EmailVerified interface{} `json:"email_verified"`
+		Name          string      `json:"name"`
+		SessionID     string      `json:"sid"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &UserInfo{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: p.trustEmail || isTrue(claims.EmailVerified),
+		Name:          claims.Name,
+		AccessToken:   oauth2Token.AccessToken,
+		SessionID:     claims.SessionID,
+	}, nil
+}
+
+// backchannelLogoutEvent is the event type required in OIDC back-channel logout tokens.
+const backchannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+
+// LogoutClaims identifies the user and/or session ended by the provider.
+type LogoutClaims struct {
+	Subject   string
+	SessionID string
+}
+
+// VerifyLogoutToken validates a back-channel logout token as described in
+// OpenID Connect Back-Channel Logout 1.0, section 2.6.
+func (p *OIDCProvider) VerifyLogoutToken(ctx context.Context, rawToken string) (*LogoutClaims, error) {
+	token, err := p.Verifier.Verify(ctx, rawToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims struct {
+		SessionID string                     `json:"sid"`
+		Nonce     *string                    `json:"nonce"`
+		Events    map[string]json.RawMessage `json:"events"`
+	}
+	if err := token.Claims(&claims); err != nil {
+		return nil, err
+	}
+
+	if _, ok := claims.Events[backchannelLogoutEvent]; !ok {
+		return nil, errors.New("logout token is missing the back-channel logout event")
+	}
+	if claims.Nonce != nil {
+		return nil, errors.New("logout token must not contain a nonce")
+	}
+	if token.Subject == "" && claims.SessionID == "" {
+		return nil, errors.New("logout token must contain sub or sid")
+	}
+
+	return &LogoutClaims{
+		Subject:   token.Subject,
+		SessionID: claims.SessionID,
 	}, nil
 }
@@ -0,0 +1,139 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/afandimsr/go-gin-api/internal/config"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/endpoints"
+)
+
+const KeycloakProviderName = "keycloak"
+
+// ErrInvalidIDToken is returned when the ID token signature, audience or nonce is invalid.
+var ErrInvalidIDToken = errors.New("invalid id token")
+
+// UserInfo is the identity returned by a provider after a successful login.
+type UserInfo struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	AccessToken   string
+	SessionID     string
+}
+
+// SocialProvider is an OIDC or OAuth2 provider usable for the authorization code flow.
+type SocialProvider interface {
+	Name() string
+	AuthCodeURL(state, nonce string) string
+	Exchange(ctx context.Context, code, nonce string) (*UserInfo, error)
+}
+
+// Registry holds the social login providers by name.
+type Registry struct {
+	providers map[string]SocialProvider
+}
+
+func NewRegistry() *Registry {
+	return &Registry{providers: make(map[string]SocialProvider)}
+}
+
+func (r *Registry) Register(p SocialProvider) {
+	r.providers[p.Name()] = p
+}
+
+func (r *Registry) Get(name string) (SocialProvider, bool) {
+	p, ok := r.providers[name]
+	return p, ok
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// presets fill in the endpoints of well-known providers.
+var presets = map[string]config.OAuthProviderConfig{
+	"google": {
+		IssuerURL: "https://accounts.google.com",
+	},
+	"github": {
+		AuthURL:     endpoints.GitHub.AuthURL,
+		TokenURL:    endpoints.GitHub.TokenURL,
+		UserInfoURL: "https://api.github.com/user",
+		Scopes:      []string{"read:user", "user:email"},
+	},
+}
+
+// NewProvider creates the provider called name. Providers with an issuer URL use
+// OIDC discovery, the others plain OAuth2 with a userinfo endpoint.
+func NewProvider(ctx context.Context, name string, cfg config.OAuthProviderConfig, redirectURL string) (SocialProvider, error) {
+	if preset, ok := presets[name]; ok {
+		cfg = withPreset(cfg, preset)
+	}
+
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("oauth provider %s: client id is required", name)
+	}
+
+	if cfg.IssuerURL != "" {
+		p, err := newOIDCProvider(ctx, name, cfg.IssuerURL, cfg.ClientID, cfg.ClientSecret, redirectURL, cfg.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		p.trustEmail = cfg.TrustEmail
+		return p, nil
+	}
+
+	if cfg.AuthURL == "" || cfg.TokenURL == "" || cfg.UserInfoURL == "" {
+		return nil, fmt.Errorf("oauth provider %s: issuer url or auth, token and userinfo urls are required", name)
+	}
+
+	p := &OAuth2Provider{
+		name: name,
+		config: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			Endpoint:     oauth2.Endpoint{AuthURL: cfg.AuthURL, TokenURL: cfg.TokenURL},
+			RedirectURL:  redirectURL,
+			Scopes:       cfg.Scopes,
+		},
+		userInfoURL: cfg.UserInfoURL,
+		trustEmail:  cfg.TrustEmail,
+	}
+	if name == "github" {
+		p.emailsURL = githubEmailsURL
+	}
+	return p, nil
+}
+
+func withPreset(cfg, preset config.OAuthProviderConfig) config.OAuthProviderConfig {
+	if cfg.IssuerURL == "" && cfg.AuthURL == "" {
+		cfg.IssuerURL = preset.IssuerURL
+		cfg.AuthURL = preset.AuthURL
+		cfg.TokenURL = preset.TokenURL
+		cfg.UserInfoURL = preset.UserInfoURL
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = preset.Scopes
+	}
+	return cfg
+}
+
+func isTrue(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return b == "true"
+	}
+	return false
+}
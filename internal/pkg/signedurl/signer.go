@@ -0,0 +1,95 @@
+// Package signedurl emulates S3 presigned URLs for the storage backends that
+// are served by the API itself.
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query parameters of a signed URL.
+const (
+	ParamExpires   = "expires"
+	ParamSignature = "signature"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrExpired          = errors.New("signed url has expired")
+)
+
+// Signer builds and verifies the URLs of the objects of the buckets served
+// under baseURL, as {baseURL}/{bucket}/{key}.
+type Signer struct {
+	secret  []byte
+	baseURL string
+	now     func() time.Time
+}
+
+func New(secret, baseURL string) *Signer {
+	return &Signer{
+		secret:  []byte(secret),
+		baseURL: strings.TrimRight(baseURL, "/"),
+		now:     time.Now,
+	}
+}
+
+// BucketURL returns the URL prefix of the objects of bucket.
+func (s *Signer) BucketURL(bucket string) string {
+	return s.baseURL + "/" + url.PathEscape(bucket)
+}
+
+// URL returns the unsigned URL of an object.
+func (s *Signer) URL(bucket, key string) string {
+	return s.BucketURL(bucket) + "/" + EscapeKey(key)
+}
+
+// Sign returns the URL of an object allowing method until expiry elapses.
+func (s *Signer) Sign(method, bucket, key string, expiry time.Duration) string {
+	expires := strconv.FormatInt(s.now().Add(expiry).Unix(), 10)
+
+	query := url.Values{}
+	query.Set(ParamExpires, expires)
+	query.Set(ParamSignature, s.signature(method, bucket, key, expires))
+	return s.URL(bucket, key) + "?" + query.Encode()
+}
+
+// Verify checks that query holds a valid, unexpired signature of method on
+// the object.
+func (s *Signer) Verify(method, bucket, key string, query url.Values) error {
+	expires := query.Get(ParamExpires)
+	expected := s.signature(method, bucket, key, expires)
+	if !hmac.Equal([]byte(query.Get(ParamSignature)), []byte(expected)) {
+		return ErrInvalidSignature
+	}
+
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if s.now().After(time.Unix(unix, 0)) {
+		return ErrExpired
+	}
+	return nil
+}
+
+func (s *Signer) signature(method, bucket, key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(strings.Join([]string{strings.ToUpper(method), bucket, key, expires}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// EscapeKey escapes every segment of an object key for use in a URL path.
+func EscapeKey(key string) string {
+	segments := strings.Split(strings.TrimLeft(key, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
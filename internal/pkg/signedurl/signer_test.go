@@ -0,0 +1,51 @@
+package signedurl_test
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signedQuery(t *testing.T, rawURL string) url.Values {
+	parsed, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return parsed.Query()
+}
+
+func TestSignAndVerify(t *testing.T) {
+	signer := signedurl.New("secret", "https://api.example.com/api/v1/storage/")
+
+	signed := signer.Sign(http.MethodGet, "private", "docs/annual report.pdf", time.Minute)
+
+	assert.True(t, strings.HasPrefix(signed, "https://api.example.com/api/v1/storage/private/docs/annual%20report.pdf?"))
+	query := signedQuery(t, signed)
+	assert.NoError(t, signer.Verify(http.MethodGet, "private", "docs/annual report.pdf", query))
+
+	assert.ErrorIs(t, signer.Verify(http.MethodPut, "private", "docs/annual report.pdf", query), signedurl.ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify(http.MethodGet, "public", "docs/annual report.pdf", query), signedurl.ErrInvalidSignature)
+	assert.ErrorIs(t, signer.Verify(http.MethodGet, "private", "docs/other.pdf", query), signedurl.ErrInvalidSignature)
+	assert.ErrorIs(t, signedurl.New("other", "").Verify(http.MethodGet, "private", "docs/annual report.pdf", query), signedurl.ErrInvalidSignature)
+
+	query.Set(signedurl.ParamExpires, "99999999999")
+	assert.ErrorIs(t, signer.Verify(http.MethodGet, "private", "docs/annual report.pdf", query), signedurl.ErrInvalidSignature)
+}
+
+func TestVerifyExpired(t *testing.T) {
+	signer := signedurl.New("secret", "/storage")
+
+	query := signedQuery(t, signer.Sign(http.MethodGet, "private", "a.txt", -time.Second))
+
+	assert.ErrorIs(t, signer.Verify(http.MethodGet, "private", "a.txt", query), signedurl.ErrExpired)
+}
+
+func TestVerifyMissingSignature(t *testing.T) {
+	signer := signedurl.New("secret", "/storage")
+
+	assert.ErrorIs(t, signer.Verify(http.MethodGet, "private", "a.txt", url.Values{}), signedurl.ErrInvalidSignature)
+}
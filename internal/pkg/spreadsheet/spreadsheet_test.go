@@ -0,0 +1,77 @@
+package spreadsheet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestFormatFromFilename(t *testing.T) {
+	format, err := FormatFromFilename("users.CSV")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	format, err = FormatFromFilename("users.xlsx")
+	assert.NoError(t, err)
+	assert.Equal(t, FormatXLSX, format)
+
+	_, err = FormatFromFilename("users.xls")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestReadAllCSV(t *testing.T) {
+	rows, err := ReadAll(strings.NewReader("\ufeffname,email\nJane, jane@example.com\nJohn\n"), FormatCSV)
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"name", "email"},
+		{"Jane", "jane@example.com"},
+		{"John"},
+	}, rows)
+}
+
+func TestReadAllXLSX(t *testing.T) {
+	f := excelize.NewFile()
+	assert.NoError(t, f.SetSheetRow("Sheet1", "A1", &[]interface{}{"name", "email"}))
+	assert.NoError(t, f.SetSheetRow("Sheet1", "A2", &[]interface{}{"Jane", "jane@example.com"}))
+	var buf bytes.Buffer
+	assert.NoError(t, f.Write(&buf))
+
+	rows, err := ReadAll(&buf, FormatXLSX)
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"name", "email"},
+		{"Jane", "jane@example.com"},
+	}, rows)
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	rows := [][]string{
+		{"name", "email"},
+		{"Jane", "jane@example.com"},
+		{"=HYPERLINK(\"x\")", "john@example.com"},
+	}
+
+	for _, format := range []string{FormatCSV, FormatXLSX} {
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, format)
+		assert.NoError(t, err)
+		for _, row := range rows {
+			assert.NoError(t, w.Write(row))
+		}
+		assert.NoError(t, w.Close())
+
+		got, err := ReadAll(&buf, format)
+		assert.NoError(t, err)
+		assert.Equal(t, rows[:2], got[:2], format)
+		if format == FormatCSV {
+			assert.Equal(t, "'=HYPERLINK(\"x\")", got[2][0])
+		} else {
+			assert.Equal(t, rows[2], got[2])
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package thumbnail validates uploaded images by their content and renders
+// square, resized variants of them.
+package thumbnail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	// Decoders of the accepted formats
+	_ "image/gif"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Content types of the accepted and produced images.
+const (
+	ContentTypeJPEG = "image/jpeg"
+	ContentTypePNG  = "image/png"
+	ContentTypeGIF  = "image/gif"
+	ContentTypeWebP = "image/webp"
+)
+
+// MaxPixels bounds the decoded size of an image, a small file can declare
+// huge dimensions and exhaust memory once decoded.
+const MaxPixels = 25_000_000
+
+var (
+	ErrUnsupportedImage = errors.New("unsupported image type, expected JPEG, PNG, GIF or WebP")
+	ErrImageTooLarge    = errors.New("image dimensions are too large")
+)
+
+// Sniff returns the content type of data detected from its first bytes, the
+// file name and the declared type are not trusted.
+func Sniff(data []byte) (string, error) {
+	switch contentType := http.DetectContentType(data); contentType {
+	case ContentTypeJPEG, ContentTypePNG, ContentTypeGIF, ContentTypeWebP:
+		return contentType, nil
+	}
+	return "", ErrUnsupportedImage
+}
+
+// Squares decodes data and renders it center-cropped to a square and scaled
+// to each of sizes. Variants are encoded as JPEG for JPEG sources and as PNG
+// otherwise, to keep transparency; the content type of the variants is returned.
+// Only the first frame of animated images is kept.
+func Squares(data []byte, sizes []int) (map[int][]byte, string, error) {
+	contentType, err := Sniff(data)
+	if err != nil {
+		return nil, "", err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, "", ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrUnsupportedImage, err)
+	}
+	square := centerSquare(src.Bounds())
+
+	outputType := ContentTypePNG
+	if contentType == ContentTypeJPEG {
+		outputType = ContentTypeJPEG
+	}
+
+	variants := make(map[int][]byte, len(sizes))
+	for _, size := range sizes {
+		dst := image.NewRGBA(image.Rect(0, 0, size, size))
+		draw.CatmullRom.Scale(dst, dst.Bounds(), src, square, draw.Src, nil)
+
+		var buf bytes.Buffer
+		if outputType == ContentTypeJPEG {
+			err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+		} else {
+			err = png.Encode(&buf, dst)
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		variants[size] = buf.Bytes()
+	}
+	return variants, outputType, nil
+}
+
+// Extension returns the file extension of a content type produced by Squares.
+func Extension(contentType string) string {
+	if contentType == ContentTypeJPEG {
+		return "jpg"
+	}
+	return "png"
+}
+
+func centerSquare(b image.Rectangle) image.Rectangle {
+	side := min(b.Dx(), b.Dy())
+	x := b.Min.X + (b.Dx()-side)/2
+	y := b.Min.Y + (b.Dy()-side)/2
+	return image.Rect(x, y, x+side, y+side)
+}
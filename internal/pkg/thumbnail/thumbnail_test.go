@@ -0,0 +1,75 @@
+package thumbnail_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/pkg/thumbnail"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	img.Set(0, 0, color.NRGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestSniff(t *testing.T) {
+	contentType, err := thumbnail.Sniff(encodePNG(t, 1, 1))
+	assert.NoError(t, err)
+	assert.Equal(t, thumbnail.ContentTypePNG, contentType)
+
+	// An HTML file named avatar.png must not be accepted
+	_, err = thumbnail.Sniff([]byte("<html><script>alert(1)</script></html>"))
+	assert.ErrorIs(t, err, thumbnail.ErrUnsupportedImage)
+}
+
+func TestSquares(t *testing.T) {
+	t.Run("PNG", func(t *testing.T) {
+		variants, contentType, err := thumbnail.Squares(encodePNG(t, 300, 200), []int{128, 32})
+		require.NoError(t, err)
+		assert.Equal(t, thumbnail.ContentTypePNG, contentType)
+
+		for _, size := range []int{128, 32} {
+			cfg, err := png.DecodeConfig(bytes.NewReader(variants[size]))
+			require.NoError(t, err)
+			assert.Equal(t, size, cfg.Width)
+			assert.Equal(t, size, cfg.Height)
+		}
+	})
+
+	t.Run("JPEG", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, jpeg.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 64, 64)), nil))
+
+		variants, contentType, err := thumbnail.Squares(buf.Bytes(), []int{16})
+		require.NoError(t, err)
+		assert.Equal(t, thumbnail.ContentTypeJPEG, contentType)
+		assert.Equal(t, "jpg", thumbnail.Extension(contentType))
+
+		_, err = jpeg.Decode(bytes.NewReader(variants[16]))
+		assert.NoError(t, err)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		// Declare 10000x10000 pixels in the IHDR chunk of a tiny PNG
+		data := encodePNG(t, 1, 1)
+		ihdr := bytes.Index(data, []byte("IHDR"))
+		binary.BigEndian.PutUint32(data[ihdr+4:], 10000)
+		binary.BigEndian.PutUint32(data[ihdr+8:], 10000)
+		binary.BigEndian.PutUint32(data[ihdr+17:], crc32.ChecksumIEEE(data[ihdr:ihdr+17]))
+
+		_, _, err := thumbnail.Squares(data, []int{16})
+		assert.ErrorIs(t, err, thumbnail.ErrImageTooLarge)
+	})
+}
@@ -97,3 +97,45 @@ func SeedAdminUserMysql(db *sql.DB) {
 
 	log.Printf("Admin user seeded: id=%s, email=%s", id, email)
 }
+
+func SeedAdminUserSQLServer(db *sql.DB) {
+	id := uuid.NewString()
+	name := "Admin"
+	email := "admin@example.com"
+	password := "admin123"
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Fatalf("failed to hash password: %v", err)
+	}
+
+	var userID string
+	// Check if user exists
+	err = db.QueryRow("SELECT id FROM users WHERE email = @p1", email).Scan(&userID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			// Insert user
+			err = db.QueryRow("INSERT INTO users(id,name, email, password, is_active) OUTPUT INSERTED.id VALUES(@p1, @p2, @p3, @p4, @p5)", id, name, email, string(hashed), true).Scan(&userID)
+			if err != nil {
+				log.Fatalf("failed to insert user: %v", err)
+			}
+		} else {
+			log.Fatalf("failed to query user: %v", err)
+		}
+	}
+
+	// Find ADMIN role id
+	var roleID string
+	err = db.QueryRow("SELECT id FROM roles WHERE name = @p1", "ADMIN").Scan(&roleID)
+	if err != nil {
+		log.Fatalf("failed to find ADMIN role: %v", err)
+	}
+
+	// Insert into user_roles
+	_, err = db.Exec("IF NOT EXISTS (SELECT 1 FROM user_roles WHERE user_id = @p1 AND role_id = @p2) INSERT INTO user_roles(user_id, role_id) VALUES(@p1, @p2)", userID, roleID)
+	if err != nil {
+		log.Fatalf("failed to insert user_roles: %v", err)
+	}
+
+	log.Printf("Admin user seeded: id=%s, email=%s", userID, email)
+}
@@ -0,0 +1,199 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/pkg/filecheck"
+	"github.com/google/uuid"
+)
+
+// MaxNameLength bounds the length of a file name, longer names are truncated.
+const MaxNameLength = 255
+
+// Options controls file uploads and downloads.
+type Options struct {
+	// MaxSize bounds the size of an uploaded file in bytes
+	MaxSize int64
+	// ReadRoles may download the files of every user
+	ReadRoles []string
+	// URLExpiry is the lifetime of a download URL
+	URLExpiry time.Duration
+	// MaxUploadSize bounds the size of a file uploaded in parts in bytes
+	MaxUploadSize int64
+	// PartSize is the size of the parts of an upload session, the last part
+	// excepted
+	PartSize int64
+	// SessionTTL is the time an upload session may take to complete
+	SessionTTL time.Duration
+	// Quota bounds the bytes stored by a user without a custom quota, 0 is
+	// unlimited
+	Quota int64
+}
+
+type Usecase struct {
+	repo       file.Repository
+	sessions   file.UploadSessionRepository
+	usage      file.UsageRepository
+	storage    storage.IS3Service
+	checks     *filecheck.Pipeline
+	quarantine file.Quarantine
+	opts       Options
+}
+
+func New(repo file.Repository, sessions file.UploadSessionRepository, usage file.UsageRepository, store storage.IS3Service, checks *filecheck.Pipeline, quarantine file.Quarantine, opts Options) *Usecase {
+	if opts.PartSize < storage.MinPartSize {
+		opts.PartSize = DefaultPartSize
+	}
+	if opts.SessionTTL <= 0 {
+		opts.SessionTTL = DefaultSessionTTL
+	}
+	return &Usecase{
+		repo:       repo,
+		sessions:   sessions,
+		usage:      usage,
+		storage:    store,
+		checks:     checks,
+		quarantine: quarantine,
+		opts:       opts,
+	}
+}
+
+// StorageKey returns the key of the content of a file in the private bucket.
+func StorageKey(ownerID, fileID string) string {
+	return fmt.Sprintf("files/%s/%s", ownerID, fileID)
+}
+
+// Upload stores body, of size bytes, as a new file owned by the current user.
+// The content type is sniffed when the client did not send a specific one.
+func (u *Usecase) Upload(ctx context.Context, name, contentType string, size int64, body io.Reader) (file.File, error) {
+	owner := user.ActorFromContext(ctx)
+	if owner == "" {
+		return file.File{}, apperror.Unauthorized("authentication required", nil)
+	}
+	if u.opts.MaxSize > 0 && size > u.opts.MaxSize {
+		return file.File{}, apperror.NewAppError(http.StatusRequestEntityTooLarge, apperror.FileTooLarge,
+			fmt.Sprintf("file must not exceed %d bytes", u.opts.MaxSize), nil)
+	}
+
+	name = cleanName(name)
+	if name == "" {
+		return file.File{}, apperror.BadRequest("file name is required", nil).WithCode(apperror.ValidationError)
+	}
+	// Held until the file is saved so concurrent uploads see these bytes
+	release, err := u.reserveQuota(owner, size)
+	if err != nil {
+		return file.File{}, err
+	}
+	defer release()
+
+	reader := bufio.NewReaderSize(body, 512)
+	if contentType == "" || contentType == "application/octet-stream" {
+		head, _ := reader.Peek(512)
+		contentType = http.DetectContentType(head)
+	}
+
+	f := file.File{
+		ID:          uuid.New().String(),
+		OwnerID:     owner,
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+	}
+	f.StorageKey = StorageKey(owner, f.ID)
+
+	hash := sha256.New()
+	err = u.storage.Upload(ctx, f.StorageKey, io.TeeReader(reader, hash),
+		storage.WithContentType(contentType),
+		storage.WithMetadata(map[string]string{"owner": owner}))
+	if err != nil {
+		return file.File{}, apperror.Internal(err)
+	}
+	f.Checksum = hex.EncodeToString(hash.Sum(nil))
+
+	// Do not leave an object no file refers to
+	if err := u.inspect(ctx, f); err != nil {
+		u.deleteObject(ctx, f.StorageKey)
+		return file.File{}, err
+	}
+	if err := u.repo.Save(f); err != nil {
+		u.deleteObject(ctx, f.StorageKey)
+		return file.File{}, apperror.Internal(err)
+	}
+
+	return u.find(f.ID)
+}
+
+// Get returns a file with a short-lived download URL. Only its owner and the
+// users having one of the read roles may download it.
+func (u *Usecase) Get(ctx context.Context, id string) (file.Download, error) {
+	f, err := u.find(id)
+	if err != nil {
+		return file.Download{}, err
+	}
+
+	if !u.canRead(ctx, f) {
+		return file.Download{}, apperror.NewForbiddenError("you are not allowed to access this file").
+			WithCode(apperror.PermissionDenied)
+	}
+
+	url, err := u.storage.PresignGet(ctx, f.StorageKey, u.opts.URLExpiry)
+	if err != nil {
+		return file.Download{}, apperror.Internal(err)
+	}
+
+	return file.Download{
+		File:      f,
+		URL:       url,
+		ExpiresAt: time.Now().Add(u.opts.URLExpiry).UTC().Truncate(time.Second),
+	}, nil
+}
+
+func (u *Usecase) find(id string) (file.File, error) {
+	f, err := u.repo.FindByID(id)
+	if err != nil {
+		if errors.Is(err, file.ErrFileNotFound) {
+			return file.File{}, apperror.NotFound("File tidak ditemukan", err).WithCode(apperror.FileNotFound)
+		}
+		return file.File{}, apperror.Internal(err)
+	}
+	return f, nil
+}
+
+func (u *Usecase) canRead(ctx context.Context, f file.File) bool {
+	if actor := user.ActorFromContext(ctx); actor != "" && actor == f.OwnerID {
+		return true
+	}
+	for _, role := range user.ActorRolesFromContext(ctx) {
+		if slices.Contains(u.opts.ReadRoles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanName keeps the base name of a client-provided file name.
+func cleanName(name string) string {
+	name = strings.TrimSpace(path.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "." || name == "/" {
+		return ""
+	}
+	if runes := []rune(name); len(runes) > MaxNameLength {
+		name = string(runes[:MaxNameLength])
+	}
+	return name
+}
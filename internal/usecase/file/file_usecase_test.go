@@ -0,0 +1,191 @@
+package file_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/memory"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/quarantine"
+	"github.com/afandimsr/go-gin-api/internal/pkg/filecheck"
+	"github.com/afandimsr/go-gin-api/internal/pkg/signedurl"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Save(f file.File) error {
+	args := m.Called(f)
+	return args.Error(0)
+}
+
+func (m *MockRepository) FindByID(id string) (file.File, error) {
+	args := m.Called(id)
+	return args.Get(0).(file.File), args.Error(1)
+}
+
+var testOptions = uc.Options{MaxSize: 1 << 10, ReadRoles: []string{"ADMIN"}, URLExpiry: 5 * time.Minute}
+
+func newStorage() *memory.Storage {
+	return memory.New("private", "", signedurl.New("secret", "http://localhost/api/v1/storage"))
+}
+
+func appError(t *testing.T, err error) *apperror.AppError {
+	var appErr *apperror.AppError
+	require.True(t, errors.As(err, &appErr), "got %v", err)
+	return appErr
+}
+
+func TestUpload(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "owner-1")
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		store := newStorage()
+		usecase := uc.New(mockRepo, new(MockUploadSessionRepository), noQuota(), store, nil, nil, testOptions)
+
+		var saved file.File
+		mockRepo.On("Save", mock.MatchedBy(func(f file.File) bool {
+			saved = f
+			return f.OwnerID == "owner-1" && f.Name == "report.txt" && f.ContentType == "text/plain; charset=utf-8" &&
+				f.Size == 5 && f.StorageKey == uc.StorageKey("owner-1", f.ID) &&
+				f.Checksum == "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"
+		})).Return(nil).Once()
+		mockRepo.On("FindByID", mock.Anything).Return(file.File{ID: "1"}, nil).Once()
+
+		_, err := usecase.Upload(ctx, `C:\Users\me\report.txt`, "", 5, strings.NewReader("hello"))
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		body, obj, err := store.Download(ctx, saved.StorageKey)
+		require.NoError(t, err)
+		data, _ := io.ReadAll(body)
+		assert.Equal(t, "hello", string(data))
+		assert.Equal(t, "owner-1", obj.Metadata["owner"])
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), noQuota(), newStorage(), nil, nil, testOptions)
+
+		_, err := usecase.Upload(ctx, "big.bin", "", testOptions.MaxSize+1, strings.NewReader(""))
+
+		appErr := appError(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, appErr.Code)
+		assert.Equal(t, apperror.FileTooLarge, appErr.ErrorCode)
+	})
+
+	t.Run("SaveFailsRemovesObject", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		store := newStorage()
+		usecase := uc.New(mockRepo, new(MockUploadSessionRepository), noQuota(), store, nil, nil, testOptions)
+
+		mockRepo.On("Save", mock.Anything).Return(errors.New("db down")).Once()
+
+		_, err := usecase.Upload(ctx, "a.txt", "text/plain", 1, strings.NewReader("a"))
+
+		assert.Error(t, err)
+		page, _ := store.List(ctx, "", "", 0)
+		assert.Empty(t, page.Objects)
+	})
+
+	t.Run("RejectedIsQuarantined", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		store := newStorage()
+		checks := filecheck.New(filecheck.Extensions(".pdf"), filecheck.Types("application/pdf"))
+		usecase := uc.New(mockRepo, new(MockUploadSessionRepository), noQuota(), store, checks, quarantine.New(store), testOptions)
+
+		_, err := usecase.Upload(ctx, "invoice.pdf", "application/pdf", 13, strings.NewReader("<html></html>"))
+
+		appErr := appError(t, err)
+		assert.Equal(t, http.StatusUnprocessableEntity, appErr.Code)
+		assert.Equal(t, apperror.FileRejected, appErr.ErrorCode)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+		page, _ := store.List(ctx, "", "", 0)
+		require.Len(t, page.Objects, 1)
+		assert.True(t, strings.HasPrefix(page.Objects[0].Key, quarantine.Prefix))
+		assert.Equal(t, url.QueryEscape("file type text/html is not allowed"), page.Objects[0].Metadata["reason"])
+	})
+
+	t.Run("CheckFailureRemovesObject", func(t *testing.T) {
+		store := newStorage()
+		checks := filecheck.New(filecheck.CheckFunc(func(ctx context.Context, c *filecheck.Content) error {
+			return errors.New("scanner unavailable")
+		}))
+		usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), noQuota(), store, checks, quarantine.New(store), testOptions)
+
+		_, err := usecase.Upload(ctx, "a.txt", "text/plain", 1, strings.NewReader("a"))
+
+		assert.Equal(t, http.StatusInternalServerError, appError(t, err).Code)
+		page, _ := store.List(ctx, "", "", 0)
+		assert.Empty(t, page.Objects)
+	})
+
+	t.Run("Anonymous", func(t *testing.T) {
+		usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), noQuota(), newStorage(), nil, nil, testOptions)
+
+		_, err := usecase.Upload(context.Background(), "a.txt", "text/plain", 1, strings.NewReader("a"))
+
+		assert.Equal(t, http.StatusUnauthorized, appError(t, err).Code)
+	})
+}
+
+func TestGet(t *testing.T) {
+	stored := file.File{ID: "1", OwnerID: "owner-1", Name: "a.txt", StorageKey: "files/owner-1/1"}
+
+	tests := []struct {
+		name    string
+		actor   string
+		roles   []string
+		allowed bool
+	}{
+		{name: "Owner", actor: "owner-1", roles: []string{"USER"}, allowed: true},
+		{name: "ReadRole", actor: "admin-1", roles: []string{"ADMIN"}, allowed: true},
+		{name: "OtherUser", actor: "user-2", roles: []string{"USER"}, allowed: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			usecase := uc.New(mockRepo, new(MockUploadSessionRepository), noQuota(), newStorage(), nil, nil, testOptions)
+			ctx := user.WithActorRoles(user.WithActor(context.Background(), tt.actor), tt.roles)
+
+			mockRepo.On("FindByID", "1").Return(stored, nil).Once()
+
+			download, err := usecase.Get(ctx, "1")
+
+			if !tt.allowed {
+				assert.Equal(t, http.StatusForbidden, appError(t, err).Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "1", download.ID)
+			assert.Contains(t, download.URL, "/private/files/owner-1/1?expires=")
+			assert.WithinDuration(t, time.Now().Add(testOptions.URLExpiry), download.ExpiresAt, 2*time.Second)
+		})
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		usecase := uc.New(mockRepo, new(MockUploadSessionRepository), noQuota(), newStorage(), nil, nil, testOptions)
+
+		mockRepo.On("FindByID", "missing").Return(file.File{}, file.ErrFileNotFound).Once()
+
+		_, err := usecase.Get(user.WithActor(context.Background(), "owner-1"), "missing")
+
+		appErr := appError(t, err)
+		assert.Equal(t, http.StatusNotFound, appErr.Code)
+		assert.Equal(t, apperror.FileNotFound, appErr.ErrorCode)
+	})
+}
@@ -0,0 +1,74 @@
+package file
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/pkg/filecheck"
+)
+
+// inspect runs the stored content of f through the checks before any file
+// refers to it. Rejected content is copied to the quarantine; callers delete
+// the stored content on any error.
+func (u *Usecase) inspect(ctx context.Context, f file.File) error {
+	if u.checks == nil {
+		return nil
+	}
+
+	open := func() (io.ReadCloser, error) {
+		body, _, err := u.storage.Download(ctx, f.StorageKey)
+		return body, err
+	}
+	content, err := filecheck.NewContent(f.Name, f.Size, open)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+
+	err = u.checks.Run(ctx, content)
+	if err == nil {
+		return nil
+	}
+	rejection, ok := filecheck.AsRejection(err)
+	if !ok {
+		return apperror.Internal(err)
+	}
+
+	u.quarantineObject(ctx, f, content.Type, rejection.Reason, open)
+	return apperror.NewAppError(http.StatusUnprocessableEntity, apperror.FileRejected, rejection.Reason, rejection)
+}
+
+func (u *Usecase) quarantineObject(ctx context.Context, f file.File, contentType, reason string, open func() (io.ReadCloser, error)) {
+	if u.quarantine == nil {
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	body, err := open()
+	if err != nil {
+		log.Printf("[File] failed to quarantine %s: %v", f.StorageKey, err)
+		return
+	}
+	defer body.Close()
+
+	key, err := u.quarantine.Put(ctx, file.Rejected{
+		OwnerID:     f.OwnerID,
+		Name:        f.Name,
+		ContentType: contentType,
+		Reason:      reason,
+	}, body)
+	if err != nil {
+		log.Printf("[File] failed to quarantine %s: %v", f.StorageKey, err)
+		return
+	}
+	log.Printf("[File] Quarantined upload of %s as %s: %s", f.OwnerID, key, reason)
+}
+
+func (u *Usecase) deleteObject(ctx context.Context, key string) {
+	if err := u.storage.Delete(context.WithoutCancel(ctx), key); err != nil {
+		log.Printf("[File] failed to delete object %s: %v", key, err)
+	}
+}
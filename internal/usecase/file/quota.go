@@ -0,0 +1,108 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// StorageUsage returns the storage used by the current user against their
+// quota.
+func (u *Usecase) StorageUsage(ctx context.Context) (file.Usage, error) {
+	owner := user.ActorFromContext(ctx)
+	if owner == "" {
+		return file.Usage{}, apperror.Unauthorized("authentication required", nil)
+	}
+	return u.ownerUsage(owner)
+}
+
+// StorageReport returns a page of the users storing files, by bytes used,
+// and their total. Reserved bytes are not reported.
+func (u *Usecase) StorageReport(page, limit int) ([]file.Usage, int64, error) {
+	if page < 1 || limit < 1 {
+		return nil, 0, apperror.BadRequest("page and limit must be positive", nil).WithCode(apperror.ValidationError)
+	}
+
+	usages, total, err := u.usage.ListUsage(limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, apperror.Internal(err)
+	}
+	for i := range usages {
+		u.applyDefaultQuota(&usages[i])
+	}
+	return usages, total, nil
+}
+
+// SetQuota sets a custom quota of quotaBytes for a user, 0 being unlimited.
+func (u *Usecase) SetQuota(userID string, quotaBytes int64) (file.Usage, error) {
+	if quotaBytes < 0 {
+		return file.Usage{}, apperror.BadRequest("quota must not be negative", nil).WithCode(apperror.ValidationError)
+	}
+	if err := u.usage.SetQuota(userID, quotaBytes); err != nil {
+		if errors.Is(err, file.ErrOwnerNotFound) {
+			return file.Usage{}, apperror.NotFound("User tidak ditemukan", err).WithCode(apperror.UserNotFound)
+		}
+		return file.Usage{}, apperror.Internal(err)
+	}
+	return u.ownerUsage(userID)
+}
+
+// DeleteQuota restores the default quota of a user.
+func (u *Usecase) DeleteQuota(userID string) (file.Usage, error) {
+	if err := u.usage.DeleteQuota(userID); err != nil {
+		return file.Usage{}, apperror.Internal(err)
+	}
+	return u.ownerUsage(userID)
+}
+
+// reservationTTL bounds how long an upload that never released its
+// reservation, the process having stopped, keeps holding quota.
+const reservationTTL = time.Hour
+
+// reserveQuota reserves size more bytes for owner within their quota and
+// returns the function releasing them, to call once the bytes are held by a
+// file or an upload session or the upload failed.
+func (u *Usecase) reserveQuota(owner string, size int64) (func(), error) {
+	var usage file.Usage
+	now := time.Now()
+	id, err := u.usage.Reserve(owner, size, now, now.Add(reservationTTL), func(current file.Usage) bool {
+		u.applyDefaultQuota(&current)
+		usage = current
+		return !current.Exceeds(size)
+	})
+	if errors.Is(err, file.ErrQuotaExceeded) {
+		return nil, apperror.NewAppError(http.StatusForbidden, apperror.StorageQuotaExceeded,
+			fmt.Sprintf("storage quota exceeded: %d of %d bytes used", usage.UsedBytes+usage.ReservedBytes, usage.QuotaBytes), err)
+	}
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+
+	return func() {
+		if err := u.usage.Release(id); err != nil {
+			log.Printf("[File] failed to release storage reservation %s: %v", id, err)
+		}
+	}, nil
+}
+
+func (u *Usecase) ownerUsage(owner string) (file.Usage, error) {
+	usage, err := u.usage.Usage(owner, time.Now())
+	if err != nil {
+		return file.Usage{}, apperror.Internal(err)
+	}
+	u.applyDefaultQuota(&usage)
+	return usage, nil
+}
+
+func (u *Usecase) applyDefaultQuota(usage *file.Usage) {
+	if !usage.CustomQuota {
+		usage.QuotaBytes = u.opts.Quota
+	}
+}
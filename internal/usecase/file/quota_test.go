@@ -0,0 +1,224 @@
+package file_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockUsageRepository struct {
+	mock.Mock
+}
+
+func (m *MockUsageRepository) Usage(ownerID string, now time.Time) (file.Usage, error) {
+	args := m.Called(ownerID, now)
+	return args.Get(0).(file.Usage), args.Error(1)
+}
+
+// Reserve calls allow with the usage given to Return, reserving when allowed.
+func (m *MockUsageRepository) Reserve(ownerID string, size int64, now, expiresAt time.Time, allow func(file.Usage) bool) (string, error) {
+	args := m.Called(ownerID, size)
+	if err := args.Error(1); err != nil {
+		return "", err
+	}
+	if !allow(args.Get(0).(file.Usage)) {
+		return "", file.ErrQuotaExceeded
+	}
+	return "reservation-1", nil
+}
+
+func (m *MockUsageRepository) Release(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUsageRepository) ListUsage(limit, offset int) ([]file.Usage, int64, error) {
+	args := m.Called(limit, offset)
+	return args.Get(0).([]file.Usage), args.Get(1).(int64), args.Error(2)
+}
+
+func (m *MockUsageRepository) SetQuota(ownerID string, quotaBytes int64) error {
+	args := m.Called(ownerID, quotaBytes)
+	return args.Error(0)
+}
+
+func (m *MockUsageRepository) DeleteQuota(ownerID string) error {
+	args := m.Called(ownerID)
+	return args.Error(0)
+}
+
+// noQuota returns a usage repository of users storing nothing without a
+// custom quota.
+func noQuota() *MockUsageRepository {
+	usage := new(MockUsageRepository)
+	usage.On("Usage", mock.Anything, mock.Anything).Return(file.Usage{}, nil).Maybe()
+	usage.On("Reserve", mock.Anything, mock.Anything).Return(file.Usage{}, nil).Maybe()
+	usage.On("Release", mock.Anything).Return(nil).Maybe()
+	return usage
+}
+
+func TestUploadQuota(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "owner-1")
+	opts := testOptions
+	opts.Quota = 100
+
+	tests := []struct {
+		name     string
+		usage    file.Usage
+		exceeded bool
+	}{
+		{name: "WithinDefaultQuota", usage: file.Usage{UsedBytes: 90, ReservedBytes: 5}},
+		{name: "DefaultQuotaExceeded", usage: file.Usage{UsedBytes: 91, ReservedBytes: 5}, exceeded: true},
+		{name: "CustomQuota", usage: file.Usage{UsedBytes: 150, QuotaBytes: 200, CustomQuota: true}},
+		{name: "CustomQuotaExceeded", usage: file.Usage{UsedBytes: 10, QuotaBytes: 12, CustomQuota: true}, exceeded: true},
+		{name: "CustomUnlimited", usage: file.Usage{UsedBytes: 1000, CustomQuota: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usage := new(MockUsageRepository)
+			usage.On("Reserve", "owner-1", int64(5)).Return(tt.usage, nil).Once()
+			usage.On("Release", "reservation-1").Return(nil).Maybe()
+			mockRepo := new(MockRepository)
+			mockRepo.On("Save", mock.Anything).Return(nil).Maybe()
+			mockRepo.On("FindByID", mock.Anything).Return(file.File{}, nil).Maybe()
+			store := newStorage()
+			usecase := uc.New(mockRepo, new(MockUploadSessionRepository), usage, store, nil, nil, opts)
+
+			_, err := usecase.Upload(ctx, "notes.txt", "text/plain", 5, strings.NewReader("hello"))
+
+			if !tt.exceeded {
+				assert.NoError(t, err)
+				// Released once the file holds the bytes
+				usage.AssertCalled(t, "Release", "reservation-1")
+				return
+			}
+			appErr := appError(t, err)
+			assert.Equal(t, http.StatusForbidden, appErr.Code)
+			assert.Equal(t, apperror.StorageQuotaExceeded, appErr.ErrorCode)
+			page, _ := store.List(ctx, "", "", 0)
+			assert.Empty(t, page.Objects)
+			mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+		})
+	}
+}
+
+func TestInitiateUploadQuota(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "owner-1")
+	opts := sessionOptions
+	opts.Quota = int64(len(content))
+
+	usage := new(MockUsageRepository)
+	usage.On("Reserve", "owner-1", int64(len(content))).Return(file.Usage{ReservedBytes: 1}, nil).Once()
+	sessions := new(MockUploadSessionRepository)
+	usecase := uc.New(new(MockRepository), sessions, usage, newStorage(), nil, nil, opts)
+
+	_, err := usecase.InitiateUpload(ctx, "video.mp4", "video/mp4", int64(len(content)), "")
+
+	assert.Equal(t, apperror.StorageQuotaExceeded, appError(t, err).ErrorCode)
+	sessions.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestUploadQuotaReservationError(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "owner-1")
+
+	usage := new(MockUsageRepository)
+	usage.On("Reserve", "owner-1", int64(5)).Return(file.Usage{}, errors.New("lock wait timeout")).Once()
+	store := newStorage()
+	usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), usage, store, nil, nil, testOptions)
+
+	_, err := usecase.Upload(ctx, "notes.txt", "text/plain", 5, strings.NewReader("hello"))
+
+	assert.Equal(t, http.StatusInternalServerError, appError(t, err).Code)
+	page, _ := store.List(ctx, "", "", 0)
+	assert.Empty(t, page.Objects)
+	usage.AssertNotCalled(t, "Release", mock.Anything)
+}
+
+func TestStorageUsage(t *testing.T) {
+	opts := testOptions
+	opts.Quota = 100
+
+	usage := new(MockUsageRepository)
+	usage.On("Usage", "owner-1", mock.Anything).Return(file.Usage{OwnerID: "owner-1", Files: 2, UsedBytes: 40}, nil).Once()
+	usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), usage, newStorage(), nil, nil, opts)
+
+	got, err := usecase.StorageUsage(user.WithActor(context.Background(), "owner-1"))
+	require.NoError(t, err)
+	assert.Equal(t, file.Usage{OwnerID: "owner-1", Files: 2, UsedBytes: 40, QuotaBytes: 100}, got)
+
+	_, err = usecase.StorageUsage(context.Background())
+	assert.Equal(t, http.StatusUnauthorized, appError(t, err).Code)
+}
+
+func TestStorageReport(t *testing.T) {
+	opts := testOptions
+	opts.Quota = 100
+
+	usage := new(MockUsageRepository)
+	usage.On("ListUsage", 10, 10).Return([]file.Usage{
+		{OwnerID: "owner-1", UsedBytes: 90},
+		{OwnerID: "owner-2", UsedBytes: 50, QuotaBytes: 0, CustomQuota: true},
+	}, int64(12), nil).Once()
+	usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), usage, newStorage(), nil, nil, opts)
+
+	usages, total, err := usecase.StorageReport(2, 10)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), total)
+	assert.Equal(t, int64(100), usages[0].QuotaBytes)
+	// A custom unlimited quota is kept
+	assert.Equal(t, int64(0), usages[1].QuotaBytes)
+
+	_, _, err = usecase.StorageReport(0, 10)
+	assert.Equal(t, apperror.ValidationError, appError(t, err).ErrorCode)
+}
+
+func TestSetQuota(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		usage := new(MockUsageRepository)
+		usage.On("SetQuota", "user-1", int64(2048)).Return(nil).Once()
+		usage.On("Usage", "user-1", mock.Anything).Return(file.Usage{OwnerID: "user-1", QuotaBytes: 2048, CustomQuota: true}, nil).Once()
+		usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), usage, newStorage(), nil, nil, testOptions)
+
+		got, err := usecase.SetQuota("user-1", 2048)
+		require.NoError(t, err)
+		assert.Equal(t, int64(2048), got.QuotaBytes)
+	})
+
+	t.Run("UserNotFound", func(t *testing.T) {
+		usage := new(MockUsageRepository)
+		usage.On("SetQuota", "missing", int64(1)).Return(file.ErrOwnerNotFound).Once()
+		usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), usage, newStorage(), nil, nil, testOptions)
+
+		_, err := usecase.SetQuota("missing", 1)
+		assert.Equal(t, apperror.UserNotFound, appError(t, err).ErrorCode)
+	})
+
+	t.Run("Negative", func(t *testing.T) {
+		usage := new(MockUsageRepository)
+		usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), usage, newStorage(), nil, nil, testOptions)
+
+		_, err := usecase.SetQuota("user-1", -1)
+		assert.Equal(t, apperror.ValidationError, appError(t, err).ErrorCode)
+		usage.AssertNotCalled(t, "SetQuota", mock.Anything, mock.Anything)
+	})
+
+	t.Run("DeleteRepositoryError", func(t *testing.T) {
+		usage := new(MockUsageRepository)
+		usage.On("DeleteQuota", "user-1").Return(errors.New("db down")).Once()
+		usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), usage, newStorage(), nil, nil, testOptions)
+
+		_, err := usecase.DeleteQuota("user-1")
+		assert.Equal(t, http.StatusInternalServerError, appError(t, err).Code)
+	})
+}
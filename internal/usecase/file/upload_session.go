@@ -0,0 +1,368 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultPartSize is used when no valid part size is configured.
+	DefaultPartSize = 8 << 20
+	// DefaultSessionTTL is used when no session lifetime is configured.
+	DefaultSessionTTL = 24 * time.Hour
+	// UploadCleanupBatchSize bounds the expired sessions aborted per run.
+	UploadCleanupBatchSize = 100
+)
+
+// MaxSize returns the size limit of a file uploaded at once.
+func (u *Usecase) MaxSize() int64 {
+	return u.opts.MaxSize
+}
+
+// PartSize returns the size of the parts of an upload session.
+func (u *Usecase) PartSize() int64 {
+	return u.opts.PartSize
+}
+
+// InitiateUpload starts a resumable upload of a file of size bytes, owned by
+// the current user. checksum is the expected SHA-256 of the file, hex-encoded,
+// verified on completion when not empty.
+func (u *Usecase) InitiateUpload(ctx context.Context, name, contentType string, size int64, checksum string) (file.UploadSession, error) {
+	owner := user.ActorFromContext(ctx)
+	if owner == "" {
+		return file.UploadSession{}, apperror.Unauthorized("authentication required", nil)
+	}
+	if size <= 0 {
+		return file.UploadSession{}, apperror.BadRequest("size must be positive", nil).WithCode(apperror.ValidationError)
+	}
+	if u.opts.MaxUploadSize > 0 && size > u.opts.MaxUploadSize {
+		return file.UploadSession{}, apperror.NewAppError(http.StatusRequestEntityTooLarge, apperror.FileTooLarge,
+			fmt.Sprintf("file must not exceed %d bytes", u.opts.MaxUploadSize), nil)
+	}
+
+	name = cleanName(name)
+	if name == "" {
+		return file.UploadSession{}, apperror.BadRequest("file name is required", nil).WithCode(apperror.ValidationError)
+	}
+	checksum, ok := parseChecksum(checksum)
+	if !ok {
+		return file.UploadSession{}, apperror.BadRequest("checksum must be a hex-encoded SHA-256", nil).WithCode(apperror.ValidationError)
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	// The pending session reserves its size until it completes or expires,
+	// the reservation covers the time until it is created
+	release, err := u.reserveQuota(owner, size)
+	if err != nil {
+		return file.UploadSession{}, err
+	}
+	defer release()
+
+	session := file.UploadSession{
+		ID:          uuid.New().String(),
+		OwnerID:     owner,
+		Name:        name,
+		ContentType: contentType,
+		Size:        size,
+		PartSize:    u.opts.PartSize,
+		TotalParts:  file.PartCount(size, u.opts.PartSize),
+		Checksum:    checksum,
+		Status:      file.UploadPending,
+		ExpiresAt:   time.Now().Add(u.opts.SessionTTL).UTC().Truncate(time.Second),
+	}
+	session.StorageKey = StorageKey(owner, session.ID)
+
+	uploadID, err := u.storage.CreateMultipartUpload(ctx, session.StorageKey,
+		storage.WithContentType(contentType),
+		storage.WithMetadata(map[string]string{"owner": owner}))
+	if err != nil {
+		return file.UploadSession{}, apperror.Internal(err)
+	}
+	session.UploadID = uploadID
+
+	if err := u.sessions.Create(session); err != nil {
+		u.abortMultipart(ctx, session)
+		return file.UploadSession{}, apperror.Internal(err)
+	}
+
+	return u.ownSession(ctx, session.ID)
+}
+
+// GetUpload returns an upload session of the current user with its uploaded
+// parts, so that an interrupted upload can resume with the missing ones.
+func (u *Usecase) GetUpload(ctx context.Context, id string) (file.UploadSession, error) {
+	return u.ownSession(ctx, id)
+}
+
+// UploadPart stores part number of an upload session. Uploading a part again
+// replaces it. checksum is the expected SHA-256 of the part, hex-encoded,
+// verified when not empty.
+func (u *Usecase) UploadPart(ctx context.Context, id string, number int, checksum string, body io.Reader) (file.UploadedPart, error) {
+	session, err := u.activeSession(ctx, id)
+	if err != nil {
+		return file.UploadedPart{}, err
+	}
+
+	size := session.ExpectedPartSize(number)
+	if size == 0 {
+		return file.UploadedPart{}, apperror.BadRequest(
+			fmt.Sprintf("part number must be between 1 and %d", session.TotalParts), nil).WithCode(apperror.ValidationError)
+	}
+	checksum, ok := parseChecksum(checksum)
+	if !ok {
+		return file.UploadedPart{}, apperror.BadRequest("checksum must be a hex-encoded SHA-256", nil).WithCode(apperror.ValidationError)
+	}
+
+	// Parts are buffered so a truncated or corrupted part never reaches the
+	// bucket, they are bounded by the part size
+	data, err := io.ReadAll(io.LimitReader(body, size+1))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return file.UploadedPart{}, apperror.NewAppError(http.StatusRequestEntityTooLarge, apperror.FileTooLarge,
+				fmt.Sprintf("part %d must be %d bytes", number, size), err)
+		}
+		return file.UploadedPart{}, apperror.BadRequest("failed to read part", err)
+	}
+	if int64(len(data)) != size {
+		return file.UploadedPart{}, apperror.BadRequest(
+			fmt.Sprintf("part %d must be %d bytes, got %d", number, size, len(data)), nil).WithCode(apperror.ValidationError)
+	}
+	sum := sha256.Sum256(data)
+	part := file.UploadedPart{Number: number, Size: size, Checksum: hex.EncodeToString(sum[:])}
+	if checksum != "" && checksum != part.Checksum {
+		return file.UploadedPart{}, checksumMismatch(fmt.Sprintf("checksum of part %d does not match", number))
+	}
+
+	uploaded, err := u.storage.UploadPart(ctx, session.StorageKey, session.UploadID, number, bytes.NewReader(data), size)
+	if err != nil {
+		return file.UploadedPart{}, apperror.Internal(err)
+	}
+	part.ETag = uploaded.ETag
+
+	if err := u.sessions.SavePart(session.ID, part); err != nil {
+		return file.UploadedPart{}, apperror.Internal(err)
+	}
+	return part, nil
+}
+
+// CompleteUpload assembles the parts of an upload session into a file of the
+// same id. When the session has an expected checksum, a file whose content
+// does not match is deleted and the session aborted.
+func (u *Usecase) CompleteUpload(ctx context.Context, id string) (file.File, error) {
+	session, err := u.activeSession(ctx, id)
+	if err != nil {
+		return file.File{}, err
+	}
+
+	if missing := missingParts(session); len(missing) > 0 {
+		return file.File{}, apperror.NewAppError(http.StatusConflict, apperror.UploadIncomplete,
+			fmt.Sprintf("missing parts: %s", strings.Join(missing, ", ")), nil)
+	}
+
+	parts := make([]storage.Part, len(session.Parts))
+	for i, p := range session.Parts {
+		parts[i] = storage.Part{Number: p.Number, ETag: p.ETag, Size: p.Size}
+	}
+	if err := u.storage.CompleteMultipartUpload(ctx, session.StorageKey, session.UploadID, parts); err != nil {
+		return file.File{}, apperror.Internal(err)
+	}
+
+	// The checksum is computed from the stored object, which is what
+	// downloads will get
+	checksum, err := u.objectChecksum(ctx, session.StorageKey)
+	if err != nil {
+		return file.File{}, apperror.Internal(err)
+	}
+	if session.Checksum != "" && checksum != session.Checksum {
+		u.discard(ctx, session)
+		return file.File{}, checksumMismatch("checksum of the file does not match")
+	}
+
+	f := file.File{
+		ID:          session.ID,
+		OwnerID:     session.OwnerID,
+		Name:        session.Name,
+		ContentType: session.ContentType,
+		Size:        session.Size,
+		Checksum:    checksum,
+		StorageKey:  session.StorageKey,
+	}
+	if err := u.inspect(ctx, f); err != nil {
+		u.discard(ctx, session)
+		return file.File{}, err
+	}
+	if err := u.repo.Save(f); err != nil {
+		u.discard(ctx, session)
+		return file.File{}, apperror.Internal(err)
+	}
+	if err := u.sessions.UpdateStatus(session.ID, file.UploadCompleted); err != nil {
+		return file.File{}, apperror.Internal(err)
+	}
+
+	return u.find(f.ID)
+}
+
+// AbortUpload cancels an upload session of the current user and frees its
+// parts. Aborting an aborted session does nothing.
+func (u *Usecase) AbortUpload(ctx context.Context, id string) error {
+	session, err := u.ownSession(ctx, id)
+	if err != nil {
+		return err
+	}
+	switch session.Status {
+	case file.UploadAborted:
+		return nil
+	case file.UploadCompleted:
+		return apperror.NewConflictError("upload is already completed")
+	}
+
+	if err := u.storage.AbortMultipartUpload(ctx, session.StorageKey, session.UploadID); err != nil {
+		return apperror.Internal(err)
+	}
+	if err := u.sessions.UpdateStatus(session.ID, file.UploadAborted); err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// CleanupExpiredUploads aborts a batch of the pending upload sessions past
+// their expiry, freeing the parts they hold in the bucket.
+func (u *Usecase) CleanupExpiredUploads(ctx context.Context) (int, error) {
+	expired, err := u.sessions.FindExpired(time.Now(), UploadCleanupBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	aborted := 0
+	for _, session := range expired {
+		if err := ctx.Err(); err != nil {
+			return aborted, err
+		}
+		if err := u.storage.AbortMultipartUpload(ctx, session.StorageKey, session.UploadID); err != nil {
+			log.Printf("[File] Abort of expired upload %s failed: %v", session.ID, err)
+			continue
+		}
+		if err := u.sessions.UpdateStatus(session.ID, file.UploadAborted); err != nil {
+			log.Printf("[File] Abort of expired upload %s failed: %v", session.ID, err)
+			continue
+		}
+		aborted++
+	}
+
+	if aborted > 0 {
+		log.Printf("[File] Aborted %d expired upload(s)", aborted)
+	}
+	return aborted, nil
+}
+
+// ownSession returns an upload session of the current user. The sessions of
+// other users are reported as not found.
+func (u *Usecase) ownSession(ctx context.Context, id string) (file.UploadSession, error) {
+	owner := user.ActorFromContext(ctx)
+	if owner == "" {
+		return file.UploadSession{}, apperror.Unauthorized("authentication required", nil)
+	}
+
+	session, err := u.sessions.FindByID(id)
+	if err != nil && !errors.Is(err, file.ErrUploadSessionNotFound) {
+		return file.UploadSession{}, apperror.Internal(err)
+	}
+	if err != nil || session.OwnerID != owner {
+		return file.UploadSession{}, apperror.NotFound("upload session not found", file.ErrUploadSessionNotFound).
+			WithCode(apperror.UploadSessionNotFound)
+	}
+	return session, nil
+}
+
+// activeSession returns a pending, unexpired upload session of the current
+// user.
+func (u *Usecase) activeSession(ctx context.Context, id string) (file.UploadSession, error) {
+	session, err := u.ownSession(ctx, id)
+	if err != nil {
+		return file.UploadSession{}, err
+	}
+	if session.Status != file.UploadPending {
+		return file.UploadSession{}, apperror.NewConflictError(fmt.Sprintf("upload is %s", session.Status))
+	}
+	if time.Now().After(session.ExpiresAt) {
+		return file.UploadSession{}, apperror.NewConflictError("upload session has expired")
+	}
+	return session, nil
+}
+
+func (u *Usecase) objectChecksum(ctx context.Context, key string) (string, error) {
+	body, _, err := u.storage.Download(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// discard deletes the assembled object of a session and aborts the session.
+func (u *Usecase) discard(ctx context.Context, session file.UploadSession) {
+	u.deleteObject(ctx, session.StorageKey)
+	if err := u.sessions.UpdateStatus(session.ID, file.UploadAborted); err != nil {
+		log.Printf("[File] failed to abort upload %s: %v", session.ID, err)
+	}
+}
+
+func (u *Usecase) abortMultipart(ctx context.Context, session file.UploadSession) {
+	if err := u.storage.AbortMultipartUpload(context.WithoutCancel(ctx), session.StorageKey, session.UploadID); err != nil {
+		log.Printf("[File] failed to abort multipart upload of %s: %v", session.StorageKey, err)
+	}
+}
+
+// missingParts lists the numbers of the parts not uploaded yet.
+func missingParts(session file.UploadSession) []string {
+	uploaded := make(map[int]bool, len(session.Parts))
+	for _, p := range session.Parts {
+		uploaded[p.Number] = true
+	}
+	var missing []string
+	for n := 1; n <= session.TotalParts; n++ {
+		if !uploaded[n] {
+			missing = append(missing, fmt.Sprint(n))
+		}
+	}
+	return missing
+}
+
+// parseChecksum normalizes an optional hex-encoded SHA-256.
+func parseChecksum(checksum string) (string, bool) {
+	checksum = strings.ToLower(strings.TrimSpace(checksum))
+	if checksum == "" {
+		return "", true
+	}
+	if len(checksum) != sha256.Size*2 {
+		return "", false
+	}
+	_, err := hex.DecodeString(checksum)
+	return checksum, err == nil
+}
+
+func checksumMismatch(msg string) *apperror.AppError {
+	return apperror.NewAppError(http.StatusBadRequest, apperror.ChecksumMismatch, msg, nil)
+}
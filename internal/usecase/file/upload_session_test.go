@@ -0,0 +1,334 @@
+package file_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/infrastructure/storage/memory"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/file"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockUploadSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockUploadSessionRepository) Create(s file.UploadSession) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
+
+func (m *MockUploadSessionRepository) FindByID(id string) (file.UploadSession, error) {
+	args := m.Called(id)
+	return args.Get(0).(file.UploadSession), args.Error(1)
+}
+
+func (m *MockUploadSessionRepository) SavePart(sessionID string, part file.UploadedPart) error {
+	args := m.Called(sessionID, part)
+	return args.Error(0)
+}
+
+func (m *MockUploadSessionRepository) UpdateStatus(id, status string) error {
+	args := m.Called(id, status)
+	return args.Error(0)
+}
+
+func (m *MockUploadSessionRepository) FindExpired(now time.Time, limit int) ([]file.UploadSession, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]file.UploadSession), args.Error(1)
+}
+
+var sessionOptions = uc.Options{PartSize: storage.MinPartSize, MaxUploadSize: 4 * storage.MinPartSize, URLExpiry: 5 * time.Minute}
+
+// content is a file of one full part and a 3 bytes last part.
+var content = append(bytes.Repeat([]byte("a"), storage.MinPartSize), "end"...)
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// newSession starts a multipart upload of content in store.
+func newSession(t *testing.T, store *memory.Storage, expected string) file.UploadSession {
+	session := file.UploadSession{
+		ID:          "3f7a2c1e-8b4d-4e6f-9a0b-1c2d3e4f5a6b",
+		OwnerID:     "owner-1",
+		Name:        "video.mp4",
+		ContentType: "video/mp4",
+		Size:        int64(len(content)),
+		PartSize:    storage.MinPartSize,
+		TotalParts:  2,
+		Checksum:    expected,
+		Status:      file.UploadPending,
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+	session.StorageKey = uc.StorageKey(session.OwnerID, session.ID)
+
+	uploadID, err := store.CreateMultipartUpload(context.Background(), session.StorageKey)
+	require.NoError(t, err)
+	session.UploadID = uploadID
+	return session
+}
+
+// uploadParts stores the parts of content and records them in session.
+func uploadParts(t *testing.T, store *memory.Storage, session *file.UploadSession) {
+	for i, data := range [][]byte{content[:storage.MinPartSize], content[storage.MinPartSize:]} {
+		part, err := store.UploadPart(context.Background(), session.StorageKey, session.UploadID, i+1, bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		session.Parts = append(session.Parts, file.UploadedPart{Number: part.Number, Size: part.Size, ETag: part.ETag, Checksum: checksum(data)})
+	}
+}
+
+func TestInitiateUpload(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "owner-1")
+
+	t.Run("Success", func(t *testing.T) {
+		sessions := new(MockUploadSessionRepository)
+		usecase := uc.New(new(MockRepository), sessions, noQuota(), newStorage(), nil, nil, sessionOptions)
+
+		var created file.UploadSession
+		sessions.On("Create", mock.MatchedBy(func(s file.UploadSession) bool {
+			created = s
+			return s.OwnerID == "owner-1" && s.Name == "video.mp4" && s.TotalParts == 2 && s.PartSize == storage.MinPartSize &&
+				s.Status == file.UploadPending && s.UploadID != "" && s.Checksum == checksum(content)
+		})).Return(nil).Once()
+		sessions.On("FindByID", mock.Anything).Return(file.UploadSession{ID: "1", OwnerID: "owner-1"}, nil).Once()
+
+		session, err := usecase.InitiateUpload(ctx, "video.mp4", "video/mp4", int64(len(content)), checksum(content))
+
+		require.NoError(t, err)
+		sessions.AssertExpectations(t)
+		assert.Equal(t, "1", session.ID)
+		sessions.AssertCalled(t, "FindByID", created.ID)
+		assert.WithinDuration(t, time.Now().Add(uc.DefaultSessionTTL), created.ExpiresAt, 2*time.Second)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), noQuota(), newStorage(), nil, nil, sessionOptions)
+
+		_, err := usecase.InitiateUpload(ctx, "video.mp4", "", sessionOptions.MaxUploadSize+1, "")
+
+		appErr := appError(t, err)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, appErr.Code)
+		assert.Equal(t, apperror.FileTooLarge, appErr.ErrorCode)
+	})
+
+	t.Run("InvalidChecksum", func(t *testing.T) {
+		usecase := uc.New(new(MockRepository), new(MockUploadSessionRepository), noQuota(), newStorage(), nil, nil, sessionOptions)
+
+		_, err := usecase.InitiateUpload(ctx, "video.mp4", "", 10, "not-a-checksum")
+
+		assert.Equal(t, http.StatusBadRequest, appError(t, err).Code)
+	})
+}
+
+func TestUploadPart(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "owner-1")
+	last := content[storage.MinPartSize:]
+
+	t.Run("Success", func(t *testing.T) {
+		sessions := new(MockUploadSessionRepository)
+		store := newStorage()
+		session := newSession(t, store, "")
+		usecase := uc.New(new(MockRepository), sessions, noQuota(), store, nil, nil, sessionOptions)
+
+		sessions.On("FindByID", session.ID).Return(session, nil).Once()
+		sessions.On("SavePart", session.ID, mock.MatchedBy(func(p file.UploadedPart) bool {
+			return p.Number == 2 && p.Size == 3 && p.ETag != "" && p.Checksum == checksum(last)
+		})).Return(nil).Once()
+
+		part, err := usecase.UploadPart(ctx, session.ID, 2, checksum(last), bytes.NewReader(last))
+
+		require.NoError(t, err)
+		sessions.AssertExpectations(t)
+		assert.Equal(t, 2, part.Number)
+	})
+
+	tests := []struct {
+		name     string
+		number   int
+		body     []byte
+		checksum string
+		errCode  string
+	}{
+		{name: "WrongSize", number: 1, body: last, errCode: apperror.ValidationError},
+		{name: "UnknownPart", number: 3, body: last, errCode: apperror.ValidationError},
+		{name: "ChecksumMismatch", number: 2, body: last, checksum: checksum([]byte("other")), errCode: apperror.ChecksumMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := new(MockUploadSessionRepository)
+			store := newStorage()
+			session := newSession(t, store, "")
+			usecase := uc.New(new(MockRepository), sessions, noQuota(), store, nil, nil, sessionOptions)
+
+			sessions.On("FindByID", session.ID).Return(session, nil).Once()
+
+			_, err := usecase.UploadPart(ctx, session.ID, tt.number, tt.checksum, bytes.NewReader(tt.body))
+
+			appErr := appError(t, err)
+			assert.Equal(t, http.StatusBadRequest, appErr.Code)
+			assert.Equal(t, tt.errCode, appErr.ErrorCode)
+			sessions.AssertNotCalled(t, "SavePart", mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("OtherOwner", func(t *testing.T) {
+		sessions := new(MockUploadSessionRepository)
+		store := newStorage()
+		session := newSession(t, store, "")
+		usecase := uc.New(new(MockRepository), sessions, noQuota(), store, nil, nil, sessionOptions)
+
+		sessions.On("FindByID", session.ID).Return(session, nil).Once()
+
+		_, err := usecase.UploadPart(user.WithActor(context.Background(), "user-2"), session.ID, 2, "", bytes.NewReader(last))
+
+		appErr := appError(t, err)
+		assert.Equal(t, http.StatusNotFound, appErr.Code)
+		assert.Equal(t, apperror.UploadSessionNotFound, appErr.ErrorCode)
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		sessions := new(MockUploadSessionRepository)
+		store := newStorage()
+		session := newSession(t, store, "")
+		session.ExpiresAt = time.Now().Add(-time.Minute)
+		usecase := uc.New(new(MockRepository), sessions, noQuota(), store, nil, nil, sessionOptions)
+
+		sessions.On("FindByID", session.ID).Return(session, nil).Once()
+
+		_, err := usecase.UploadPart(ctx, session.ID, 2, "", bytes.NewReader(last))
+
+		assert.Equal(t, http.StatusConflict, appError(t, err).Code)
+	})
+}
+
+func TestCompleteUpload(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "owner-1")
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		sessions := new(MockUploadSessionRepository)
+		store := newStorage()
+		session := newSession(t, store, checksum(content))
+		uploadParts(t, store, &session)
+		usecase := uc.New(mockRepo, sessions, noQuota(), store, nil, nil, sessionOptions)
+
+		sessions.On("FindByID", session.ID).Return(session, nil).Once()
+		mockRepo.On("Save", mock.MatchedBy(func(f file.File) bool {
+			return f.ID == session.ID && f.Size == int64(len(content)) && f.Checksum == checksum(content) &&
+				f.StorageKey == session.StorageKey
+		})).Return(nil).Once()
+		sessions.On("UpdateStatus", session.ID, file.UploadCompleted).Return(nil).Once()
+		mockRepo.On("FindByID", session.ID).Return(file.File{ID: session.ID}, nil).Once()
+
+		f, err := usecase.CompleteUpload(ctx, session.ID)
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		sessions.AssertExpectations(t)
+		assert.Equal(t, session.ID, f.ID)
+		obj, err := store.Head(ctx, session.StorageKey)
+		require.NoError(t, err)
+		assert.Equal(t, int64(len(content)), obj.Size)
+	})
+
+	t.Run("MissingParts", func(t *testing.T) {
+		sessions := new(MockUploadSessionRepository)
+		store := newStorage()
+		session := newSession(t, store, "")
+		uploadParts(t, store, &session)
+		session.Parts = session.Parts[1:]
+		usecase := uc.New(new(MockRepository), sessions, noQuota(), store, nil, nil, sessionOptions)
+
+		sessions.On("FindByID", session.ID).Return(session, nil).Once()
+
+		_, err := usecase.CompleteUpload(ctx, session.ID)
+
+		appErr := appError(t, err)
+		assert.Equal(t, http.StatusConflict, appErr.Code)
+		assert.Equal(t, apperror.UploadIncomplete, appErr.ErrorCode)
+		assert.Contains(t, appErr.Message, "1")
+	})
+
+	t.Run("ChecksumMismatch", func(t *testing.T) {
+		mockRepo := new(MockRepository)
+		sessions := new(MockUploadSessionRepository)
+		store := newStorage()
+		session := newSession(t, store, checksum([]byte("other")))
+		uploadParts(t, store, &session)
+		usecase := uc.New(mockRepo, sessions, noQuota(), store, nil, nil, sessionOptions)
+
+		sessions.On("FindByID", session.ID).Return(session, nil).Once()
+		sessions.On("UpdateStatus", session.ID, file.UploadAborted).Return(nil).Once()
+
+		_, err := usecase.CompleteUpload(ctx, session.ID)
+
+		assert.Equal(t, apperror.ChecksumMismatch, appError(t, err).ErrorCode)
+		sessions.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "Save", mock.Anything)
+		exists, _ := store.Exists(ctx, session.StorageKey)
+		assert.False(t, exists)
+	})
+}
+
+func TestAbortUpload(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "owner-1")
+
+	t.Run("Success", func(t *testing.T) {
+		sessions := new(MockUploadSessionRepository)
+		store := newStorage()
+		session := newSession(t, store, "")
+		usecase := uc.New(new(MockRepository), sessions, noQuota(), store, nil, nil, sessionOptions)
+
+		sessions.On("FindByID", session.ID).Return(session, nil).Once()
+		sessions.On("UpdateStatus", session.ID, file.UploadAborted).Return(nil).Once()
+
+		require.NoError(t, usecase.AbortUpload(ctx, session.ID))
+
+		sessions.AssertExpectations(t)
+		_, err := store.UploadPart(ctx, session.StorageKey, session.UploadID, 1, bytes.NewReader(nil), 0)
+		assert.ErrorIs(t, err, storage.ErrUploadNotFound)
+	})
+
+	t.Run("Completed", func(t *testing.T) {
+		sessions := new(MockUploadSessionRepository)
+		store := newStorage()
+		session := newSession(t, store, "")
+		session.Status = file.UploadCompleted
+		usecase := uc.New(new(MockRepository), sessions, noQuota(), store, nil, nil, sessionOptions)
+
+		sessions.On("FindByID", session.ID).Return(session, nil).Once()
+
+		err := usecase.AbortUpload(ctx, session.ID)
+
+		assert.Equal(t, http.StatusConflict, appError(t, err).Code)
+	})
+}
+
+func TestCleanupExpiredUploads(t *testing.T) {
+	sessions := new(MockUploadSessionRepository)
+	store := newStorage()
+	session := newSession(t, store, "")
+	usecase := uc.New(new(MockRepository), sessions, noQuota(), store, nil, nil, sessionOptions)
+
+	sessions.On("FindExpired", mock.Anything, uc.UploadCleanupBatchSize).Return([]file.UploadSession{session}, nil).Once()
+	sessions.On("UpdateStatus", session.ID, file.UploadAborted).Return(nil).Once()
+
+	aborted, err := usecase.CleanupExpiredUploads(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, aborted)
+	sessions.AssertExpectations(t)
+}
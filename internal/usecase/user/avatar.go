@@ -0,0 +1,119 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/pkg/filecheck"
+	"github.com/afandimsr/go-gin-api/internal/pkg/thumbnail"
+)
+
+// MaxAvatarSize bounds the size of an uploaded avatar file.
+const MaxAvatarSize = 2 << 20
+
+// AvatarSizes are the pixel sizes of the square avatar variants, the first
+// one is stored as the avatar URL of the profile.
+var AvatarSizes = []int{512, 128, 64}
+
+// avatarCacheControl lets clients cache avatars forever, a new avatar getting
+// a new key.
+const avatarCacheControl = "public, max-age=31536000, immutable"
+
+// AvatarKey returns the storage key of an avatar variant. The key depends on
+// the image content only, so a new avatar never reuses a cached URL.
+func AvatarKey(userID, digest string, size int, ext string) string {
+	return fmt.Sprintf("avatars/%s/%s/%d.%s", userID, digest, size, ext)
+}
+
+// UploadAvatar validates an avatar image by its content, stores its resized
+// variants and saves the URL of the largest one on the profile of the user.
+func (u *Usecase) UploadAvatar(ctx context.Context, id string, data []byte) (user.User, error) {
+	if u.avatarStorage == nil {
+		return user.User{}, apperror.Internal(errors.New("avatar storage is not configured"))
+	}
+
+	existingUser, err := u.GetByID(ctx, id)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	if len(data) > MaxAvatarSize {
+		return user.User{}, apperror.NewAppError(http.StatusRequestEntityTooLarge, apperror.BadRequestError,
+			fmt.Sprintf("avatar must not exceed %d MB", MaxAvatarSize>>20), nil)
+	}
+
+	if err := u.checkAvatar(ctx, id, data); err != nil {
+		return user.User{}, err
+	}
+
+	variants, contentType, err := thumbnail.Squares(data, AvatarSizes)
+	if err != nil {
+		if errors.Is(err, thumbnail.ErrUnsupportedImage) {
+			return user.User{}, apperror.NewAppError(http.StatusUnsupportedMediaType, apperror.BadRequestError, thumbnail.ErrUnsupportedImage.Error(), err)
+		}
+		if errors.Is(err, thumbnail.ErrImageTooLarge) {
+			return user.User{}, apperror.BadRequest(err.Error(), err)
+		}
+		return user.User{}, apperror.Internal(err)
+	}
+
+	sum := sha256.Sum256(data)
+	digest := hex.EncodeToString(sum[:8])
+	ext := thumbnail.Extension(contentType)
+	for _, size := range AvatarSizes {
+		if err := u.avatarStorage.Upload(ctx, AvatarKey(id, digest, size, ext), bytes.NewReader(variants[size]),
+			storage.WithContentType(contentType), storage.WithCacheControl(avatarCacheControl)); err != nil {
+			return user.User{}, apperror.Internal(err)
+		}
+	}
+
+	existingUser.Profile.AvatarURL = u.avatarStorage.URL(AvatarKey(id, digest, AvatarSizes[0], ext))
+	existingUser.UpdatedBy = user.ActorFromContext(ctx)
+	if err := u.repo.UpdateProfile(ctx, existingUser); err != nil {
+		if errors.Is(err, user.ErrVersionConflict) {
+			return user.User{}, versionMismatch(err)
+		}
+		return user.User{}, apperror.Internal(err)
+	}
+
+	return u.GetByID(ctx, id)
+}
+
+// checkAvatar runs an avatar through the avatar checks. A rejected avatar is
+// kept in the quarantine instead of being published.
+func (u *Usecase) checkAvatar(ctx context.Context, id string, data []byte) error {
+	content := filecheck.Bytes("avatar", data)
+	err := u.avatarChecks.Run(ctx, content)
+	if err == nil {
+		return nil
+	}
+	rejection, ok := filecheck.AsRejection(err)
+	if !ok {
+		return apperror.Internal(err)
+	}
+
+	if u.quarantine != nil {
+		key, qErr := u.quarantine.Put(context.WithoutCancel(ctx), file.Rejected{
+			OwnerID:     id,
+			Name:        content.Name,
+			ContentType: content.Type,
+			Reason:      rejection.Reason,
+		}, bytes.NewReader(data))
+		if qErr != nil {
+			log.Printf("[Usecase] Failed to quarantine avatar of user %s: %v", id, qErr)
+		} else {
+			log.Printf("[Usecase] Quarantined avatar of user %s as %s: %s", id, key, rejection.Reason)
+		}
+	}
+	return apperror.NewAppError(http.StatusUnprocessableEntity, apperror.FileRejected, rejection.Reason, rejection)
+}
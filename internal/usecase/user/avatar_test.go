@@ -0,0 +1,120 @@
+package user_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/pkg/filecheck"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockQuarantine struct {
+	mock.Mock
+}
+
+func (m *MockQuarantine) Put(ctx context.Context, r file.Rejected, body io.Reader) (string, error) {
+	args := m.Called(r)
+	return args.String(0), args.Error(1)
+}
+
+func TestUploadAvatar(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "1")
+
+	var img bytes.Buffer
+	assert.NoError(t, png.Encode(&img, image.NewNRGBA(image.Rect(0, 0, 40, 30))))
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockStorage := new(MockStorage)
+		usecase := uc.New(mockRepo, uc.Deps{AvatarStorage: mockStorage})
+
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Version: 2, Profile: user.Profile{Department: "IT"}}, nil).Once()
+		for _, size := range uc.AvatarSizes {
+			mockStorage.On("Upload", mock.MatchedBy(func(key string) bool {
+				return strings.HasPrefix(key, "avatars/1/") && strings.HasSuffix(key, fmt.Sprintf("/%d.png", size))
+			}), mock.Anything, storage.UploadOptions{
+				ContentType:  "image/png",
+				CacheControl: "public, max-age=31536000, immutable",
+			}).Return(nil).Once()
+		}
+		mockRepo.On("UpdateProfile", mock.MatchedBy(func(u user.User) bool {
+			return u.Version == 2 && u.Profile.Department == "IT" &&
+				strings.HasPrefix(u.Profile.AvatarURL, "https://cdn.example.com/avatars/1/") &&
+				strings.HasSuffix(u.Profile.AvatarURL, "/512.png")
+		})).Return(nil).Once()
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Version: 3}, nil).Once()
+
+		_, err := usecase.UploadAvatar(ctx, "1", img.Bytes())
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockStorage.AssertExpectations(t)
+	})
+
+	t.Run("NotAnImage", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockStorage := new(MockStorage)
+		usecase := uc.New(mockRepo, uc.Deps{AvatarStorage: mockStorage})
+
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1"}, nil).Once()
+
+		_, err := usecase.UploadAvatar(ctx, "1", []byte("<svg onload=alert(1)></svg>"))
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusUnsupportedMediaType, appErr.Code)
+		mockStorage.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("RejectedIsQuarantined", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockStorage := new(MockStorage)
+		mockQuarantine := new(MockQuarantine)
+		checks := filecheck.New(filecheck.MaxDimensions(32, 32))
+		usecase := uc.New(mockRepo, uc.Deps{AvatarStorage: mockStorage, AvatarChecks: checks, Quarantine: mockQuarantine})
+
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1"}, nil).Once()
+		mockQuarantine.On("Put", file.Rejected{
+			OwnerID:     "1",
+			Name:        "avatar",
+			ContentType: "image/png",
+			Reason:      "image must not exceed 32x32 pixels",
+		}).Return("quarantine/1", nil).Once()
+
+		_, err := usecase.UploadAvatar(ctx, "1", img.Bytes())
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusUnprocessableEntity, appErr.Code)
+		assert.Equal(t, apperror.FileRejected, appErr.ErrorCode)
+		mockQuarantine.AssertExpectations(t)
+		mockStorage.AssertNotCalled(t, "Upload", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{AvatarStorage: new(MockStorage)})
+
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1"}, nil).Once()
+
+		_, err := usecase.UploadAvatar(ctx, "1", make([]byte, uc.MaxAvatarSize+1))
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, appErr.Code)
+	})
+}
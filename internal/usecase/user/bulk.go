@@ -0,0 +1,117 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// MaxBulkIDs caps the number of users of a single bulk operation.
+const MaxBulkIDs = 100
+
+// Bulk applies action to every user of ids independently: a failing user is
+// reported in its result and does not stop the others. role is the role added
+// or removed by the add-role and remove-role actions.
+func (u *Usecase) Bulk(ctx context.Context, action string, ids []string, role string) (user.BulkReport, error) {
+	report := user.BulkReport{Action: action, Results: []user.BulkItemResult{}}
+
+	if len(ids) == 0 || len(ids) > MaxBulkIDs {
+		return report, apperror.BadRequest(fmt.Sprintf("between 1 and %d ids are required", MaxBulkIDs), nil).
+			WithCode(apperror.ValidationError)
+	}
+
+	var apply func(ctx context.Context, id string) error
+	switch action {
+	case user.BulkActivate:
+		apply = u.Activate
+	case user.BulkDeactivate:
+		apply = func(ctx context.Context, id string) error { return u.Deactivate(ctx, id, nil) }
+	case user.BulkDelete:
+		apply = u.Delete
+	case user.BulkAddRole, user.BulkRemoveRole:
+		known, err := u.knownRoles(ctx, []string{role})
+		if err != nil {
+			return report, apperror.Internal(err)
+		}
+		if len(known) == 0 {
+			return report, apperror.BadRequest(fmt.Sprintf("unknown role %q", role), nil).
+				WithCode(apperror.ValidationError)
+		}
+		add := action == user.BulkAddRole
+		apply = func(ctx context.Context, id string) error { return u.changeRole(ctx, id, known[0], add) }
+	default:
+		return report, apperror.BadRequest(fmt.Sprintf("unsupported action %q", action), nil).
+			WithCode(apperror.ValidationError)
+	}
+
+	// Locking oneself out through a bulk operation is almost always a mistake
+	selfLockout := action == user.BulkDeactivate || action == user.BulkDelete || action == user.BulkRemoveRole
+	actor := user.ActorFromContext(ctx)
+
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		var err error
+		if selfLockout && id == actor {
+			err = apperror.NewForbiddenError("cannot " + action + " your own account")
+		} else {
+			err = apply(ctx, id)
+		}
+
+		result := user.BulkItemResult{ID: id, Success: err == nil}
+		if err != nil {
+			result.Error = err.Error()
+			var appErr *apperror.AppError
+			if errors.As(err, &appErr) {
+				result.ErrorCode = appErr.ErrorCode
+			}
+			report.Failed++
+		} else {
+			report.Succeeded++
+		}
+		report.Results = append(report.Results, result)
+	}
+	report.Total = len(report.Results)
+
+	return report, nil
+}
+
+// changeRole adds or removes role from the roles of a user.
+func (u *Usecase) changeRole(ctx context.Context, id, role string, add bool) error {
+	existingUser, err := u.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	has := slices.ContainsFunc(existingUser.Roles, func(r string) bool { return strings.EqualFold(r, role) })
+	switch {
+	case add && has, !add && !has:
+		return nil
+	case add:
+		existingUser.Roles = append(existingUser.Roles, role)
+	default:
+		existingUser.Roles = slices.DeleteFunc(existingUser.Roles, func(r string) bool { return strings.EqualFold(r, role) })
+	}
+	existingUser.UpdatedBy = user.ActorFromContext(ctx)
+
+	if err := u.repo.Update(ctx, existingUser); err != nil {
+		if errors.Is(err, user.ErrVersionConflict) {
+			return versionMismatch(err)
+		}
+		return apperror.Internal(err)
+	}
+	return nil
+}
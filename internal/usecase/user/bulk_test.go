@@ -0,0 +1,100 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBulk(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "admin")
+
+	t.Run("Deactivate reports every user", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockSessions := new(MockSessionRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Sessions: mockSessions})
+
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1"}, nil).Once()
+		mockRepo.On("FindByID", "2").Return(user.User{}, user.ErrUserNotFound).Once()
+		mockRepo.On("UpdateActive", "1", false, "admin").Return(nil).Once()
+		mockSessions.On("RevokeByUserID", "1").Return(int64(2), nil).Once()
+
+		report, err := usecase.Bulk(ctx, user.BulkDeactivate, []string{"1", "2", "1", "admin"}, "")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, report.Total)
+		assert.Equal(t, 1, report.Succeeded)
+		assert.Equal(t, 2, report.Failed)
+		assert.True(t, report.Results[0].Success)
+		assert.Equal(t, apperror.UserNotFound, report.Results[1].ErrorCode)
+		assert.Equal(t, apperror.AuthForbidden, report.Results[2].ErrorCode)
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("Add role", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindAllRoles").Return([]string{"ADMIN", "USER"}, nil)
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Roles: []string{"USER"}}, nil).Once()
+		mockRepo.On("FindByID", "2").Return(user.User{ID: "2", Roles: []string{"ADMIN"}}, nil).Once()
+		mockRepo.On("Update", mock.MatchedBy(func(u user.User) bool {
+			return u.ID == "1" && assert.ObjectsAreEqual([]string{"USER", "ADMIN"}, u.Roles) && u.UpdatedBy == "admin"
+		})).Return(nil).Once()
+
+		report, err := usecase.Bulk(ctx, user.BulkAddRole, []string{"1", "2"}, "admin")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Succeeded)
+		mockRepo.AssertExpectations(t) // user 2 already had the role, no update
+	})
+
+	t.Run("Remove role conflict", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindAllRoles").Return([]string{"ADMIN", "USER"}, nil)
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Roles: []string{"USER", "ADMIN"}}, nil).Once()
+		mockRepo.On("Update", mock.MatchedBy(func(u user.User) bool {
+			return assert.ObjectsAreEqual([]string{"USER"}, u.Roles)
+		})).Return(user.ErrVersionConflict).Once()
+
+		report, err := usecase.Bulk(ctx, user.BulkRemoveRole, []string{"1"}, "ADMIN")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, apperror.PreconditionFailed, report.Results[0].ErrorCode)
+	})
+
+	t.Run("Rejects invalid requests", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+		mockRepo.On("FindAllRoles").Return([]string{"USER"}, nil)
+
+		tooMany := make([]string, uc.MaxBulkIDs+1)
+		for _, tc := range []struct {
+			action string
+			ids    []string
+			role   string
+		}{
+			{user.BulkActivate, nil, ""},
+			{user.BulkActivate, tooMany, ""},
+			{"archive", []string{"1"}, ""},
+			{user.BulkAddRole, []string{"1"}, "OWNER"},
+		} {
+			_, err := usecase.Bulk(ctx, tc.action, tc.ids, tc.role)
+
+			var appErr *apperror.AppError
+			assert.True(t, errors.As(err, &appErr), tc.action)
+			assert.Equal(t, http.StatusBadRequest, appErr.Code, tc.action)
+		}
+	})
+}
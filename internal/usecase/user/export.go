@@ -0,0 +1,31 @@
+package user
+
+import (
+	"context"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// Export calls fn for every user matching the filters and sort of query, without
+// paging. Users are streamed from the repository, so fn should write them out
+// rather than collect them. Errors returned by fn are passed through unchanged.
+func (u *Usecase) Export(ctx context.Context, query user.ListQuery, fn func(user.User) error) error {
+	query.After = nil
+	if err := query.Normalize(); err != nil {
+		return apperror.BadRequest(err.Error(), err).WithCode(apperror.ValidationError)
+	}
+
+	var fnErr error
+	err := u.repo.Stream(ctx, query, func(usr user.User) error {
+		if fnErr = ctx.Err(); fnErr != nil {
+			return fnErr
+		}
+		fnErr = fn(usr)
+		return fnErr
+	})
+	if err != nil && fnErr == nil {
+		return apperror.Internal(err)
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExport(t *testing.T) {
+	users := []user.User{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+	t.Run("Streams every user without paging", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+		mockRepo.On("Stream", mock.MatchedBy(func(q user.ListQuery) bool {
+			return q.Role == "ADMIN" && q.SortBy == user.SortByCreatedAt && q.After == nil
+		})).Return(users, nil).Once()
+
+		var ids []string
+		err := usecase.Export(context.Background(), user.ListQuery{Role: "ADMIN", After: &user.Cursor{}}, func(u user.User) error {
+			ids = append(ids, u.ID)
+			return nil
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"1", "2", "3"}, ids)
+	})
+
+	t.Run("Stops at the first write error", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+		mockRepo.On("Stream", mock.Anything).Return(users, nil).Once()
+		writeErr := errors.New("broken pipe")
+
+		written := 0
+		err := usecase.Export(context.Background(), user.ListQuery{}, func(u user.User) error {
+			written++
+			return writeErr
+		})
+
+		assert.ErrorIs(t, err, writeErr)
+		assert.Equal(t, 1, written)
+	})
+
+	t.Run("Stops when the request is canceled", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+		mockRepo.On("Stream", mock.Anything).Return(users, nil).Once()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := usecase.Export(ctx, user.ListQuery{}, func(u user.User) error { return nil })
+
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("Repository errors are internal", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+		mockRepo.On("Stream", mock.Anything).Return([]user.User{}, errors.New("connection reset")).Once()
+
+		err := usecase.Export(context.Background(), user.ListQuery{}, func(u user.User) error { return nil })
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusInternalServerError, appErr.Code)
+	})
+
+	t.Run("Invalid sort", func(t *testing.T) {
+		usecase := uc.New(new(MockUserRepository), uc.Deps{})
+
+		err := usecase.Export(context.Background(), user.ListQuery{SortBy: "password"}, func(u user.User) error { return nil })
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusBadRequest, appErr.Code)
+	})
+}
@@ -0,0 +1,144 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// LoginWithIdentity handles login/registration of a user authenticated by an
+// external identity provider (Keycloak, Google, GitHub, ...). Users are matched
+// by their (provider, subject) identity first, then linked by verified email,
+// and registered otherwise.
+func (u *Usecase) LoginWithIdentity(ctx context.Context, ext user.ExternalIdentity) (string, error) {
+	log.Printf("[Usecase] LoginWithIdentity: provider=%s, email=%s, sub=%s", ext.Provider, ext.Email, ext.Subject)
+
+	if ext.Provider == "" || ext.Subject == "" {
+		return "", apperror.Unauthorized("invalid token claims", nil)
+	}
+
+	existingUser, err := u.findIdentityUser(ctx, ext)
+	if errors.Is(err, user.ErrUserNotFound) {
+		existingUser, err = u.linkOrRegisterIdentity(ctx, ext)
+	}
+	if err != nil {
+		log.Printf("[Usecase] LoginWithIdentity failed: %v", err)
+		return "", err
+	}
+	if !existingUser.IsActive {
+		return "", errUserInactive()
+	}
+
+	// Only Keycloak tokens are embedded, the auth middleware verifies them against Keycloak
+	var keycloakToken string
+	if ext.Provider == user.KeycloakIdentityProvider {
+		keycloakToken = ext.AccessToken
+	}
+
+	log.Printf("[Usecase] Generating token for user ID %s", existingUser.ID)
+	return u.issueToken(ctx, existingUser, ext.Provider, keycloakToken, ext.SessionID)
+}
+
+// findIdentityUser returns the user linked to the identity, or ErrUserNotFound.
+// Keycloak users linked before user_identities existed are found by keycloak_id.
+func (u *Usecase) findIdentityUser(ctx context.Context, ext user.ExternalIdentity) (user.User, error) {
+	if u.identityRepo != nil {
+		identity, err := u.identityRepo.FindByProviderSubject(ctx, ext.Provider, ext.Subject)
+		if err == nil {
+			existingUser, err := u.repo.FindByID(ctx, identity.UserID)
+			if err != nil && !errors.Is(err, user.ErrUserNotFound) {
+				return user.User{}, apperror.Internal(err)
+			}
+			return existingUser, err
+		}
+		if !errors.Is(err, user.ErrIdentityNotFound) {
+			return user.User{}, apperror.Internal(err)
+		}
+	}
+
+	if ext.Provider != user.KeycloakIdentityProvider {
+		return user.User{}, user.ErrUserNotFound
+	}
+
+	existingUser, err := u.repo.FindByKeycloakID(ctx, ext.Subject)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return user.User{}, err
+		}
+		return user.User{}, apperror.Internal(err)
+	}
+
+	if err := u.linkIdentity(ctx, existingUser, ext); err != nil {
+		return user.User{}, err
+	}
+	return existingUser, nil
+}
+
+func (u *Usecase) linkOrRegisterIdentity(ctx context.Context, ext user.ExternalIdentity) (user.User, error) {
+	if ext.Email == "" {
+		return user.User{}, apperror.Unauthorized("email is not provided by "+ext.Provider, nil)
+	}
+
+	existingUser, err := u.repo.FindByEmail(ctx, ext.Email)
+	switch {
+	case err == nil:
+		// Linking by an unverified email would let anyone claim the account
+		if !ext.EmailVerified {
+			return user.User{}, apperror.NewConflictError("an account with this email already exists").WithCode(apperror.UserAlreadyExists)
+		}
+		log.Printf("[Usecase] Linking existing user %s to %s identity %s", existingUser.ID, ext.Provider, ext.Subject)
+
+	case errors.Is(err, user.ErrUserNotFound):
+		log.Printf("[Usecase] No existing user found. Registering new user...")
+		// A user whose identity cannot be linked is not registered
+		err = u.withinTx(ctx, func(ctx context.Context) error {
+			existingUser, err = u.provisionUser(ctx, ext.Email, user.AuthResult{Provider: ext.Provider, Name: ext.Name})
+			if err != nil {
+				return err
+			}
+			return u.linkIdentity(ctx, existingUser, ext)
+		})
+		if err != nil {
+			return user.User{}, err
+		}
+		return existingUser, nil
+
+	default:
+		return user.User{}, apperror.Internal(err)
+	}
+
+	if err := u.linkIdentity(ctx, existingUser, ext); err != nil {
+		return user.User{}, err
+	}
+	return existingUser, nil
+}
+
+// linkIdentity records the identity and, for Keycloak, keeps users.keycloak_id
+// in sync, both or neither.
+func (u *Usecase) linkIdentity(ctx context.Context, usr user.User, ext user.ExternalIdentity) error {
+	return u.withinTx(ctx, func(ctx context.Context) error {
+		if ext.Provider == user.KeycloakIdentityProvider && usr.KeycloakID != ext.Subject {
+			if err := u.repo.UpdateKeycloakID(ctx, usr.ID, ext.Subject); err != nil {
+				return apperror.Internal(err)
+			}
+		}
+
+		if u.identityRepo == nil {
+			return nil
+		}
+
+		err := u.identityRepo.Create(ctx, user.Identity{
+			UserID:   usr.ID,
+			Provider: ext.Provider,
+			Subject:  ext.Subject,
+			Email:    ext.Email,
+		})
+		if err != nil {
+			return apperror.Internal(err)
+		}
+		return nil
+	})
+}
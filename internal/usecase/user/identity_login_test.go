@@ -0,0 +1,142 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type fakeTxKey struct{}
+
+// fakeTx counts the units of work committed and rolled back, nested units of
+// work joining the outer one.
+type fakeTx struct {
+	committed, rolledBack int
+}
+
+func (f *fakeTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(fakeTxKey{}) != nil {
+		return fn(ctx)
+	}
+	if err := fn(context.WithValue(ctx, fakeTxKey{}, f)); err != nil {
+		f.rolledBack++
+		return err
+	}
+	f.committed++
+	return nil
+}
+
+func TestLoginWithIdentity(t *testing.T) {
+	google := user.ExternalIdentity{Provider: "google", Subject: "g-1", Email: "a@example.com", EmailVerified: true, Name: "A"}
+
+	t.Run("LinkedIdentity", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockIdentities := new(MockIdentityRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Identities: mockIdentities})
+
+		mockIdentities.On("FindByProviderSubject", "google", "g-1").Return(user.Identity{UserID: "1"}, nil).Once()
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Email: "a@example.com", IsActive: true}, nil).Once()
+
+		token, err := usecase.LoginWithIdentity(context.Background(), google)
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		mockIdentities.AssertExpectations(t)
+	})
+
+	t.Run("LinksVerifiedEmail", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockIdentities := new(MockIdentityRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Identities: mockIdentities})
+
+		mockIdentities.On("FindByProviderSubject", "google", "g-1").Return(user.Identity{}, user.ErrIdentityNotFound).Once()
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{ID: "1", Email: "a@example.com", IsActive: true}, nil).Once()
+		mockIdentities.On("Create", user.Identity{UserID: "1", Provider: "google", Subject: "g-1", Email: "a@example.com"}).Return(nil).Once()
+
+		_, err := usecase.LoginWithIdentity(context.Background(), google)
+
+		assert.NoError(t, err)
+		mockIdentities.AssertExpectations(t)
+	})
+
+	t.Run("RefusesUnverifiedEmail", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockIdentities := new(MockIdentityRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Identities: mockIdentities})
+
+		unverified := google
+		unverified.EmailVerified = false
+		mockIdentities.On("FindByProviderSubject", "google", "g-1").Return(user.Identity{}, user.ErrIdentityNotFound).Once()
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{ID: "1", Email: "a@example.com", IsActive: true}, nil).Once()
+
+		_, err := usecase.LoginWithIdentity(context.Background(), unverified)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperror.UserAlreadyExists, appErr.ErrorCode)
+		mockIdentities.AssertNotCalled(t, "Create", mock.Anything)
+	})
+
+	t.Run("RegistersNewUser", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockIdentities := new(MockIdentityRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Identities: mockIdentities})
+
+		mockIdentities.On("FindByProviderSubject", "google", "g-1").Return(user.Identity{}, user.ErrIdentityNotFound).Once()
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{}, user.ErrUserNotFound).Once()
+		mockRepo.On("FindAllRoles").Return([]string{"USER"}, nil).Once()
+		mockRepo.On("Save", mock.AnythingOfType("user.User")).Return(nil).Once()
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{ID: "2", Email: "a@example.com", IsActive: true}, nil).Once()
+		mockIdentities.On("Create", mock.MatchedBy(func(i user.Identity) bool { return i.UserID == "2" })).Return(nil).Once()
+
+		_, err := usecase.LoginWithIdentity(context.Background(), google)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockIdentities.AssertExpectations(t)
+	})
+
+	t.Run("RegistrationRolledBackWhenLinkFails", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockIdentities := new(MockIdentityRepository)
+		tx := new(fakeTx)
+		usecase := uc.New(mockRepo, uc.Deps{Identities: mockIdentities, Tx: tx})
+
+		mockIdentities.On("FindByProviderSubject", "google", "g-1").Return(user.Identity{}, user.ErrIdentityNotFound).Once()
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{}, user.ErrUserNotFound).Once()
+		mockRepo.On("FindAllRoles").Return([]string{"USER"}, nil).Once()
+		mockRepo.On("Save", mock.AnythingOfType("user.User")).Return(nil).Once()
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{ID: "2", Email: "a@example.com", IsActive: true}, nil).Once()
+		mockIdentities.On("Create", mock.Anything).Return(errors.New("duplicate identity")).Once()
+
+		_, err := usecase.LoginWithIdentity(context.Background(), google)
+
+		assert.Error(t, err)
+		assert.Equal(t, 1, tx.rolledBack)
+		assert.Equal(t, 0, tx.committed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("KeycloakLegacyLink", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockIdentities := new(MockIdentityRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Identities: mockIdentities})
+
+		keycloak := user.ExternalIdentity{Provider: user.KeycloakIdentityProvider, Subject: "kc-1", Email: "a@example.com"}
+		mockIdentities.On("FindByProviderSubject", "keycloak", "kc-1").Return(user.Identity{}, user.ErrIdentityNotFound).Once()
+		mockRepo.On("FindByKeycloakID", "kc-1").Return(user.User{ID: "1", KeycloakID: "kc-1", IsActive: true}, nil).Once()
+		mockIdentities.On("Create", mock.MatchedBy(func(i user.Identity) bool { return i.Provider == "keycloak" && i.UserID == "1" })).Return(nil).Once()
+
+		_, err := usecase.LoginWithIdentity(context.Background(), keycloak)
+
+		assert.NoError(t, err)
+		mockRepo.AssertNotCalled(t, "UpdateKeycloakID", mock.Anything, mock.Anything)
+		mockIdentities.AssertExpectations(t)
+	})
+}
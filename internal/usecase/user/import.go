@@ -0,0 +1,276 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/mail"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/domain/valueobject"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MaxImportRows bounds the number of data rows of a single import file, the
+// passwords of all of them are hashed while the request waits.
+const MaxImportRows = 1000
+
+// ImportOptions controls a bulk import of users.
+type ImportOptions struct {
+	DryRun    bool
+	BatchSize int
+}
+
+// Columns of an import file, matched case-insensitively against the header row.
+// roles holds role names separated by ";" or "|", USER when empty.
+const (
+	importColumnName     = "name"
+	importColumnEmail    = "email"
+	importColumnPassword = "password"
+	importColumnRoles    = "roles"
+)
+
+type importRow struct {
+	index int // into user.ImportReport.Rows
+	user  user.User
+}
+
+// parsedRow is a data row with the errors found before checking its email
+// against the registered users.
+type parsedRow struct {
+	result     user.ImportRowResult
+	user       user.User
+	errs       []string
+	checkEmail bool
+}
+
+// ImportUsers validates the records of a CSV or XLSX file (header row first)
+// and creates the valid users batch by batch, each batch in one transaction.
+// Invalid rows are reported and skipped; with DryRun nothing is written.
+func (u *Usecase) ImportUsers(ctx context.Context, records [][]string, opts ImportOptions) (user.ImportReport, error) {
+	report := user.ImportReport{DryRun: opts.DryRun, Rows: []user.ImportRowResult{}}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	if len(records) == 0 {
+		return report, apperror.BadRequest("import file is empty", nil)
+	}
+	columns, err := importColumns(records[0])
+	if err != nil {
+		return report, apperror.BadRequest(err.Error(), err)
+	}
+	if len(records)-1 > MaxImportRows {
+		return report, apperror.BadRequest(fmt.Sprintf("import file has more than %d rows", MaxImportRows), nil)
+	}
+
+	localRoles, err := u.repo.FindAllRoles(ctx)
+	if err != nil {
+		return report, apperror.Internal(err)
+	}
+	knownRoles := make(map[string]string, len(localRoles))
+	for _, role := range localRoles {
+		knownRoles[strings.ToUpper(role)] = role
+	}
+
+	var (
+		parsed []parsedRow
+		emails []string
+	)
+	seen := map[string]int{}
+	actor := user.ActorFromContext(ctx)
+
+	for i, record := range records[1:] {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		if isBlankRecord(record) {
+			continue
+		}
+		field := func(column string) string {
+			idx, ok := columns[column]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+
+		row := user.User{
+			Name:      field(importColumnName),
+			Email:     strings.ToLower(field(importColumnEmail)),
+			Password:  field(importColumnPassword),
+			CreatedBy: actor,
+		}
+		result := user.ImportRowResult{Line: i + 2, Email: row.Email}
+
+		var errs []string
+		checkEmail := false
+		if row.Email == "" {
+			errs = append(errs, "email is required")
+		} else if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email {
+			errs = append(errs, "email is not valid")
+		} else if line, ok := seen[row.Email]; ok {
+			errs = append(errs, fmt.Sprintf("duplicate email, already on line %d", line))
+		} else {
+			seen[row.Email] = result.Line
+			emails = append(emails, row.Email)
+			checkEmail = true
+		}
+
+		if row.Name == "" {
+			row.Name = strings.Split(row.Email, "@")[0]
+		}
+
+		if _, err := valueobject.Password(row.Password); err != nil {
+			errs = append(errs, err.Error())
+		}
+
+		for _, name := range splitRoles(field(importColumnRoles)) {
+			role, ok := knownRoles[strings.ToUpper(name)]
+			if !ok {
+				errs = append(errs, fmt.Sprintf("unknown role %q", name))
+				continue
+			}
+			row.Roles = append(row.Roles, role)
+		}
+		if len(row.Roles) == 0 {
+			row.Roles = []string{"USER"} // Default role
+		}
+
+		parsed = append(parsed, parsedRow{result: result, user: row, errs: errs, checkEmail: checkEmail})
+	}
+
+	// One query for all the emails instead of one per row
+	registered, err := u.repo.FindRegisteredEmails(ctx, emails)
+	if err != nil {
+		return report, apperror.Internal(err)
+	}
+	taken := make(map[string]bool, len(registered))
+	for _, email := range registered {
+		taken[email] = true
+	}
+
+	var valid []importRow
+	for _, row := range parsed {
+		result, errs := row.result, row.errs
+		if row.checkEmail && taken[row.user.Email] {
+			errs = append([]string{"email is already registered"}, errs...)
+		}
+
+		if len(errs) > 0 {
+			result.Status = user.ImportStatusInvalid
+			result.Errors = errs
+			report.Invalid++
+		} else {
+			result.Status = user.ImportStatusValid
+			report.Valid++
+			valid = append(valid, importRow{index: len(report.Rows), user: row.user})
+		}
+		report.Rows = append(report.Rows, result)
+	}
+	report.Total = len(report.Rows)
+
+	if opts.DryRun {
+		return report, nil
+	}
+
+	for start := 0; start < len(valid); start += opts.BatchSize {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		batch := valid[start:min(start+opts.BatchSize, len(valid))]
+		if err := u.saveImportBatch(ctx, batch); err != nil {
+			for _, row := range batch {
+				report.Rows[row.index].Status = user.ImportStatusFailed
+				report.Rows[row.index].Errors = []string{err.Error()}
+			}
+			report.Failed += len(batch)
+			continue
+		}
+
+		for _, row := range batch {
+			report.Rows[row.index].Status = user.ImportStatusCreated
+		}
+		report.Created += len(batch)
+	}
+
+	return report, nil
+}
+
+// saveImportBatch hashes the passwords of batch, on all CPUs since bcrypt
+// dominates the import time, and saves the users in one transaction.
+func (u *Usecase) saveImportBatch(ctx context.Context, batch []importRow) error {
+	users := make([]user.User, len(batch))
+	errs := make([]error, len(batch))
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, runtime.GOMAXPROCS(0))
+	)
+	for i, row := range batch {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, row importRow) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(row.user.Password), bcrypt.DefaultCost)
+			users[i] = row.user
+			users[i].Password = string(hashedPassword)
+			errs[i] = err
+		}(i, row)
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return u.repo.SaveAll(ctx, users)
+}
+
+// importColumns maps the known column names of the header row to their index.
+func importColumns(header []string) (map[string]int, error) {
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case importColumnName, importColumnEmail, importColumnPassword, importColumnRoles:
+			if _, ok := columns[name]; ok {
+				return nil, fmt.Errorf("column %q appears more than once", name)
+			}
+			columns[name] = i
+		}
+	}
+
+	for _, required := range []string{importColumnEmail, importColumnPassword} {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+	return columns, nil
+}
+
+func splitRoles(value string) []string {
+	var roles []string
+	for _, role := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == '|' }) {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+func isBlankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
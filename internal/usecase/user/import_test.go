@@ -0,0 +1,84 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var importRecords = [][]string{
+	{"Name", "Email", "Password", "Roles"},
+	{"Jane", "jane@example.com", "Secret#123", "admin;user"},
+	{"", "bad-email", "Secret#123", ""},
+	{"Jane Again", "JANE@example.com", "Secret#123", ""},
+	{"", "", "", ""},
+	{"Taken", "taken@example.com", "weak", "OWNER"},
+	{"John", "john@example.com", "Secret#123", ""},
+}
+
+func newImportUsecase() (*uc.Usecase, *MockUserRepository) {
+	mockRepo := new(MockUserRepository)
+	mockRepo.On("FindAllRoles").Return([]string{"ADMIN", "USER"}, nil)
+	mockRepo.On("FindRegisteredEmails", []string{"jane@example.com", "taken@example.com", "john@example.com"}).Return([]string{"taken@example.com"}, nil).Once()
+	return uc.New(mockRepo, uc.Deps{}), mockRepo
+}
+
+func TestImportUsers(t *testing.T) {
+	t.Run("Dry run validates without writing", func(t *testing.T) {
+		usecase, mockRepo := newImportUsecase()
+
+		report, err := usecase.ImportUsers(context.Background(), importRecords, uc.ImportOptions{DryRun: true})
+
+		assert.NoError(t, err)
+		assert.True(t, report.DryRun)
+		assert.Equal(t, 5, report.Total) // blank line skipped
+		assert.Equal(t, 2, report.Valid)
+		assert.Equal(t, 3, report.Invalid)
+		assert.Equal(t, 0, report.Created)
+
+		assert.Equal(t, user.ImportStatusValid, report.Rows[0].Status)
+		assert.Equal(t, []string{"email is not valid"}, report.Rows[1].Errors)
+		assert.Equal(t, []string{"duplicate email, already on line 2"}, report.Rows[2].Errors)
+		assert.Equal(t, 6, report.Rows[3].Line)
+		assert.Equal(t, []string{"email is already registered", "password too short", `unknown role "OWNER"`}, report.Rows[3].Errors)
+		mockRepo.AssertNotCalled(t, "SaveAll", mock.Anything)
+		mockRepo.AssertNotCalled(t, "FindByEmail", mock.Anything)
+	})
+
+	t.Run("Saves valid rows per batch", func(t *testing.T) {
+		usecase, mockRepo := newImportUsecase()
+		ctx := user.WithActor(context.Background(), "admin-1")
+
+		mockRepo.On("SaveAll", mock.MatchedBy(func(users []user.User) bool {
+			return len(users) == 1 && users[0].Email == "jane@example.com" &&
+				assert.ObjectsAreEqual([]string{"ADMIN", "USER"}, users[0].Roles) &&
+				users[0].Password != "Secret#123" && users[0].CreatedBy == "admin-1"
+		})).Return(nil).Once()
+		mockRepo.On("SaveAll", mock.MatchedBy(func(users []user.User) bool {
+			return len(users) == 1 && users[0].Email == "john@example.com"
+		})).Return(errors.New("duplicate entry")).Once()
+
+		report, err := usecase.ImportUsers(ctx, importRecords, uc.ImportOptions{BatchSize: 1})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 1, report.Failed)
+		assert.Equal(t, user.ImportStatusCreated, report.Rows[0].Status)
+		assert.Equal(t, user.ImportStatusFailed, report.Rows[4].Status)
+		assert.Equal(t, []string{"duplicate entry"}, report.Rows[4].Errors)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Requires email and password columns", func(t *testing.T) {
+		usecase, _ := newImportUsecase()
+
+		_, err := usecase.ImportUsers(context.Background(), [][]string{{"name", "email"}}, uc.ImportOptions{})
+
+		assert.EqualError(t, err, `missing required column "password"`)
+	})
+}
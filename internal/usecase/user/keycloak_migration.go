@@ -0,0 +1,129 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// KeycloakMigrationOptions controls a bulk migration of local users to Keycloak.
+type KeycloakMigrationOptions struct {
+	BatchSize   int
+	Concurrency int
+	DryRun      bool
+	// AfterID resumes a previous run after the given user ID
+	AfterID string
+	// RequirePasswordUpdate creates users without a credential and forces a
+	// password reset instead of importing the bcrypt hash
+	RequirePasswordUpdate bool
+}
+
+type KeycloakMigrationFailure struct {
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	Error  string `json:"error"`
+}
+
+// KeycloakMigrationReport summarizes a migration run. LastID can be passed as
+// AfterID to resume an interrupted run. A dry run counts the users it would
+// migrate in WouldMigrate and leaves Migrated at 0.
+type KeycloakMigrationReport struct {
+	DryRun       bool                       `json:"dry_run"`
+	Processed    int                        `json:"processed"`
+	Migrated     int                        `json:"migrated"`
+	WouldMigrate int                        `json:"would_migrate"`
+	Failed       []KeycloakMigrationFailure `json:"failed"`
+	LastID       string                     `json:"last_id"`
+}
+
+// MigrateUsersToKeycloak creates a Keycloak account for every local user without a
+// keycloak_id, batch by batch, and links the returned Keycloak ID locally.
+func (u *Usecase) MigrateUsersToKeycloak(ctx context.Context, opts KeycloakMigrationOptions) (KeycloakMigrationReport, error) {
+	report := KeycloakMigrationReport{DryRun: opts.DryRun, LastID: opts.AfterID, Failed: []KeycloakMigrationFailure{}}
+
+	if u.keycloakService == nil {
+		return report, errors.New("keycloak service is not configured")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return report, err
+		}
+
+		users, err := u.repo.FindWithoutKeycloakID(ctx, report.LastID, opts.BatchSize)
+		if err != nil {
+			return report, err
+		}
+		if len(users) == 0 {
+			return report, nil
+		}
+
+		var (
+			wg  sync.WaitGroup
+			mu  sync.Mutex
+			sem = make(chan struct{}, opts.Concurrency)
+		)
+		for _, usr := range users {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(usr user.User) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				err := u.migrateUserToKeycloak(ctx, usr, opts)
+
+				mu.Lock()
+				defer mu.Unlock()
+				report.Processed++
+				if err != nil {
+					log.Printf("[Usecase] Keycloak migration failed for %s: %v", usr.Email, err)
+					report.Failed = append(report.Failed, KeycloakMigrationFailure{UserID: usr.ID, Email: usr.Email, Error: err.Error()})
+					return
+				}
+				if opts.DryRun {
+					report.WouldMigrate++
+					return
+				}
+				report.Migrated++
+			}(usr)
+		}
+		wg.Wait()
+
+		report.LastID = users[len(users)-1].ID
+		log.Printf("[Usecase] Keycloak migration: processed=%d migrated=%d would_migrate=%d failed=%d last_id=%s",
+			report.Processed, report.Migrated, report.WouldMigrate, len(report.Failed), report.LastID)
+
+		if len(users) < opts.BatchSize {
+			return report, nil
+		}
+	}
+}
+
+func (u *Usecase) migrateUserToKeycloak(ctx context.Context, usr user.User, opts KeycloakMigrationOptions) error {
+	if opts.DryRun {
+		return nil
+	}
+
+	keycloakID, err := u.keycloakService.ImportUser(ctx, usr.Email, usr.Name, usr.Password, usr.Roles, opts.RequirePasswordUpdate)
+	if keycloakID == "" {
+		if err == nil {
+			err = errors.New("keycloak returned no user id")
+		}
+		return err
+	}
+
+	// Link even when role assignment failed so the user is not created twice
+	if linkErr := u.repo.UpdateKeycloakID(ctx, usr.ID, keycloakID); linkErr != nil {
+		return linkErr
+	}
+	return err
+}
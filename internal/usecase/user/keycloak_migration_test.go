@@ -0,0 +1,58 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMigrateUsersToKeycloak(t *testing.T) {
+	batch := []user.User{
+		{ID: "a", Name: "A", Email: "a@example.com", Password: "$2a$10$hash-a"},
+		{ID: "b", Name: "B", Email: "b@example.com", Password: "$2a$10$hash-b"},
+	}
+
+	t.Run("MigratesAndReportsFailures", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockKeycloak := new(MockKeycloakService)
+		usecase := uc.New(mockRepo, uc.Deps{Keycloak: mockKeycloak})
+
+		mockRepo.On("FindWithoutKeycloakID", "", 2).Return(batch, nil).Once()
+		mockRepo.On("FindWithoutKeycloakID", "b", 2).Return([]user.User{}, nil).Once()
+		mockKeycloak.On("ImportUser", "a@example.com", "A", "$2a$10$hash-a", []string(nil), false).Return("kc-a", nil).Once()
+		mockKeycloak.On("ImportUser", "b@example.com", "B", "$2a$10$hash-b", []string(nil), false).Return("", errors.New("boom")).Once()
+		mockRepo.On("UpdateKeycloakID", "a", "kc-a").Return(nil).Once()
+
+		report, err := usecase.MigrateUsersToKeycloak(context.Background(), uc.KeycloakMigrationOptions{BatchSize: 2, Concurrency: 2})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, report.Processed)
+		assert.Equal(t, 1, report.Migrated)
+		assert.Len(t, report.Failed, 1)
+		assert.Equal(t, "b", report.Failed[0].UserID)
+		assert.Equal(t, "b", report.LastID)
+		mockRepo.AssertExpectations(t)
+		mockKeycloak.AssertExpectations(t)
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockKeycloak := new(MockKeycloakService)
+		usecase := uc.New(mockRepo, uc.Deps{Keycloak: mockKeycloak})
+
+		mockRepo.On("FindWithoutKeycloakID", "resume", 10).Return(batch, nil).Once()
+
+		report, err := usecase.MigrateUsersToKeycloak(context.Background(), uc.KeycloakMigrationOptions{BatchSize: 10, AfterID: "resume", DryRun: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 0, report.Migrated)
+		assert.Equal(t, 2, report.WouldMigrate)
+		mockKeycloak.AssertNotCalled(t, "ImportUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		mockRepo.AssertNotCalled(t, "UpdateKeycloakID", mock.Anything, mock.Anything)
+	})
+}
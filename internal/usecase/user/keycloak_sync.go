@@ -0,0 +1,180 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// BackchannelLogout revokes the local sessions ended by Keycloak. When the logout
+// token carries a session ID only that session is revoked, otherwise every
+// session of the user identified by keycloakID is revoked.
+func (u *Usecase) BackchannelLogout(ctx context.Context, keycloakID, keycloakSessionID string) error {
+	if u.sessionRepo == nil {
+		return nil
+	}
+
+	if keycloakSessionID != "" {
+		revoked, err := u.sessionRepo.RevokeByKeycloakSessionID(ctx, keycloakSessionID)
+		if err != nil {
+			return apperror.Internal(err)
+		}
+		log.Printf("[Usecase] Back-channel logout: revoked %d session(s) for sid=%s", revoked, keycloakSessionID)
+		return nil
+	}
+
+	existingUser, err := u.repo.FindByKeycloakID(ctx, keycloakID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			// Nothing to revoke for users we don't know about
+			return nil
+		}
+		return apperror.Internal(err)
+	}
+
+	revoked, err := u.sessionRepo.RevokeByUserID(ctx, existingUser.ID)
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	log.Printf("[Usecase] Back-channel logout: revoked %d session(s) for user %s", revoked, existingUser.ID)
+	return nil
+}
+
+// SyncKeycloakEvents applies the Keycloak admin events recorded after since to the
+// local users and user_roles tables. It returns the time of the last applied event,
+// which should be passed as since on the next call. since is inclusive, so the
+// events at that time are applied again, which is harmless: applying an event
+// reloads the user from Keycloak.
+func (u *Usecase) SyncKeycloakEvents(ctx context.Context, since time.Time) (time.Time, error) {
+	if u.keycloakService == nil {
+		return since, nil
+	}
+
+	events, err := u.keycloakService.AdminEvents(ctx, since)
+	if err != nil {
+		return since, err
+	}
+
+	// Apply in chronological order so the last write wins
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	latest := since
+	for _, event := range events {
+		if err := u.applyKeycloakEvent(ctx, event); err != nil {
+			// Stop here so the failed event is retried on the next run
+			return latest, err
+		}
+		latest = event.Time
+	}
+
+	return latest, nil
+}
+
+func (u *Usecase) applyKeycloakEvent(ctx context.Context, event user.KeycloakAdminEvent) error {
+	keycloakID := event.UserID()
+	if keycloakID == "" {
+		return nil
+	}
+
+	existingUser, err := u.repo.FindByKeycloakID(ctx, keycloakID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			// User was never linked locally
+			return nil
+		}
+		return err
+	}
+
+	switch event.ResourceType {
+	case user.KeycloakResourceUser:
+		switch event.OperationType {
+		case user.KeycloakOperationDelete:
+			log.Printf("[Usecase] Keycloak user %s deleted, removing local user %s", keycloakID, existingUser.ID)
+			return u.withinTx(ctx, func(ctx context.Context) error {
+				if err := u.repo.Delete(ctx, existingUser.ID, user.ActorFromContext(ctx)); err != nil {
+					return err
+				}
+				return u.revokeSessions(ctx, existingUser.ID)
+			})
+
+		case user.KeycloakOperationUpdate:
+			return u.syncKeycloakUser(ctx, existingUser)
+		}
+
+	case user.KeycloakResourceRealmRoleMapping:
+		return u.syncKeycloakRoles(ctx, existingUser)
+	}
+
+	return nil
+}
+
+func (u *Usecase) syncKeycloakUser(ctx context.Context, existingUser user.User) error {
+	kcUser, err := u.keycloakService.GetUser(ctx, existingUser.KeycloakID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			// Deleted afterwards, the DELETE event will handle it
+			return nil
+		}
+		return err
+	}
+
+	if kcUser.Email != "" {
+		existingUser.Email = kcUser.Email
+	}
+	if name := kcUser.FullName(); name != "" {
+		existingUser.Name = name
+	}
+
+	if err := u.repo.Update(ctx, existingUser); err != nil {
+		return err
+	}
+
+	// Also reached through our own SetUserEnabled calls, only act on a real change
+	if existingUser.IsActive == kcUser.Enabled {
+		return nil
+	}
+	return u.withinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.UpdateActive(ctx, existingUser.ID, kcUser.Enabled, user.ActorFromContext(ctx)); err != nil {
+			return err
+		}
+		if !kcUser.Enabled {
+			return u.revokeSessions(ctx, existingUser.ID)
+		}
+		return nil
+	})
+}
+
+// syncKeycloakRoles replaces the local roles of the user with the Keycloak realm
+// roles that also exist locally (matched case-insensitively).
+func (u *Usecase) syncKeycloakRoles(ctx context.Context, existingUser user.User) error {
+	kcRoles, err := u.keycloakService.GetUserRealmRoles(ctx, existingUser.KeycloakID)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return nil
+		}
+		return err
+	}
+
+	roles, err := u.knownRoles(ctx, kcRoles)
+	if err != nil {
+		return err
+	}
+
+	existingUser.Roles = roles
+	return u.repo.Update(ctx, existingUser)
+}
+
+func (u *Usecase) revokeSessions(ctx context.Context, userID string) error {
+	if u.sessionRepo == nil {
+		return nil
+	}
+	_, err := u.sessionRepo.RevokeByUserID(ctx, userID)
+	return err
+}
@@ -0,0 +1,127 @@
+package user_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBackchannelLogout(t *testing.T) {
+	t.Run("RevokeBySessionID", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockSessions := new(MockSessionRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Sessions: mockSessions})
+
+		mockSessions.On("RevokeByKeycloakSessionID", "kc-sid").Return(int64(1), nil).Once()
+
+		err := usecase.BackchannelLogout(context.Background(), "kc-sub", "kc-sid")
+
+		assert.NoError(t, err)
+		mockSessions.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "FindByKeycloakID", mock.Anything)
+	})
+
+	t.Run("RevokeBySubject", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockSessions := new(MockSessionRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Sessions: mockSessions})
+
+		mockRepo.On("FindByKeycloakID", "kc-sub").Return(user.User{ID: "user-1", KeycloakID: "kc-sub"}, nil).Once()
+		mockSessions.On("RevokeByUserID", "user-1").Return(int64(2), nil).Once()
+
+		err := usecase.BackchannelLogout(context.Background(), "kc-sub", "")
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("UnknownSubject", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockSessions := new(MockSessionRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Sessions: mockSessions})
+
+		mockRepo.On("FindByKeycloakID", "kc-unknown").Return(user.User{}, user.ErrUserNotFound).Once()
+
+		err := usecase.BackchannelLogout(context.Background(), "kc-unknown", "")
+
+		assert.NoError(t, err)
+		mockSessions.AssertNotCalled(t, "RevokeByUserID", mock.Anything)
+	})
+}
+
+func TestSyncKeycloakEvents(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	existing := user.User{ID: "user-1", KeycloakID: "kc-1", Name: "Old", Email: "old@example.com", Roles: []string{"USER"}, IsActive: true}
+
+	t.Run("UserUpdatedAndDisabled", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockKeycloak := new(MockKeycloakService)
+		mockSessions := new(MockSessionRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Keycloak: mockKeycloak, Sessions: mockSessions})
+
+		eventTime := since.Add(time.Minute)
+		mockKeycloak.On("AdminEvents", since).Return([]user.KeycloakAdminEvent{
+			{Time: eventTime, OperationType: user.KeycloakOperationUpdate, ResourceType: user.KeycloakResourceUser, ResourcePath: "users/kc-1"},
+		}, nil).Once()
+		mockKeycloak.On("GetUser", "kc-1").Return(user.KeycloakUser{ID: "kc-1", Email: "new@example.com", FirstName: "New", LastName: "Name", Enabled: false}, nil).Once()
+		mockRepo.On("FindByKeycloakID", "kc-1").Return(existing, nil).Once()
+		mockRepo.On("Update", mock.MatchedBy(func(u user.User) bool {
+			return u.Email == "new@example.com" && u.Name == "New Name"
+		})).Return(nil).Once()
+		mockRepo.On("UpdateActive", "user-1", false, "").Return(nil).Once()
+		mockSessions.On("RevokeByUserID", "user-1").Return(int64(1), nil).Once()
+
+		latest, err := usecase.SyncKeycloakEvents(context.Background(), since)
+
+		assert.NoError(t, err)
+		assert.Equal(t, eventTime, latest)
+		mockRepo.AssertExpectations(t)
+		mockKeycloak.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("RoleMappingChanged", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockKeycloak := new(MockKeycloakService)
+		usecase := uc.New(mockRepo, uc.Deps{Keycloak: mockKeycloak})
+
+		mockKeycloak.On("AdminEvents", since).Return([]user.KeycloakAdminEvent{
+			{Time: since.Add(time.Minute), OperationType: user.KeycloakOperationCreate, ResourceType: user.KeycloakResourceRealmRoleMapping, ResourcePath: "users/kc-1/role-mappings/realm"},
+		}, nil).Once()
+		mockKeycloak.On("GetUserRealmRoles", "kc-1").Return([]string{"admin", "offline_access"}, nil).Once()
+		mockRepo.On("FindByKeycloakID", "kc-1").Return(existing, nil).Once()
+		mockRepo.On("FindAllRoles").Return([]string{"ADMIN", "USER"}, nil).Once()
+		mockRepo.On("Update", mock.MatchedBy(func(u user.User) bool {
+			return assert.ObjectsAreEqual([]string{"ADMIN"}, u.Roles)
+		})).Return(nil).Once()
+
+		_, err := usecase.SyncKeycloakEvents(context.Background(), since)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockKeycloak.AssertExpectations(t)
+	})
+
+	t.Run("UserDeleted", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockKeycloak := new(MockKeycloakService)
+		usecase := uc.New(mockRepo, uc.Deps{Keycloak: mockKeycloak})
+
+		mockKeycloak.On("AdminEvents", since).Return([]user.KeycloakAdminEvent{
+			{Time: since.Add(time.Minute), OperationType: user.KeycloakOperationDelete, ResourceType: user.KeycloakResourceUser, ResourcePath: "users/kc-1"},
+		}, nil).Once()
+		mockRepo.On("FindByKeycloakID", "kc-1").Return(existing, nil).Once()
+		mockRepo.On("Delete", "user-1", "").Return(nil).Once()
+
+		_, err := usecase.SyncKeycloakEvents(context.Background(), since)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,108 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// DeactivationBatchSize bounds the users deactivated by a single DeactivateDue run.
+const DeactivationBatchSize = 100
+
+// Activate enables a user again. Activating an active user cancels its
+// scheduled deactivation, if any.
+func (u *Usecase) Activate(ctx context.Context, id string) error {
+	return u.setActive(ctx, id, true)
+}
+
+// Deactivate disables a user right away, or at the given time when at is set.
+// Deactivated users cannot log in and their tokens stop working.
+func (u *Usecase) Deactivate(ctx context.Context, id string, at *time.Time) error {
+	if id == user.ActorFromContext(ctx) {
+		return apperror.NewForbiddenError("cannot deactivate your own account")
+	}
+
+	if at == nil {
+		return u.setActive(ctx, id, false)
+	}
+
+	if !at.After(time.Now()) {
+		return apperror.BadRequest("deactivation date must be in the future", nil)
+	}
+
+	existingUser, err := u.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !existingUser.IsActive {
+		return apperror.BadRequest("user is already inactive", nil).WithCode(apperror.UserInactive)
+	}
+
+	if err := u.repo.ScheduleDeactivation(ctx, id, at, user.ActorFromContext(ctx)); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return apperror.NotFound("User tidak ditemukan", err).WithCode(apperror.UserNotFound)
+		}
+		return apperror.Internal(err)
+	}
+	return nil
+}
+
+// DeactivateDue deactivates the users whose scheduled deactivation has passed.
+// A user that fails is logged and retried on the next run.
+func (u *Usecase) DeactivateDue(ctx context.Context) (int, error) {
+	due, err := u.repo.FindDueDeactivations(ctx, time.Now(), DeactivationBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	deactivated := 0
+	for _, usr := range due {
+		if err := ctx.Err(); err != nil {
+			return deactivated, err
+		}
+		if err := u.setActive(ctx, usr.ID, false); err != nil {
+			log.Printf("[Usecase] Scheduled deactivation of user %s failed: %v", usr.ID, err)
+			continue
+		}
+		deactivated++
+	}
+
+	if deactivated > 0 {
+		log.Printf("[Usecase] Deactivated %d user(s) on schedule", deactivated)
+	}
+	return deactivated, nil
+}
+
+// setActive enables or disables a user, in Keycloak first so a failure there
+// leaves the local user untouched. Disabled users lose their sessions.
+func (u *Usecase) setActive(ctx context.Context, id string, active bool) error {
+	existingUser, err := u.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if existingUser.KeycloakID != "" && u.keycloakService != nil {
+		err := u.keycloakService.SetUserEnabled(ctx, existingUser.KeycloakID, active)
+		if err != nil && !errors.Is(err, user.ErrUserNotFound) {
+			return err
+		}
+	}
+
+	err = u.withinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.UpdateActive(ctx, id, active, user.ActorFromContext(ctx)); err != nil {
+			return err
+		}
+		if !active {
+			return u.revokeSessions(ctx, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return apperror.Internal(err)
+	}
+	return nil
+}
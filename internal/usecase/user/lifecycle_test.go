@@ -0,0 +1,159 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestDeactivate(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "admin")
+
+	t.Run("Now", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockKeycloak := new(MockKeycloakService)
+		mockSessions := new(MockSessionRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Keycloak: mockKeycloak, Sessions: mockSessions})
+
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", KeycloakID: "kc-1", IsActive: true}, nil).Once()
+		mockKeycloak.On("SetUserEnabled", "kc-1", false).Return(nil).Once()
+		mockRepo.On("UpdateActive", "1", false, "admin").Return(nil).Once()
+		mockSessions.On("RevokeByUserID", "1").Return(int64(1), nil).Once()
+
+		err := usecase.Deactivate(ctx, "1", nil)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockKeycloak.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("KeycloakFailureKeepsLocalUser", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockKeycloak := new(MockKeycloakService)
+		usecase := uc.New(mockRepo, uc.Deps{Keycloak: mockKeycloak})
+
+		upstream := apperror.NewAppError(http.StatusBadGateway, apperror.ExternalServiceError, "failed to update user", nil)
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", KeycloakID: "kc-1", IsActive: true}, nil).Once()
+		mockKeycloak.On("SetUserEnabled", "kc-1", false).Return(upstream).Once()
+
+		err := usecase.Deactivate(ctx, "1", nil)
+
+		assert.Equal(t, upstream, err)
+		mockRepo.AssertNotCalled(t, "UpdateActive", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("Scheduled", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		at := time.Now().Add(24 * time.Hour)
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", IsActive: true}, nil).Once()
+		mockRepo.On("ScheduleDeactivation", "1", &at, "admin").Return(nil).Once()
+
+		err := usecase.Deactivate(ctx, "1", &at)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+		mockRepo.AssertNotCalled(t, "UpdateActive", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("PastDate", func(t *testing.T) {
+		usecase := uc.New(new(MockUserRepository), uc.Deps{})
+
+		at := time.Now().Add(-time.Hour)
+		err := usecase.Deactivate(ctx, "1", &at)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusBadRequest, appErr.Code)
+	})
+
+	t.Run("Self", func(t *testing.T) {
+		usecase := uc.New(new(MockUserRepository), uc.Deps{})
+
+		err := usecase.Deactivate(ctx, "admin", nil)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusForbidden, appErr.Code)
+	})
+}
+
+func TestActivate(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockKeycloak := new(MockKeycloakService)
+	usecase := uc.New(mockRepo, uc.Deps{Keycloak: mockKeycloak})
+
+	mockRepo.On("FindByID", "1").Return(user.User{ID: "1", KeycloakID: "kc-1"}, nil).Once()
+	mockKeycloak.On("SetUserEnabled", "kc-1", true).Return(user.ErrUserNotFound).Once()
+	mockRepo.On("UpdateActive", "1", true, "").Return(nil).Once()
+
+	err := usecase.Activate(context.Background(), "1")
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockKeycloak.AssertExpectations(t)
+}
+
+func TestDeactivateDue(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRepository)
+	usecase := uc.New(mockRepo, uc.Deps{Sessions: mockSessions})
+
+	mockRepo.On("FindDueDeactivations", mock.Anything, uc.DeactivationBatchSize).
+		Return([]user.User{{ID: "1"}, {ID: "2"}}, nil).Once()
+	mockRepo.On("FindByID", "1").Return(user.User{ID: "1", IsActive: true}, nil).Once()
+	mockRepo.On("FindByID", "2").Return(user.User{}, user.ErrUserNotFound).Once()
+	mockRepo.On("UpdateActive", "1", false, "").Return(nil).Once()
+	mockSessions.On("RevokeByUserID", "1").Return(int64(0), nil).Once()
+
+	deactivated, err := usecase.DeactivateDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, deactivated)
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestInactiveUserLogin(t *testing.T) {
+	t.Run("Password", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{ID: "1", Email: "a@example.com", Password: string(hash)}, nil).Once()
+
+		token, err := usecase.Login(context.Background(), "a@example.com", "secret")
+
+		var appErr *apperror.AppError
+		assert.Empty(t, token)
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperror.UserInactive, appErr.ErrorCode)
+	})
+
+	t.Run("Identity", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockIdentities := new(MockIdentityRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Identities: mockIdentities})
+
+		mockIdentities.On("FindByProviderSubject", "google", "g-1").Return(user.Identity{UserID: "1"}, nil).Once()
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Email: "a@example.com"}, nil).Once()
+
+		token, err := usecase.LoginWithIdentity(context.Background(), user.ExternalIdentity{Provider: "google", Subject: "g-1", Email: "a@example.com"})
+
+		var appErr *apperror.AppError
+		assert.Empty(t, token)
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperror.UserInactive, appErr.ErrorCode)
+	})
+}
@@ -0,0 +1,85 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLogin(t *testing.T) {
+	t.Run("LocalFallback", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{ID: "1", Email: "a@example.com", IsActive: true, Password: string(hash)}, nil).Once()
+
+		token, err := usecase.Login(context.Background(), "a@example.com", "secret")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("InvalidCredentials", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockAuth := new(MockAuthService)
+		usecase := uc.New(mockRepo, uc.Deps{AuthService: mockAuth})
+
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{ID: "1", Email: "a@example.com", IsActive: true}, nil).Once()
+		mockAuth.On("Authenticate", "a@example.com", "wrong", mock.Anything).Return(user.AuthResult{}, user.ErrInvalidCredentials).Once()
+
+		_, err := usecase.Login(context.Background(), "a@example.com", "wrong")
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperror.InvalidCredentials, appErr.ErrorCode)
+	})
+
+	t.Run("ProvisionsExternalUser", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockAuth := new(MockAuthService)
+		mockSessions := new(MockSessionRepository)
+		usecase := uc.New(mockRepo, uc.Deps{AuthService: mockAuth, Sessions: mockSessions})
+
+		mockRepo.On("FindByEmail", "new@example.com").Return(user.User{}, user.ErrUserNotFound).Once()
+		mockAuth.On("Authenticate", "new@example.com", "secret", (*user.User)(nil)).
+			Return(user.AuthResult{Provider: "ldap", Name: "New User", Roles: []string{"admin", "unknown"}}, nil).Once()
+		mockRepo.On("FindAllRoles").Return([]string{"ADMIN", "USER"}, nil).Once()
+		mockRepo.On("Save", mock.MatchedBy(func(u user.User) bool {
+			return u.Email == "new@example.com" && u.Name == "New User" && len(u.Roles) == 1 && u.Roles[0] == "ADMIN" && u.Password != ""
+		})).Return(nil).Once()
+		mockRepo.On("FindByEmail", "new@example.com").Return(user.User{ID: "2", Email: "new@example.com", IsActive: true, Roles: []string{"ADMIN"}}, nil).Once()
+		mockSessions.On("Create", mock.MatchedBy(func(s user.Session) bool {
+			return s.UserID == "2" && s.AuthProvider == "ldap"
+		})).Return(nil).Once()
+
+		token, err := usecase.Login(context.Background(), "new@example.com", "secret")
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, token)
+		mockRepo.AssertExpectations(t)
+		mockSessions.AssertExpectations(t)
+	})
+
+	t.Run("ProviderError", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockAuth := new(MockAuthService)
+		usecase := uc.New(mockRepo, uc.Deps{AuthService: mockAuth})
+
+		upstream := apperror.NewAppError(502, apperror.ExternalServiceError, "ldap request failed", nil)
+		mockRepo.On("FindByEmail", "a@example.com").Return(user.User{ID: "1"}, nil).Once()
+		mockAuth.On("Authenticate", "a@example.com", "secret", mock.Anything).Return(user.AuthResult{}, upstream).Once()
+
+		_, err := usecase.Login(context.Background(), "a@example.com", "secret")
+
+		assert.Equal(t, upstream, err)
+	})
+}
@@ -0,0 +1,89 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/pkg/mergepatch"
+)
+
+// Patch applies a JSON Merge Patch (RFC 7396) to the name, email and roles of
+// a user. Members missing from the patch are left untouched. A non-zero
+// version must match the current one, otherwise a 412 is returned.
+func (u *Usecase) Patch(ctx context.Context, id string, patch []byte, version int) (user.User, error) {
+	existingUser, err := u.repo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return user.User{}, apperror.NotFound(
+				"User tidak ditemukan",
+				err,
+			).WithCode(apperror.UserNotFound)
+		}
+
+		return user.User{}, apperror.Internal(err)
+	}
+
+	if version != 0 && version != existingUser.Version {
+		return user.User{}, versionMismatch(user.ErrVersionConflict)
+	}
+
+	doc, err := json.Marshal(user.UserPatch{
+		Name:  existingUser.Name,
+		Email: existingUser.Email,
+		Roles: existingUser.Roles,
+	})
+	if err != nil {
+		return user.User{}, apperror.Internal(err)
+	}
+
+	merged, err := mergepatch.Apply(doc, patch)
+	if err != nil {
+		return user.User{}, apperror.BadRequest("invalid merge patch", err)
+	}
+
+	var patched user.UserPatch
+	decoder := json.NewDecoder(bytes.NewReader(merged))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patched); err != nil {
+		return user.User{}, apperror.BadRequest("invalid merge patch", err)
+	}
+
+	if patched.Email == "" {
+		return user.User{}, apperror.BadRequest("email is required", nil).
+			WithCode(apperror.ValidationError)
+	}
+
+	roles, err := u.checkRoles(ctx, patched.Roles)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	existingUser.Name = patched.Name
+	existingUser.Email = patched.Email
+	existingUser.Roles = roles
+	existingUser.UpdatedBy = user.ActorFromContext(ctx)
+
+	if err := u.repo.Update(ctx, existingUser); err != nil {
+		if errors.Is(err, user.ErrVersionConflict) {
+			return user.User{}, versionMismatch(err)
+		}
+		return user.User{}, apperror.Internal(err)
+	}
+
+	return u.GetByID(ctx, id)
+}
+
+// versionMismatch is the 412 returned when a user changed since it was read.
+func versionMismatch(err error) error {
+	return apperror.NewAppError(
+		http.StatusPreconditionFailed,
+		apperror.PreconditionFailed,
+		"user has been modified, reload it and retry",
+		err,
+	)
+}
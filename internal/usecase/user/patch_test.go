@@ -0,0 +1,124 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPatch(t *testing.T) {
+	current := user.User{ID: "1", Name: "Old", Email: "old@example.com", Roles: []string{"ADMIN"}, Version: 3}
+
+	t.Run("Keeps omitted members", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindByID", "1").Return(current, nil).Once()
+		mockRepo.On("FindAllRoles").Return([]string{"ADMIN", "USER"}, nil).Once()
+		mockRepo.On("Update", mock.MatchedBy(func(u user.User) bool {
+			return u.Name == "New" && u.Email == "old@example.com" &&
+				len(u.Roles) == 1 && u.Roles[0] == "ADMIN" && u.Version == 3
+		})).Return(nil).Once()
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Name: "New", Version: 4}, nil).Once()
+
+		updated, err := usecase.Patch(context.Background(), "1", []byte(`{"name":"New"}`), 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, updated.Version)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Null removes roles", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindByID", "1").Return(current, nil)
+		mockRepo.On("Update", mock.MatchedBy(func(u user.User) bool {
+			return len(u.Roles) == 0
+		})).Return(nil).Once()
+
+		_, err := usecase.Patch(context.Background(), "1", []byte(`{"roles":null}`), 0)
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Stale If-Match", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindByID", "1").Return(current, nil).Once()
+
+		_, err := usecase.Patch(context.Background(), "1", []byte(`{"name":"New"}`), 2)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusPreconditionFailed, appErr.Code)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("Concurrent write", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindByID", "1").Return(current, nil).Once()
+		mockRepo.On("FindAllRoles").Return([]string{"ADMIN", "USER"}, nil).Once()
+		mockRepo.On("Update", mock.Anything).Return(user.ErrVersionConflict).Once()
+
+		_, err := usecase.Patch(context.Background(), "1", []byte(`{"name":"New"}`), 0)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusPreconditionFailed, appErr.Code)
+		assert.Equal(t, apperror.PreconditionFailed, appErr.ErrorCode)
+	})
+
+	t.Run("Rejects unknown roles", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindByID", "1").Return(current, nil).Once()
+		mockRepo.On("FindAllRoles").Return([]string{"ADMIN", "USER"}, nil).Once()
+
+		_, err := usecase.Patch(context.Background(), "1", []byte(`{"roles":["admin","OWNER"]}`), 0)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusBadRequest, appErr.Code)
+		assert.Equal(t, apperror.ValidationError, appErr.ErrorCode)
+		mockRepo.AssertNotCalled(t, "Update", mock.Anything)
+	})
+
+	t.Run("Rejects unknown members", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindByID", "1").Return(current, nil).Once()
+
+		_, err := usecase.Patch(context.Background(), "1", []byte(`{"password":"x"}`), 0)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusBadRequest, appErr.Code)
+	})
+
+	t.Run("Email cannot be removed", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindByID", "1").Return(current, nil).Once()
+
+		_, err := usecase.Patch(context.Background(), "1", []byte(`{"email":null}`), 0)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusBadRequest, appErr.Code)
+	})
+}
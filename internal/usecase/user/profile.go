@@ -0,0 +1,103 @@
+package user
+
+import (
+	"context"
+	"errors"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+)
+
+// UpdateProfile replaces the profile of a user. Custom attributes are validated
+// against the attribute definitions. A non-zero version must match the current
+// one, otherwise a 412 is returned.
+func (u *Usecase) UpdateProfile(ctx context.Context, id string, profile user.Profile, version int) (user.User, error) {
+	existingUser, err := u.GetByID(ctx, id)
+	if err != nil {
+		return user.User{}, err
+	}
+
+	if version != 0 && version != existingUser.Version {
+		return user.User{}, versionMismatch(user.ErrVersionConflict)
+	}
+
+	if err := u.validateAttributes(ctx, profile.Attributes); err != nil {
+		return user.User{}, err
+	}
+
+	existingUser.Profile = profile
+	existingUser.UpdatedBy = user.ActorFromContext(ctx)
+	if err := u.repo.UpdateProfile(ctx, existingUser); err != nil {
+		if errors.Is(err, user.ErrVersionConflict) {
+			return user.User{}, versionMismatch(err)
+		}
+		return user.User{}, apperror.Internal(err)
+	}
+
+	return u.GetByID(ctx, id)
+}
+
+func (u *Usecase) validateAttributes(ctx context.Context, attrs map[string]interface{}) error {
+	var defs []user.AttributeDefinition
+	if u.attributeRepo != nil {
+		var err error
+		if defs, err = u.attributeRepo.FindAll(ctx); err != nil {
+			return apperror.Internal(err)
+		}
+	}
+
+	errs := user.ValidateAttributes(defs, attrs)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	fieldErrs := apperror.FieldErrors{}
+	for name, msg := range errs {
+		fieldErrs["attributes."+name] = msg
+	}
+	return apperror.NewValidationError(fieldErrs)
+}
+
+// Attributes returns the definitions of the custom profile attributes.
+func (u *Usecase) Attributes(ctx context.Context) ([]user.AttributeDefinition, error) {
+	defs, err := u.attributeRepo.FindAll(ctx)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	return defs, nil
+}
+
+// SaveAttribute creates or replaces a custom attribute definition. Values
+// stored under the previous definition are checked again on the next profile update.
+func (u *Usecase) SaveAttribute(ctx context.Context, def user.AttributeDefinition) (user.AttributeDefinition, error) {
+	if err := def.Validate(); err != nil {
+		return user.AttributeDefinition{}, apperror.BadRequest(err.Error(), err)
+	}
+
+	if err := u.attributeRepo.Save(ctx, def); err != nil {
+		return user.AttributeDefinition{}, apperror.Internal(err)
+	}
+
+	defs, err := u.attributeRepo.FindAll(ctx)
+	if err != nil {
+		return user.AttributeDefinition{}, apperror.Internal(err)
+	}
+	for _, saved := range defs {
+		if saved.Name == def.Name {
+			return saved, nil
+		}
+	}
+	return def, nil
+}
+
+// DeleteAttribute removes a custom attribute definition. Values already stored
+// on users are kept but rejected on their next profile update.
+func (u *Usecase) DeleteAttribute(ctx context.Context, name string) error {
+	if err := u.attributeRepo.Delete(ctx, name); err != nil {
+		if errors.Is(err, user.ErrAttributeNotFound) {
+			return apperror.NotFound("attribute not found", err)
+		}
+		return apperror.Internal(err)
+	}
+	return nil
+}
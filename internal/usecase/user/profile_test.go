@@ -0,0 +1,115 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestUpdateProfile(t *testing.T) {
+	ctx := user.WithActor(context.Background(), "admin")
+	defs := []user.AttributeDefinition{
+		{Name: "cost_center", Type: user.AttributeNumber, Required: true},
+		{Name: "shift", Type: user.AttributeString, Options: []string{"day", "night"}},
+		{Name: "hired_on", Type: user.AttributeDate},
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockAttributes := new(MockAttributeRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Attributes: mockAttributes})
+
+		profile := user.Profile{
+			Department: "Finance",
+			Timezone:   "Asia/Jakarta",
+			Attributes: map[string]interface{}{"cost_center": float64(42), "shift": "night", "hired_on": nil},
+		}
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Version: 3}, nil).Once()
+		mockAttributes.On("FindAll").Return(defs, nil).Once()
+		mockRepo.On("UpdateProfile", mock.MatchedBy(func(u user.User) bool {
+			_, hasNull := u.Profile.Attributes["hired_on"]
+			return u.Version == 3 && u.UpdatedBy == "admin" && u.Profile.Department == "Finance" && !hasNull
+		})).Return(nil).Once()
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Version: 4, Profile: profile}, nil).Once()
+
+		updated, err := usecase.UpdateProfile(ctx, "1", profile, 3)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 4, updated.Version)
+		mockRepo.AssertExpectations(t)
+		mockAttributes.AssertExpectations(t)
+	})
+
+	t.Run("InvalidAttributes", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockAttributes := new(MockAttributeRepository)
+		usecase := uc.New(mockRepo, uc.Deps{Attributes: mockAttributes})
+
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Version: 1}, nil).Once()
+		mockAttributes.On("FindAll").Return(defs, nil).Once()
+
+		_, err := usecase.UpdateProfile(ctx, "1", user.Profile{
+			Attributes: map[string]interface{}{"shift": "evening", "hired_on": "31/12/2026", "unknown": true},
+		}, 0)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperror.ValidationError, appErr.ErrorCode)
+		assert.Equal(t, apperror.FieldErrors{
+			"attributes.cost_center": "is required",
+			"attributes.shift":       "must be one of the allowed options",
+			"attributes.hired_on":    "must be a date (YYYY-MM-DD)",
+			"attributes.unknown":     "is not a defined attribute",
+		}, appErr.Err)
+		mockRepo.AssertNotCalled(t, "UpdateProfile", mock.Anything)
+	})
+
+	t.Run("StaleVersion", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", Version: 5}, nil).Once()
+
+		_, err := usecase.UpdateProfile(ctx, "1", user.Profile{}, 4)
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusPreconditionFailed, appErr.Code)
+	})
+}
+
+func TestSaveAttribute(t *testing.T) {
+	t.Run("Invalid", func(t *testing.T) {
+		mockAttributes := new(MockAttributeRepository)
+		usecase := uc.New(nil, uc.Deps{Attributes: mockAttributes})
+
+		_, err := usecase.SaveAttribute(context.Background(), user.AttributeDefinition{Name: "level", Type: user.AttributeNumber, Options: []string{"1"}})
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusBadRequest, appErr.Code)
+		mockAttributes.AssertNotCalled(t, "Save", mock.Anything)
+	})
+
+	t.Run("Success", func(t *testing.T) {
+		mockAttributes := new(MockAttributeRepository)
+		usecase := uc.New(nil, uc.Deps{Attributes: mockAttributes})
+
+		def := user.AttributeDefinition{Name: "shift", Type: user.AttributeString, Options: []string{"day", "night"}}
+		mockAttributes.On("Save", def).Return(nil).Once()
+		mockAttributes.On("FindAll").Return([]user.AttributeDefinition{def}, nil).Once()
+
+		saved, err := usecase.SaveAttribute(context.Background(), def)
+
+		assert.NoError(t, err)
+		assert.Equal(t, def, saved)
+		mockAttributes.AssertExpectations(t)
+	})
+}
@@ -0,0 +1,113 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeleteRevokesSessions(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRepository)
+	usecase := uc.New(mockRepo, uc.Deps{Sessions: mockSessions})
+
+	mockRepo.On("FindByID", "1").Return(user.User{ID: "1"}, nil).Once()
+	mockRepo.On("Delete", "1", "").Return(nil).Once()
+	mockSessions.On("RevokeByUserID", "1").Return(int64(2), nil).Once()
+
+	assert.NoError(t, usecase.Delete(context.Background(), "1"))
+	mockRepo.AssertExpectations(t)
+	mockSessions.AssertExpectations(t)
+}
+
+func TestDeleteRolledBackWhenRevokeFails(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	mockSessions := new(MockSessionRepository)
+	tx := new(fakeTx)
+	usecase := uc.New(mockRepo, uc.Deps{Sessions: mockSessions, Tx: tx})
+
+	mockRepo.On("FindByID", "1").Return(user.User{ID: "1"}, nil).Once()
+	mockRepo.On("Delete", "1", "").Return(nil).Once()
+	mockSessions.On("RevokeByUserID", "1").Return(int64(0), errors.New("connection reset")).Once()
+
+	err := usecase.Delete(context.Background(), "1")
+
+	assert.Error(t, err)
+	assert.Equal(t, 1, tx.rolledBack)
+	assert.Equal(t, 0, tx.committed)
+}
+
+func TestRestore(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := uc.New(mockRepo, uc.Deps{})
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo.On("Restore", "1", "").Return(nil).Once()
+		assert.NoError(t, usecase.Restore(context.Background(), "1"))
+	})
+
+	t.Run("NotDeleted", func(t *testing.T) {
+		mockRepo.On("Restore", "2", "").Return(user.ErrUserNotFound).Once()
+
+		err := usecase.Restore(context.Background(), "2")
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperror.UserNotFound, appErr.ErrorCode)
+	})
+
+	t.Run("EmailTaken", func(t *testing.T) {
+		mockRepo.On("Restore", "3", "").Return(user.ErrEmailTaken).Once()
+
+		err := usecase.Restore(context.Background(), "3")
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusConflict, appErr.Code)
+		assert.Equal(t, apperror.UserAlreadyExists, appErr.ErrorCode)
+	})
+}
+
+func TestPurge(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := uc.New(mockRepo, uc.Deps{})
+
+	t.Run("Success", func(t *testing.T) {
+		mockRepo.On("Purge", "1").Return(nil).Once()
+		assert.NoError(t, usecase.Purge(context.Background(), "1"))
+	})
+
+	t.Run("NotDeleted", func(t *testing.T) {
+		mockRepo.On("Purge", "2").Return(user.ErrUserNotFound).Once()
+
+		err := usecase.Purge(context.Background(), "2")
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, apperror.UserNotFound, appErr.ErrorCode)
+	})
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := uc.New(mockRepo, uc.Deps{})
+
+	retention := 30 * 24 * time.Hour
+	mockRepo.On("PurgeDeletedBefore", mock.MatchedBy(func(cutoff time.Time) bool {
+		return time.Since(cutoff)-retention < time.Minute
+	})).Return(int64(3), nil).Once()
+
+	purged, err := usecase.PurgeDeleted(context.Background(), retention)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	mockRepo.AssertExpectations(t)
+}
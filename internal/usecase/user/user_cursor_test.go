@@ -0,0 +1,67 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/user"
+	"github.com/afandimsr/go-gin-api/internal/pkg/cursor"
+	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGetAllAfter(t *testing.T) {
+	cursor.SetSecret("test-secret")
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("NextCursor", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		usecase := uc.New(mockRepo, uc.Deps{})
+
+		mockRepo.On("FindAll", user.ListQuery{SortBy: user.SortByCreatedAt, SortDir: user.SortAsc, Limit: 3}).
+			Return([]user.User{{ID: "1", CreatedAt: created}, {ID: "2", CreatedAt: created}, {ID: "3", CreatedAt: created}}, nil).Once()
+
+		users, next, err := usecase.GetAllAfter(context.Background(), "", 2, user.ListQuery{})
+
+		assert.NoError(t, err)
+		assert.Len(t, users, 2)
+		assert.NotEmpty(t, next)
+
+		// The cursor points at the last returned row
+		mockRepo.On("FindAll", mock.MatchedBy(func(q user.ListQuery) bool {
+			return q.After != nil && q.After.ID == "2" && q.After.Value == "2026-01-01T00:00:00Z" && q.Offset == 0
+		})).Return([]user.User{{ID: "3", CreatedAt: created}}, nil).Once()
+
+		users, next, err = usecase.GetAllAfter(context.Background(), next, 2, user.ListQuery{})
+
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Empty(t, next)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("SortMismatch", func(t *testing.T) {
+		usecase := uc.New(new(MockUserRepository), uc.Deps{})
+		token, _ := cursor.Encode(user.NewCursor(user.User{ID: "1", CreatedAt: created}, user.SortByCreatedAt, user.SortAsc))
+
+		_, _, err := usecase.GetAllAfter(context.Background(), token, 10, user.ListQuery{SortBy: user.SortByName})
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, 400, appErr.Code)
+	})
+
+	t.Run("TamperedCursor", func(t *testing.T) {
+		usecase := uc.New(new(MockUserRepository), uc.Deps{})
+
+		_, _, err := usecase.GetAllAfter(context.Background(), "eyJpZCI6IjEifQ.AAAA", 10, user.ListQuery{})
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, 400, appErr.Code)
+	})
+}
@@ -1,15 +1,24 @@
 package user
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/file"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
+	"github.com/afandimsr/go-gin-api/internal/domain/transaction"
 	"github.com/afandimsr/go-gin-api/internal/domain/user"
 	"github.com/afandimsr/go-gin-api/internal/domain/valueobject"
 	pw "github.com/afandimsr/go-gin-api/internal/domain/valueobject"
+	"github.com/afandimsr/go-gin-api/internal/pkg/cursor"
+	"github.com/afandimsr/go-gin-api/internal/pkg/filecheck"
 	"github.com/afandimsr/go-gin-api/internal/pkg/jwt"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -17,23 +26,122 @@ type Usecase struct {
 	repo            user.UserRepository
 	authService     user.AuthService
 	keycloakService user.KeycloakService
+	sessionRepo     user.SessionRepository
+	identityRepo    user.IdentityRepository
+	attributeRepo   user.AttributeRepository
+	avatarStorage   storage.IS3Service
+	avatarChecks    *filecheck.Pipeline
+	quarantine      file.Quarantine
+	tx              transaction.Manager
 }
 
-func New(repo user.UserRepository, authService user.AuthService, ks user.KeycloakService) *Usecase {
+// Deps holds the dependencies of the usecase besides the user repository. The
+// features needing one left nil are unavailable.
+type Deps struct {
+	AuthService   user.AuthService
+	Keycloak      user.KeycloakService
+	Sessions      user.SessionRepository
+	Identities    user.IdentityRepository
+	Attributes    user.AttributeRepository
+	AvatarStorage storage.IS3Service
+	AvatarChecks  *filecheck.Pipeline
+	Quarantine    file.Quarantine
+	// Tx runs units of work in a transaction, without it they run directly
+	Tx transaction.Manager
+}
+
+func New(repo user.UserRepository, deps Deps) *Usecase {
 	return &Usecase{
 		repo:            repo,
-		authService:     authService,
-		keycloakService: ks,
+		authService:     deps.AuthService,
+		keycloakService: deps.Keycloak,
+		sessionRepo:     deps.Sessions,
+		identityRepo:    deps.Identities,
+		attributeRepo:   deps.Attributes,
+		avatarStorage:   deps.AvatarStorage,
+		avatarChecks:    deps.AvatarChecks,
+		quarantine:      deps.Quarantine,
+		tx:              deps.Tx,
 	}
 }
 
-func (u *Usecase) GetAll(page, limit int) ([]user.User, error) {
-	offset := (page - 1) * limit
-	return u.repo.FindAll(limit, offset)
+// withinTx runs fn as a unit of work, in a transaction unless no transaction
+// manager is configured.
+func (u *Usecase) withinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if u.tx == nil {
+		return fn(ctx)
+	}
+	return u.tx.WithinTx(ctx, fn)
 }
 
-func (u *Usecase) GetByID(id string) (user.User, error) {
-	availableUser, err := u.repo.FindByID(id)
+// GetAll returns one page of users matching query and the total number of matches.
+func (u *Usecase) GetAll(ctx context.Context, page, limit int, query user.ListQuery) ([]user.User, int64, error) {
+	if page < 1 || limit < 1 {
+		return nil, 0, apperror.BadRequest("page and limit must be positive", nil).WithCode(apperror.ValidationError)
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, 0, apperror.BadRequest(err.Error(), err).WithCode(apperror.ValidationError)
+	}
+
+	total, err := u.repo.Count(ctx, query)
+	if err != nil {
+		return nil, 0, apperror.Internal(err)
+	}
+
+	query.Limit = limit
+	query.Offset = (page - 1) * limit
+	if int64(query.Offset) >= total {
+		// Past the last page, skip the query
+		return []user.User{}, total, nil
+	}
+
+	users, err := u.repo.FindAll(ctx, query)
+	if err != nil {
+		return nil, 0, apperror.Internal(err)
+	}
+	return users, total, nil
+}
+
+// GetAllAfter returns up to limit users after the position encoded in cursorToken
+// (keyset pagination, an empty token starts at the beginning) and the cursor of
+// the next page, which is empty on the last page.
+func (u *Usecase) GetAllAfter(ctx context.Context, cursorToken string, limit int, query user.ListQuery) ([]user.User, string, error) {
+	if limit < 1 {
+		return nil, "", apperror.BadRequest("limit must be positive", nil).WithCode(apperror.ValidationError)
+	}
+
+	if cursorToken != "" {
+		var after user.Cursor
+		if err := cursor.Decode(cursorToken, &after); err != nil {
+			return nil, "", apperror.BadRequest("invalid cursor", err).WithCode(apperror.ValidationError)
+		}
+		query.After = &after
+	}
+	if err := query.Normalize(); err != nil {
+		return nil, "", apperror.BadRequest(err.Error(), err).WithCode(apperror.ValidationError)
+	}
+
+	// Fetch one extra row to know whether there is a next page
+	query.Limit = limit + 1
+	query.Offset = 0
+	users, err := u.repo.FindAll(ctx, query)
+	if err != nil {
+		return nil, "", apperror.Internal(err)
+	}
+	if len(users) <= limit {
+		return users, "", nil
+	}
+
+	users = users[:limit]
+	next, err := cursor.Encode(user.NewCursor(users[limit-1], query.SortBy, query.SortDir))
+	if err != nil {
+		return nil, "", apperror.Internal(err)
+	}
+	return users, next, nil
+}
+
+func (u *Usecase) GetByID(ctx context.Context, id string) (user.User, error) {
+	availableUser, err := u.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, user.ErrUserNotFound) {
 			return user.User{}, apperror.NotFound(
@@ -48,7 +156,7 @@ func (u *Usecase) GetByID(id string) (user.User, error) {
 	return availableUser, nil
 }
 
-func (u *Usecase) Create(newUser user.User) error {
+func (u *Usecase) Create(ctx context.Context, newUser user.User) error {
 	if newUser.Email == "" {
 		return apperror.BadRequest("email is required", nil)
 	}
@@ -63,21 +171,22 @@ func (u *Usecase) Create(newUser user.User) error {
 		return apperror.Internal(err)
 	}
 	newUser.Password = string(hashedPassword)
+	newUser.CreatedBy = user.ActorFromContext(ctx)
 
-	if err := u.repo.Save(newUser); err != nil {
+	if err := u.repo.Save(ctx, newUser); err != nil {
 		return apperror.Internal(err)
 	}
 
 	return nil
 }
 
-func (u *Usecase) Update(id string, updatedUser user.User) error {
+func (u *Usecase) Update(ctx context.Context, id string, updatedUser user.User) error {
 	if updatedUser.Email == "" {
 		return apperror.BadRequest("email is required", nil)
 	}
 
 	// Check if user exists
-	existingUser, err := u.repo.FindByID(id)
+	existingUser, err := u.repo.FindByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, user.ErrUserNotFound) {
 			return apperror.NotFound(
@@ -89,9 +198,20 @@ func (u *Usecase) Update(id string, updatedUser user.User) error {
 		return apperror.Internal(err)
 	}
 
+	// updatedUser.Version carries the If-Match precondition, 0 when absent
+	if updatedUser.Version != 0 && updatedUser.Version != existingUser.Version {
+		return versionMismatch(user.ErrVersionConflict)
+	}
+
+	roles, err := u.checkRoles(ctx, updatedUser.Roles)
+	if err != nil {
+		return err
+	}
+
 	existingUser.Name = updatedUser.Name
 	existingUser.Email = updatedUser.Email
-	existingUser.Roles = updatedUser.Roles
+	existingUser.Roles = roles
+	existingUser.UpdatedBy = user.ActorFromContext(ctx)
 
 	if updatedUser.Password != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(updatedUser.Password), bcrypt.DefaultCost)
@@ -101,16 +221,19 @@ func (u *Usecase) Update(id string, updatedUser user.User) error {
 		existingUser.Password = string(hashedPassword)
 	}
 
-	if err := u.repo.Update(existingUser); err != nil {
+	if err := u.repo.Update(ctx, existingUser); err != nil {
+		if errors.Is(err, user.ErrVersionConflict) {
+			return versionMismatch(err)
+		}
 		return apperror.Internal(err)
 	}
 
 	return nil
 }
 
-func (u *Usecase) Delete(id string) error {
+func (u *Usecase) Delete(ctx context.Context, id string) error {
 	// Check if user exists
-	if _, err := u.repo.FindByID(id); err != nil {
+	if _, err := u.repo.FindByID(ctx, id); err != nil {
 		if errors.Is(err, user.ErrUserNotFound) {
 			return apperror.NotFound(
 				"User tidak ditemukan",
@@ -121,61 +244,283 @@ func (u *Usecase) Delete(id string) error {
 		return apperror.Internal(err)
 	}
 
-	if err := u.repo.Delete(id); err != nil {
+	// Deleted users can no longer log in, end their current sessions too
+	err := u.withinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.Delete(ctx, id, user.ActorFromContext(ctx)); err != nil {
+			return err
+		}
+		return u.revokeSessions(ctx, id)
+	})
+	if err != nil {
 		return apperror.Internal(err)
 	}
 
 	return nil
 }
 
-func (u *Usecase) Login(email, password string) (string, error) {
-	// 1. Find user by email
-	existingUser, err := u.repo.FindByEmail(email)
-	if err != nil {
+// Restore undoes the soft delete of a user.
+func (u *Usecase) Restore(ctx context.Context, id string) error {
+	if err := u.repo.Restore(ctx, id, user.ActorFromContext(ctx)); err != nil {
 		if errors.Is(err, user.ErrUserNotFound) {
-			return "", apperror.Unauthorized(
-				"Username/Password tidak valid!",
+			return apperror.NotFound(
+				"User tidak ditemukan",
 				err,
-			).WithCode(apperror.InvalidCredentials)
+			).WithCode(apperror.UserNotFound)
+		}
+		if errors.Is(err, user.ErrEmailTaken) {
+			return apperror.NewConflictError("another user has registered the email of this user").WithCode(apperror.UserAlreadyExists)
 		}
 
-		return "", err
+		return apperror.Internal(err)
 	}
 
-	// 2. Authenticate
-	// If AuthService is available (and configured), try it first/instead.
-	// For this implementation, if AuthService is provided, we use it to validate password.
-	// If it returns true, we consider it valid.
-	authenticated := false
-	if u.authService != nil {
-		isAuth, err := u.authService.Login(email, password)
-		if err == nil && isAuth {
-			authenticated = true
+	return nil
+}
+
+// Purge permanently deletes a soft-deleted user.
+func (u *Usecase) Purge(ctx context.Context, id string) error {
+	if err := u.repo.Purge(ctx, id); err != nil {
+		if errors.Is(err, user.ErrUserNotFound) {
+			return apperror.NotFound(
+				"User tidak ditemukan",
+				err,
+			).WithCode(apperror.UserNotFound)
 		}
+
+		return apperror.Internal(err)
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently deletes the users soft-deleted longer than retention ago.
+func (u *Usecase) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	purged, err := u.repo.PurgeDeletedBefore(ctx, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	if purged > 0 {
+		log.Printf("[Usecase] Purged %d user(s) deleted more than %s ago", purged, retention)
+	}
+	return purged, nil
+}
+
+func (u *Usecase) Login(ctx context.Context, email, password string) (string, error) {
+	// 1. Find user by email. Unknown users may still be accepted by an external
+	// provider, in which case they are provisioned below.
+	var account *user.User
+	existingUser, err := u.repo.FindByEmail(ctx, email)
+	if err == nil {
+		account = &existingUser
+	} else if !errors.Is(err, user.ErrUserNotFound) {
+		return "", err
 	}
 
-	// Fallback to local bcrypt if not authenticated via external service (or if service not used)
-	// Note: The requirement implies "if login using client auth service".
-	// We can assume priority: External > Local.
-	if !authenticated {
-		if err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(password)); err != nil {
+	// 2. Authenticate against the configured provider chain
+	result, err := u.authenticate(ctx, email, password, account)
+	if err != nil {
+		if errors.Is(err, user.ErrInvalidCredentials) {
 			return "", apperror.Unauthorized("Username/Password tidak valid!", nil).WithCode(apperror.InvalidCredentials)
 		}
+		return "", err
+	}
+	log.Printf("[Auth] %s authenticated via %s", email, result.Provider)
+
+	// Checked after authenticating so the account state is not revealed to unauthenticated callers
+	if account != nil && !account.IsActive {
+		return "", errUserInactive()
+	}
+
+	// 3. Just-in-time provisioning and role sync for external providers
+	if account == nil {
+		existingUser, err = u.provisionUser(ctx, email, result)
+		if err != nil {
+			return "", err
+		}
+	} else if len(result.Roles) > 0 {
+		if err := u.syncProviderRoles(ctx, &existingUser, result.Roles); err != nil {
+			return "", apperror.Internal(err)
+		}
 	}
 
-	// 3. Lazy Migration to Keycloak
+	// 4. Lazy Migration to Keycloak
 	if existingUser.KeycloakID == "" && u.keycloakService != nil {
 		// This user is not yet in Keycloak, migrate them
-		keycloakID, err := u.keycloakService.CreateUser(existingUser.Email, existingUser.Name, password, existingUser.Roles)
+		keycloakID, err := u.keycloakService.CreateUser(ctx, existingUser.Email, existingUser.Name, password, existingUser.Roles)
 		if err == nil {
 			// Update local user with Keycloak ID
-			_ = u.repo.UpdateKeycloakID(existingUser.ID, keycloakID)
+			_ = u.repo.UpdateKeycloakID(ctx, existingUser.ID, keycloakID)
 		}
 		// We don't block login if Keycloak migration fails, just log it or handle as needed
 	}
 
-	// 4. Generate Token
-	token, err := jwt.GenerateToken(existingUser.ID, existingUser.Email, existingUser.Name, existingUser.Roles)
+	// 5. Generate Token
+	return u.issueToken(ctx, existingUser, result.Provider, "", "")
+}
+
+func errUserInactive() error {
+	return apperror.NewForbiddenError("user account is deactivated").WithCode(apperror.UserInactive)
+}
+
+// authenticate delegates to the auth service, falling back to the local bcrypt
+// hash when none is configured.
+func (u *Usecase) authenticate(ctx context.Context, email, password string, account *user.User) (user.AuthResult, error) {
+	if u.authService != nil {
+		return u.authService.Authenticate(ctx, email, password, account)
+	}
+
+	if account == nil || bcrypt.CompareHashAndPassword([]byte(account.Password), []byte(password)) != nil {
+		return user.AuthResult{}, user.ErrInvalidCredentials
+	}
+	return user.AuthResult{Provider: "local"}, nil
+}
+
+// provisionUser creates the local account for a user authenticated by an
+// external provider. The stored password is random so the account cannot be
+// used with the local provider until a password is set.
+func (u *Usecase) provisionUser(ctx context.Context, email string, result user.AuthResult) (user.User, error) {
+	name := result.Name
+	if name == "" {
+		name = strings.Split(email, "@")[0]
+	}
+
+	roles, err := u.knownRoles(ctx, result.Roles)
+	if err != nil {
+		return user.User{}, apperror.Internal(err)
+	}
+	if len(roles) == 0 {
+		roles = []string{"USER"} // Default role
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(uuid.NewString()), bcrypt.DefaultCost)
+	if err != nil {
+		return user.User{}, apperror.Internal(err)
+	}
+
+	log.Printf("[Usecase] Provisioning %s authenticated via %s", email, result.Provider)
+	newUser := user.User{
+		Email:    email,
+		Name:     name,
+		Password: string(hashedPassword),
+		Roles:    roles,
+		IsActive: true,
+	}
+	if err := u.repo.Save(ctx, newUser); err != nil {
+		return user.User{}, apperror.Internal(err)
+	}
+
+	// Re-fetch to get the generated ID
+	created, err := u.repo.FindByEmail(ctx, email)
+	if err != nil {
+		return user.User{}, apperror.Internal(err)
+	}
+	return created, nil
+}
+
+// syncProviderRoles replaces the local roles with the ones reported by the
+// provider when they differ.
+func (u *Usecase) syncProviderRoles(ctx context.Context, usr *user.User, providerRoles []string) error {
+	roles, err := u.knownRoles(ctx, providerRoles)
+	if err != nil {
+		return err
+	}
+	if len(roles) == 0 || sameRoles(usr.Roles, roles) {
+		return nil
+	}
+
+	usr.Roles = roles
+	return u.repo.Update(ctx, *usr)
+}
+
+// knownRoles keeps the roles that exist locally, matched case-insensitively and
+// returned with their local spelling.
+func (u *Usecase) knownRoles(ctx context.Context, roles []string) ([]string, error) {
+	localRoles, err := u.repo.FindAllRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	known := make(map[string]string, len(localRoles))
+	for _, role := range localRoles {
+		known[strings.ToUpper(role)] = role
+	}
+
+	result := []string{}
+	for _, role := range roles {
+		if local, ok := known[strings.ToUpper(role)]; ok {
+			result = append(result, local)
+		}
+	}
+	return result, nil
+}
+
+// checkRoles returns roles with their local spelling, or a validation error
+// naming the first role that does not exist locally.
+func (u *Usecase) checkRoles(ctx context.Context, roles []string) ([]string, error) {
+	if len(roles) == 0 {
+		return roles, nil
+	}
+
+	localRoles, err := u.repo.FindAllRoles(ctx)
+	if err != nil {
+		return nil, apperror.Internal(err)
+	}
+	known := make(map[string]string, len(localRoles))
+	for _, role := range localRoles {
+		known[strings.ToUpper(role)] = role
+	}
+
+	result := make([]string, 0, len(roles))
+	for _, role := range roles {
+		local, ok := known[strings.ToUpper(role)]
+		if !ok {
+			return nil, apperror.BadRequest(fmt.Sprintf("unknown role %q", role), nil).
+				WithCode(apperror.ValidationError)
+		}
+		result = append(result, local)
+	}
+	return result, nil
+}
+
+func sameRoles(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	set := make(map[string]bool, len(a))
+	for _, role := range a {
+		set[role] = true
+	}
+	for _, role := range b {
+		if !set[role] {
+			return false
+		}
+	}
+	return true
+}
+
+// issueToken records a local session (when a session repository is configured)
+// and signs a token bound to it.
+func (u *Usecase) issueToken(ctx context.Context, usr user.User, authProvider, keycloakToken, keycloakSessionID string) (string, error) {
+	var sessionID string
+	if u.sessionRepo != nil {
+		session := user.Session{
+			ID:                uuid.NewString(),
+			UserID:            usr.ID,
+			KeycloakSessionID: keycloakSessionID,
+			AuthProvider:      authProvider,
+			ExpiresAt:         time.Now().Add(jwt.TokenTTL),
+		}
+		if err := u.sessionRepo.Create(ctx, session); err != nil {
+			return "", apperror.Internal(err)
+		}
+		sessionID = session.ID
+	}
+
+	token, err := jwt.GenerateSessionToken(sessionID, usr.ID, usr.Email, usr.Name, usr.Roles, keycloakToken)
 	if err != nil {
 		return "", apperror.Internal(err)
 	}
@@ -184,7 +529,7 @@ func (u *Usecase) Login(email, password string) (string, error) {
 }
 
 // ChangePassword changes the password of a user
-func (u *Usecase) ChangePassword(id string, newPassword string) error {
+func (u *Usecase) ChangePassword(ctx context.Context, id string, newPassword string) error {
 	// Add validation password
 	pw, err := pw.Password(newPassword)
 
@@ -204,7 +549,7 @@ func (u *Usecase) ChangePassword(id string, newPassword string) error {
 	}
 
 	// Check if user exists
-	if _, err := u.repo.FindByID(id); err != nil {
+	if _, err := u.repo.FindByID(ctx, id); err != nil {
 		if errors.Is(err, user.ErrUserNotFound) {
 			return apperror.NotFound(
 				"User tidak ditemukan",
@@ -223,71 +568,9 @@ func (u *Usecase) ChangePassword(id string, newPassword string) error {
 		return apperror.Internal(err)
 	}
 
-	if err := u.repo.ChangePassword(id, string(hashedPassword)); err != nil {
+	if err := u.repo.ChangePassword(ctx, id, string(hashedPassword), user.ActorFromContext(ctx)); err != nil {
 		return apperror.Internal(err)
 	}
 
 	return nil
 }
-
-// LoginWithOIDC handles user login/registration from OIDC ID Token claims
-func (u *Usecase) LoginWithOIDC(claims map[string]interface{}, keycloakToken string) (string, error) {
-	email, _ := claims["email"].(string)
-	sub, _ := claims["sub"].(string)
-	name, _ := claims["name"].(string)
-
-	log.Printf("[Usecase] LoginWithOIDC: email=%s, sub=%s, name=%s", email, sub, name)
-
-	if email == "" || sub == "" {
-		return "", apperror.Unauthorized("invalid token claims", nil)
-	}
-
-	// 1. Try to find user by Keycloak ID (sub)
-	existingUser, err := u.repo.FindByKeycloakID(sub)
-	if err != nil {
-		if errors.Is(err, user.ErrUserNotFound) {
-			log.Printf("[Usecase] User with Keycloak ID %s not found. Checking email...", sub)
-			// 2. Fallback: try to find by email (for existing users not yet linked)
-			existingUser, err = u.repo.FindByEmail(email)
-			if err == nil {
-				log.Printf("[Usecase] Found existing user by email %s. Linking to Keycloak ID %s", email, sub)
-				// Link existing user to Keycloak
-				_ = u.repo.UpdateKeycloakID(existingUser.ID, sub)
-			} else if errors.Is(err, user.ErrUserNotFound) {
-				log.Printf("[Usecase] No existing user found. Registering new user...")
-				// 3. Register new user from OIDC
-				newUser := user.User{
-					KeycloakID: sub,
-					Email:      email,
-					Name:       name,
-					Roles:      []string{"USER"}, // Default role
-					IsActive:   true,
-				}
-				if err := u.repo.Save(newUser); err != nil {
-					log.Printf("[Usecase] Failed to save new user: %v", err)
-					return "", apperror.Internal(err)
-				}
-				log.Printf("[Usecase] New user saved successfully")
-				// Re-fetch to get the generated ID
-				existingUser, _ = u.repo.FindByEmail(email)
-			} else {
-				log.Printf("[Usecase] FindByEmail failed: %v", err)
-				return "", err
-			}
-		} else {
-			log.Printf("[Usecase] FindByKeycloakID failed: %v", err)
-			return "", err
-		}
-	}
-
-	log.Printf("[Usecase] Generating token for user ID %s", existingUser.ID)
-
-	// 4. Generate local token with Keycloak Access Token
-	token, err := jwt.GenerateToken(existingUser.ID, existingUser.Email, existingUser.Name, existingUser.Roles, keycloakToken)
-	if err != nil {
-		log.Printf("[Usecase] Token generation failed: %v", err)
-		return "", apperror.Internal(err)
-	}
-
-	return token, nil
-}
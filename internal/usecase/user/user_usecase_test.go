@@ -1,10 +1,15 @@
 package user_test
 
 import (
+	"context"
 	"errors"
+	"io"
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/afandimsr/go-gin-api/internal/domain/apperror"
+	"github.com/afandimsr/go-gin-api/internal/domain/storage"
 	"github.com/afandimsr/go-gin-api/internal/domain/user"
 	uc "github.com/afandimsr/go-gin-api/internal/usecase/user"
 	"github.com/stretchr/testify/assert"
@@ -20,66 +25,314 @@ type MockKeycloakService struct {
 	mock.Mock
 }
 
-func (m *MockKeycloakService) CreateUser(email, name, password string, roles []string) (string, error) {
+func (m *MockKeycloakService) CreateUser(ctx context.Context, email, name, password string, roles []string) (string, error) {
 	args := m.Called(email, name, password, roles)
 	return args.String(0), args.Error(1)
 }
 
-func (m *MockUserRepository) FindAll(limit, offset int) ([]user.User, error) {
-	args := m.Called(limit, offset)
+func (m *MockKeycloakService) ImportUser(ctx context.Context, email, name, passwordHash string, roles []string, requirePasswordUpdate bool) (string, error) {
+	args := m.Called(email, name, passwordHash, roles, requirePasswordUpdate)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockKeycloakService) VerifyToken(ctx context.Context, accessToken string) error {
+	args := m.Called(accessToken)
+	return args.Error(0)
+}
+
+func (m *MockKeycloakService) AdminEvents(ctx context.Context, since time.Time) ([]user.KeycloakAdminEvent, error) {
+	args := m.Called(since)
+	return args.Get(0).([]user.KeycloakAdminEvent), args.Error(1)
+}
+
+func (m *MockKeycloakService) GetUser(ctx context.Context, keycloakID string) (user.KeycloakUser, error) {
+	args := m.Called(keycloakID)
+	return args.Get(0).(user.KeycloakUser), args.Error(1)
+}
+
+func (m *MockKeycloakService) SetUserEnabled(ctx context.Context, keycloakID string, enabled bool) error {
+	args := m.Called(keycloakID, enabled)
+	return args.Error(0)
+}
+
+func (m *MockKeycloakService) GetUserRealmRoles(ctx context.Context, keycloakID string) ([]string, error) {
+	args := m.Called(keycloakID)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+type MockAuthService struct {
+	mock.Mock
+}
+
+func (m *MockAuthService) Authenticate(ctx context.Context, email, password string, account *user.User) (user.AuthResult, error) {
+	args := m.Called(email, password, account)
+	return args.Get(0).(user.AuthResult), args.Error(1)
+}
+
+type MockIdentityRepository struct {
+	mock.Mock
+}
+
+func (m *MockIdentityRepository) Create(ctx context.Context, i user.Identity) error {
+	args := m.Called(i)
+	return args.Error(0)
+}
+
+func (m *MockIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (user.Identity, error) {
+	args := m.Called(provider, subject)
+	return args.Get(0).(user.Identity), args.Error(1)
+}
+
+func (m *MockIdentityRepository) FindByUserID(ctx context.Context, userID string) ([]user.Identity, error) {
+	args := m.Called(userID)
+	return args.Get(0).([]user.Identity), args.Error(1)
+}
+
+type MockAttributeRepository struct {
+	mock.Mock
+}
+
+func (m *MockAttributeRepository) FindAll(ctx context.Context) ([]user.AttributeDefinition, error) {
+	args := m.Called()
+	return args.Get(0).([]user.AttributeDefinition), args.Error(1)
+}
+
+func (m *MockAttributeRepository) Save(ctx context.Context, def user.AttributeDefinition) error {
+	args := m.Called(def)
+	return args.Error(0)
+}
+
+func (m *MockAttributeRepository) Delete(ctx context.Context, name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+type MockStorage struct {
+	mock.Mock
+}
+
+func (m *MockStorage) Upload(ctx context.Context, key string, body io.Reader, opts ...storage.UploadOption) error {
+	data, _ := io.ReadAll(body)
+	args := m.Called(key, data, storage.ApplyUploadOptions(opts))
+	return args.Error(0)
+}
+
+func (m *MockStorage) Download(ctx context.Context, key string) (io.ReadCloser, storage.Object, error) {
+	args := m.Called(key)
+	body, _ := args.Get(0).(io.ReadCloser)
+	return body, args.Get(1).(storage.Object), args.Error(2)
+}
+
+func (m *MockStorage) Head(ctx context.Context, key string) (storage.Object, error) {
+	args := m.Called(key)
+	return args.Get(0).(storage.Object), args.Error(1)
+}
+
+func (m *MockStorage) Exists(ctx context.Context, key string) (bool, error) {
+	args := m.Called(key)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockStorage) Delete(ctx context.Context, key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *MockStorage) List(ctx context.Context, prefix, cursor string, limit int) (storage.ListPage, error) {
+	args := m.Called(prefix, cursor, limit)
+	return args.Get(0).(storage.ListPage), args.Error(1)
+}
+
+func (m *MockStorage) Copy(ctx context.Context, srcKey, dstKey string) error {
+	args := m.Called(srcKey, dstKey)
+	return args.Error(0)
+}
+
+func (m *MockStorage) PresignGet(ctx context.Context, key string, expiry time.Duration) (string, error) {
+	args := m.Called(key, expiry)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorage) PresignPut(ctx context.Context, key string, expiry time.Duration, opts ...storage.UploadOption) (string, error) {
+	args := m.Called(key, expiry, storage.ApplyUploadOptions(opts))
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorage) CreateMultipartUpload(ctx context.Context, key string, opts ...storage.UploadOption) (string, error) {
+	args := m.Called(key, storage.ApplyUploadOptions(opts))
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockStorage) UploadPart(ctx context.Context, key, uploadID string, number int, body io.Reader, size int64) (storage.Part, error) {
+	data, _ := io.ReadAll(body)
+	args := m.Called(key, uploadID, number, data)
+	return args.Get(0).(storage.Part), args.Error(1)
+}
+
+func (m *MockStorage) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []storage.Part) error {
+	args := m.Called(key, uploadID, parts)
+	return args.Error(0)
+}
+
+func (m *MockStorage) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	args := m.Called(key, uploadID)
+	return args.Error(0)
+}
+
+func (m *MockStorage) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
+
+type MockSessionRepository struct {
+	mock.Mock
+}
+
+func (m *MockSessionRepository) Create(ctx context.Context, s user.Session) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
+
+func (m *MockSessionRepository) FindByID(ctx context.Context, id string) (user.Session, error) {
+	args := m.Called(id)
+	return args.Get(0).(user.Session), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeByKeycloakSessionID(ctx context.Context, keycloakSessionID string) (int64, error) {
+	args := m.Called(keycloakSessionID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockSessionRepository) RevokeByUserID(ctx context.Context, userID string) (int64, error) {
+	args := m.Called(userID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) FindAll(ctx context.Context, query user.ListQuery) ([]user.User, error) {
+	args := m.Called(query)
 	return args.Get(0).([]user.User), args.Error(1)
 }
 
-func (m *MockUserRepository) FindByID(id string) (user.User, error) {
+func (m *MockUserRepository) Count(ctx context.Context, query user.ListQuery) (int64, error) {
+	args := m.Called(query)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+// Stream replays the users given to Return, then returns its error.
+func (m *MockUserRepository) Stream(ctx context.Context, query user.ListQuery, fn func(user.User) error) error {
+	args := m.Called(query)
+	for _, u := range args.Get(0).([]user.User) {
+		if err := fn(u); err != nil {
+			return err
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockUserRepository) Restore(ctx context.Context, id string, actor string) error {
+	args := m.Called(id, actor)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Purge(ctx context.Context, id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	args := m.Called(cutoff)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockUserRepository) FindByID(ctx context.Context, id string) (user.User, error) {
 	args := m.Called(id)
 	return args.Get(0).(user.User), args.Error(1)
 }
 
-func (m *MockUserRepository) FindByEmail(email string) (user.User, error) {
+func (m *MockUserRepository) FindByEmail(ctx context.Context, email string) (user.User, error) {
 	args := m.Called(email)
 	return args.Get(0).(user.User), args.Error(1)
 }
 
-func (m *MockUserRepository) Save(u user.User) error {
+func (m *MockUserRepository) FindRegisteredEmails(ctx context.Context, emails []string) ([]string, error) {
+	args := m.Called(emails)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) Save(ctx context.Context, u user.User) error {
 	args := m.Called(u)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Update(u user.User) error {
+func (m *MockUserRepository) SaveAll(ctx context.Context, users []user.User) error {
+	args := m.Called(users)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Update(ctx context.Context, u user.User) error {
 	args := m.Called(u)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) Delete(id string) error {
-	args := m.Called(id)
+func (m *MockUserRepository) Delete(ctx context.Context, id string, actor string) error {
+	args := m.Called(id, actor)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) FindByKeycloakID(keycloakID string) (user.User, error) {
+func (m *MockUserRepository) FindByKeycloakID(ctx context.Context, keycloakID string) (user.User, error) {
 	args := m.Called(keycloakID)
 	return args.Get(0).(user.User), args.Error(1)
 }
 
-func (m *MockUserRepository) UpdateKeycloakID(id string, keycloakID string) error {
+func (m *MockUserRepository) UpdateKeycloakID(ctx context.Context, id string, keycloakID string) error {
 	args := m.Called(id, keycloakID)
 	return args.Error(0)
 }
 
-func (m *MockUserRepository) ChangePassword(id string, newPassword string) error {
-	args := m.Called(id, newPassword)
+func (m *MockUserRepository) ChangePassword(ctx context.Context, id string, newPassword string, actor string) error {
+	args := m.Called(id, newPassword, actor)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateActive(ctx context.Context, id string, active bool, actor string) error {
+	args := m.Called(id, active, actor)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UpdateProfile(ctx context.Context, u user.User) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ScheduleDeactivation(ctx context.Context, id string, at *time.Time, actor string) error {
+	args := m.Called(id, at, actor)
 	return args.Error(0)
 }
 
+func (m *MockUserRepository) FindDueDeactivations(ctx context.Context, now time.Time, limit int) ([]user.User, error) {
+	args := m.Called(now, limit)
+	return args.Get(0).([]user.User), args.Error(1)
+}
+
+func (m *MockUserRepository) FindAllRoles(ctx context.Context) ([]string, error) {
+	args := m.Called()
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (m *MockUserRepository) FindWithoutKeycloakID(ctx context.Context, afterID string, limit int) ([]user.User, error) {
+	args := m.Called(afterID, limit)
+	return args.Get(0).([]user.User), args.Error(1)
+}
+
 func TestGetByID(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := uc.New(mockRepo, nil, nil)
+	usecase := uc.New(mockRepo, uc.Deps{})
 
 	mockUser := user.User{ID: "ef6d1df7-f85c-426c-9c12-6d58a1fc2633", Name: "Test User", Email: "test@example.com"}
 
 	t.Run("Success", func(t *testing.T) {
 		mockRepo.On("FindByID", "ef6d1df7-f85c-426c-9c12-6d58a1fc2633").Return(mockUser, nil)
 
-		u, err := usecase.GetByID("ef6d1df7-f85c-426c-9c12-6d58a1fc2633")
+		u, err := usecase.GetByID(context.Background(), "ef6d1df7-f85c-426c-9c12-6d58a1fc2633")
 
 		assert.NoError(t, err)
 		assert.Equal(t, mockUser.ID, u.ID)
@@ -91,7 +344,7 @@ func TestGetByID(t *testing.T) {
 	t.Run("NotFound", func(t *testing.T) {
 		mockRepo.On("FindByID", "412fd1c1-0d29-45dd-9cb7-efcf64390e8b").Return(user.User{}, user.ErrUserNotFound)
 
-		_, err := usecase.GetByID("412fd1c1-0d29-45dd-9cb7-efcf64390e8b")
+		_, err := usecase.GetByID(context.Background(), "412fd1c1-0d29-45dd-9cb7-efcf64390e8b")
 
 		assert.Error(t, err)
 		assert.Equal(t, "User tidak ditemukan", err.Error())
@@ -100,27 +353,27 @@ func TestGetByID(t *testing.T) {
 
 func TestCreate(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := uc.New(mockRepo, nil, nil)
+	usecase := uc.New(mockRepo, uc.Deps{})
 
 	t.Run("Success", func(t *testing.T) {
 		newUser := user.User{Name: "New User", Email: "new@example.com", Password: "password123"}
 
 		mockRepo.On("Save", mock.AnythingOfType("user.User")).Return(nil).Once()
-		err := usecase.Create(newUser)
+		err := usecase.Create(context.Background(), newUser)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("MissingEmail", func(t *testing.T) {
-		err := usecase.Create(user.User{Password: "123"})
+		err := usecase.Create(context.Background(), user.User{Password: "123"})
 		assert.Error(t, err)
 	})
 }
 
 func TestChangePassword(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := uc.New(mockRepo, nil, nil)
+	usecase := uc.New(mockRepo, uc.Deps{})
 	t.Run("Success", func(t *testing.T) {
 		userID := "6906ab46-7eda-4df8-8ad4-f9b46e39cb32"
 		newPassword := "Newpassword123@"
@@ -135,10 +388,10 @@ func TestChangePassword(t *testing.T) {
 		mockRepo.
 			On("ChangePassword", userID, mock.MatchedBy(func(pw string) bool {
 				return pw != newPassword && len(pw) > 20
-			})).
+			}), "").
 			Return(nil)
 
-		err := usecase.ChangePassword(userID, newPassword)
+		err := usecase.ChangePassword(context.Background(), userID, newPassword)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -152,7 +405,7 @@ func TestChangePassword(t *testing.T) {
 			On("FindByID", userID).
 			Return(user.User{}, user.ErrUserNotFound)
 
-		err := usecase.ChangePassword(userID, newPassword)
+		err := usecase.ChangePassword(context.Background(), userID, newPassword)
 
 		assert.Error(t, err)
 		assert.Equal(t, "User tidak ditemukan", err.Error())
@@ -164,7 +417,7 @@ func TestChangePassword(t *testing.T) {
 
 	t.Run("WeakPassword", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := uc.New(mockRepo, nil, nil)
+		usecase := uc.New(mockRepo, uc.Deps{})
 
 		// ✅ mock FindByID (WAJIB)
 		mockRepo.
@@ -172,7 +425,7 @@ func TestChangePassword(t *testing.T) {
 			Return(user.User{ID: "some-id"}, nil).
 			Once()
 
-		err := usecase.ChangePassword("some-id", "newpassword123")
+		err := usecase.ChangePassword(context.Background(), "some-id", "newpassword123")
 
 		assert.Error(t, err)
 
@@ -183,7 +436,7 @@ func TestChangePassword(t *testing.T) {
 
 	t.Run("ShortPassword", func(t *testing.T) {
 		mockRepo := new(MockUserRepository)
-		usecase := uc.New(mockRepo, nil, nil)
+		usecase := uc.New(mockRepo, uc.Deps{})
 
 		// ✅ mock FindByID (WAJIB)
 		mockRepo.
@@ -191,7 +444,7 @@ func TestChangePassword(t *testing.T) {
 			Return(user.User{ID: "some-id"}, nil).
 			Once()
 
-		err := usecase.ChangePassword("some-id", "123")
+		err := usecase.ChangePassword(context.Background(), "some-id", "123")
 
 		assert.Error(t, err)
 
@@ -204,7 +457,7 @@ func TestChangePassword(t *testing.T) {
 
 func TestDelete(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := uc.New(mockRepo, nil, nil)
+	usecase := uc.New(mockRepo, uc.Deps{})
 	t.Run("Success", func(t *testing.T) {
 		userID := "6906ab46-7eda-4df8-8ad4-f9b46e39cb32"
 		// ✅ mock FindByID (WAJIB)
@@ -214,10 +467,10 @@ func TestDelete(t *testing.T) {
 			Once()
 		// ✅ mock Delete
 		mockRepo.
-			On("Delete", userID).
+			On("Delete", userID, "").
 			Return(nil).
 			Once()
-		err := usecase.Delete(userID)
+		err := usecase.Delete(context.Background(), userID)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -227,7 +480,7 @@ func TestDelete(t *testing.T) {
 		mockRepo.
 			On("FindByID", userID).
 			Return(user.User{}, user.ErrUserNotFound)
-		err := usecase.Delete(userID)
+		err := usecase.Delete(context.Background(), userID)
 
 		assert.Error(t, err)
 		assert.Equal(t, "User tidak ditemukan", err.Error())
@@ -239,7 +492,7 @@ func TestDelete(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := uc.New(mockRepo, nil, nil)
+	usecase := uc.New(mockRepo, uc.Deps{})
 	t.Run("Success", func(t *testing.T) {
 		userID := "6906ab46-7eda-4df8-8ad4-f9b46e39cb32"
 		updatedUser := user.User{Name: "Updated User", Email: "updated@example.com", Roles: []string{"USER"}, Password: "newpassword123"}
@@ -248,6 +501,10 @@ func TestUpdate(t *testing.T) {
 			On("FindByID", userID).
 			Return(user.User{ID: userID, Name: "Old Name", Email: "old@example.com", Roles: []string{"USER"}, Password: "oldpassword123"}, nil).
 			Once()
+		mockRepo.
+			On("FindAllRoles").
+			Return([]string{"ADMIN", "USER"}, nil).
+			Once()
 
 		// ✅ mock Update
 		mockRepo.
@@ -255,7 +512,7 @@ func TestUpdate(t *testing.T) {
 			Return(nil).
 			Once()
 
-		err := usecase.Update(userID, updatedUser)
+		err := usecase.Update(context.Background(), userID, updatedUser)
 
 		assert.NoError(t, err)
 		mockRepo.AssertExpectations(t)
@@ -266,18 +523,35 @@ func TestUpdate(t *testing.T) {
 		mockRepo.
 			On("FindByID", userID).
 			Return(user.User{}, user.ErrUserNotFound)
-		err := usecase.Update(userID, updatedUser)
+		err := usecase.Update(context.Background(), userID, updatedUser)
 		assert.Error(t, err)
 		assert.Equal(t, "User tidak ditemukan", err.Error())
 		var appErr *apperror.AppError
 		assert.True(t, errors.As(err, &appErr))
 		assert.Equal(t, apperror.UserNotFound, appErr.ErrorCode)
 	})
+	t.Run("UnknownRole", func(t *testing.T) {
+		userID := "3f1c2a7e-7b1d-4c55-9d0e-5b7c1a9e2f10"
+		updatedUser := user.User{Name: "Updated User", Email: "updated@example.com", Roles: []string{"OWNER"}}
+		mockRepo.
+			On("FindByID", userID).
+			Return(user.User{ID: userID, Email: "old@example.com"}, nil).
+			Once()
+		mockRepo.
+			On("FindAllRoles").
+			Return([]string{"ADMIN", "USER"}, nil).
+			Once()
+		err := usecase.Update(context.Background(), userID, updatedUser)
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, http.StatusBadRequest, appErr.Code)
+		assert.Equal(t, apperror.ValidationError, appErr.ErrorCode)
+	})
 }
 
 func TestGetAll(t *testing.T) {
 	mockRepo := new(MockUserRepository)
-	usecase := uc.New(mockRepo, nil, nil)
+	usecase := uc.New(mockRepo, uc.Deps{})
 	t.Run("Success", func(t *testing.T) {
 		mockUsers := []user.User{
 			{ID: "1", Name: "User One", Email: "user1@example.com"},
@@ -285,25 +559,98 @@ func TestGetAll(t *testing.T) {
 		}
 
 		mockRepo.
-			On("FindAll", 10, 0).
+			On("Count", user.ListQuery{SortBy: user.SortByCreatedAt, SortDir: user.SortAsc}).
+			Return(int64(2), nil).
+			Once()
+		mockRepo.
+			On("FindAll", user.ListQuery{SortBy: user.SortByCreatedAt, SortDir: user.SortAsc, Limit: 10, Offset: 0}).
 			Return(mockUsers, nil).
 			Once()
-		users, err := usecase.GetAll(1, 10)
+		users, total, err := usecase.GetAll(context.Background(), 1, 10, user.ListQuery{})
 
 		assert.NoError(t, err)
 		assert.Len(t, users, 2)
+		assert.Equal(t, int64(2), total)
 		mockRepo.AssertExpectations(t)
 	})
 
 	t.Run("EmptyResult", func(t *testing.T) {
 		mockRepo.
-			On("FindAll", 10, 0).
-			Return([]user.User{}, nil).
+			On("Count", mock.AnythingOfType("user.ListQuery")).
+			Return(int64(0), nil).
 			Once()
-		users, err := usecase.GetAll(1, 10)
+		users, total, err := usecase.GetAll(context.Background(), 1, 10, user.ListQuery{})
 		assert.NoError(t, err)
 		assert.Len(t, users, 0)
+		assert.Equal(t, int64(0), total)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Filtered", func(t *testing.T) {
+		active := true
+		query := user.ListQuery{Search: "one", Role: "ADMIN", IsActive: &active, SortBy: user.SortByName, SortDir: user.SortDesc}
+
+		mockRepo.
+			On("Count", query).
+			Return(int64(6), nil).
+			Once()
+		mockRepo.
+			On("FindAll", user.ListQuery{Search: "one", Role: "ADMIN", IsActive: &active, SortBy: user.SortByName, SortDir: user.SortDesc, Limit: 5, Offset: 5}).
+			Return([]user.User{{ID: "1"}}, nil).
+			Once()
+		users, total, err := usecase.GetAll(context.Background(), 2, 5, query)
+		assert.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, int64(6), total)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("InvalidSort", func(t *testing.T) {
+		_, _, err := usecase.GetAll(context.Background(), 1, 10, user.ListQuery{SortBy: "password"})
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, 400, appErr.Code)
+	})
+
+	t.Run("InvalidPage", func(t *testing.T) {
+		_, _, err := usecase.GetAll(context.Background(), 0, 10, user.ListQuery{})
+
+		var appErr *apperror.AppError
+		assert.True(t, errors.As(err, &appErr))
+		assert.Equal(t, 400, appErr.Code)
+	})
+}
+
+func TestAuditActor(t *testing.T) {
+	mockRepo := new(MockUserRepository)
+	usecase := uc.New(mockRepo, uc.Deps{})
+	ctx := user.WithActor(context.Background(), "admin-1")
+
+	t.Run("Create records the creator", func(t *testing.T) {
+		mockRepo.On("Save", mock.MatchedBy(func(u user.User) bool {
+			return u.CreatedBy == "admin-1"
+		})).Return(nil).Once()
+
+		assert.NoError(t, usecase.Create(ctx, user.User{Email: "new@example.com"}))
 		mockRepo.AssertExpectations(t)
 	})
 
+	t.Run("Update records the updater", func(t *testing.T) {
+		mockRepo.On("FindByID", "1").Return(user.User{ID: "1", CreatedBy: "someone"}, nil).Once()
+		mockRepo.On("Update", mock.MatchedBy(func(u user.User) bool {
+			return u.UpdatedBy == "admin-1" && u.CreatedBy == "someone"
+		})).Return(nil).Once()
+
+		assert.NoError(t, usecase.Update(ctx, "1", user.User{Email: "a@example.com", UpdatedBy: "spoofed"}))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Delete records the actor", func(t *testing.T) {
+		mockRepo.On("FindByID", "2").Return(user.User{ID: "2"}, nil).Once()
+		mockRepo.On("Delete", "2", "admin-1").Return(nil).Once()
+
+		assert.NoError(t, usecase.Delete(ctx, "2"))
+		mockRepo.AssertExpectations(t)
+	})
 }
@@ -0,0 +1 @@
+ALTER TABLE user_sessions ADD COLUMN auth_provider VARCHAR(50) NULL;
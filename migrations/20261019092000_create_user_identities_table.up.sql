@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS user_identities (
+    id CHAR(36) PRIMARY KEY,
+    user_id CHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+
+-- Existing Keycloak links become identities of the "keycloak" provider
+INSERT INTO user_identities (id, user_id, provider, subject, email)
+SELECT id, id, 'keycloak', keycloak_id, email FROM users WHERE keycloak_id IS NOT NULL AND keycloak_id <> '';
@@ -0,0 +1,2 @@
+-- Dropping the column also drops idx_users_created_at
+ALTER TABLE users DROP COLUMN created_at;
@@ -0,0 +1,2 @@
+DROP INDEX idx_users_name_id ON users;
+DROP INDEX idx_users_created_at_id ON users;
@@ -0,0 +1,3 @@
+-- Keyset pagination orders by (sort column, id)
+CREATE INDEX idx_users_created_at_id ON users (created_at, id);
+CREATE INDEX idx_users_name_id ON users (name, id);
@@ -0,0 +1,4 @@
+-- Soft-deleted users would become visible again, purge them first
+DELETE FROM users WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE users DROP COLUMN deleted_at;
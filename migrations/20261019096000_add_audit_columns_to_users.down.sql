@@ -0,0 +1,3 @@
+ALTER TABLE users DROP COLUMN updated_by;
+ALTER TABLE users DROP COLUMN created_by;
+ALTER TABLE users DROP COLUMN updated_at;
@@ -0,0 +1,6 @@
+ALTER TABLE users ADD COLUMN updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE users ADD COLUMN created_by CHAR(36) NULL;
+ALTER TABLE users ADD COLUMN updated_by CHAR(36) NULL;
+
+-- Existing rows have not been updated since they were created
+UPDATE users SET updated_at = created_at;
@@ -0,0 +1,2 @@
+-- Dropping the column also drops idx_users_deactivate_at
+ALTER TABLE users DROP COLUMN deactivate_at;
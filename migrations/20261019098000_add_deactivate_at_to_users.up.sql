@@ -0,0 +1,3 @@
+ALTER TABLE users ADD COLUMN deactivate_at TIMESTAMP NULL;
+
+CREATE INDEX idx_users_deactivate_at ON users (deactivate_at);
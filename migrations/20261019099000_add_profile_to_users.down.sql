@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS user_attribute_definitions;
+
+-- Dropping the columns also drops idx_users_department
+ALTER TABLE users
+    DROP COLUMN phone,
+    DROP COLUMN department,
+    DROP COLUMN job_title,
+    DROP COLUMN avatar_url,
+    DROP COLUMN locale,
+    DROP COLUMN timezone,
+    DROP COLUMN custom_attributes;
@@ -0,0 +1,22 @@
+ALTER TABLE users
+    ADD COLUMN phone VARCHAR(32) NULL,
+    ADD COLUMN department VARCHAR(100) NULL,
+    ADD COLUMN job_title VARCHAR(100) NULL,
+    ADD COLUMN avatar_url VARCHAR(512) NULL,
+    ADD COLUMN locale VARCHAR(35) NULL,
+    ADD COLUMN timezone VARCHAR(64) NULL,
+    -- JSON object of custom attributes, see user_attribute_definitions
+    ADD COLUMN custom_attributes TEXT NULL;
+
+CREATE INDEX idx_users_department ON users (department);
+
+CREATE TABLE IF NOT EXISTS user_attribute_definitions (
+    name VARCHAR(64) PRIMARY KEY,
+    label VARCHAR(255) NULL,
+    type VARCHAR(20) NOT NULL,
+    required BOOLEAN NOT NULL DEFAULT FALSE,
+    -- JSON array of the allowed values of string attributes
+    options TEXT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS upload_session_parts;
+DROP TABLE IF EXISTS upload_sessions;
+
+ALTER TABLE files DROP COLUMN checksum;
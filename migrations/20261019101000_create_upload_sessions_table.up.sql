@@ -0,0 +1,28 @@
+ALTER TABLE files ADD COLUMN checksum CHAR(64) NULL;
+
+CREATE TABLE IF NOT EXISTS upload_sessions (
+    id CHAR(36) PRIMARY KEY,
+    owner_id CHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    part_size BIGINT NOT NULL,
+    checksum CHAR(64) NULL,
+    status VARCHAR(20) NOT NULL,
+    storage_key VARCHAR(512) NOT NULL,
+    -- Id of the multipart upload in the bucket
+    upload_id VARCHAR(1024) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_upload_sessions_status_expires_at ON upload_sessions (status, expires_at);
+
+CREATE TABLE IF NOT EXISTS upload_session_parts (
+    session_id CHAR(36) NOT NULL REFERENCES upload_sessions(id) ON DELETE CASCADE,
+    number INT NOT NULL,
+    size BIGINT NOT NULL,
+    etag VARCHAR(255) NOT NULL,
+    checksum CHAR(64) NOT NULL,
+    PRIMARY KEY (session_id, number)
+);
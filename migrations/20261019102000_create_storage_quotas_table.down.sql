@@ -0,0 +1,3 @@
+DROP INDEX idx_upload_sessions_owner_id ON upload_sessions;
+
+DROP TABLE IF EXISTS storage_quotas;
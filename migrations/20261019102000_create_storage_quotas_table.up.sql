@@ -0,0 +1,9 @@
+-- Custom storage quotas, the other users have the default quota
+CREATE TABLE IF NOT EXISTS storage_quotas (
+    user_id CHAR(36) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    -- 0 is unlimited
+    quota_bytes BIGINT NOT NULL,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_upload_sessions_owner_id ON upload_sessions (owner_id);
@@ -0,0 +1,2 @@
+-- NULL is the only way to store a user without Keycloak, nothing to revert
+SELECT 1;
@@ -0,0 +1,2 @@
+-- Users without Keycloak have a NULL keycloak_id, '' would violate its UNIQUE constraint
+UPDATE users SET keycloak_id = NULL WHERE keycloak_id = '';
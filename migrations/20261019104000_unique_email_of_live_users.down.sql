@@ -0,0 +1,11 @@
+-- Soft-deleted users sharing their email with another user are given a unique
+-- one, prefixed with their id, so the email of every user is unique again
+UPDATE users u
+JOIN (SELECT email FROM users GROUP BY email HAVING COUNT(*) > 1) shared ON shared.email = u.email
+SET u.email = LEFT(CONCAT(u.id, '+', u.email), 255)
+WHERE u.deleted_at IS NOT NULL;
+
+ALTER TABLE users
+    DROP INDEX idx_users_live_email,
+    DROP COLUMN live_email,
+    ADD UNIQUE INDEX email (email);
@@ -0,0 +1,7 @@
+-- The email of a soft-deleted user can be registered again, only live users
+-- need a unique email. MySQL has no partial index, the generated column is
+-- NULL for deleted users and NULLs do not conflict.
+ALTER TABLE users
+    DROP INDEX email,
+    ADD COLUMN live_email VARCHAR(255) GENERATED ALWAYS AS (IF(deleted_at IS NULL, email, NULL)) VIRTUAL,
+    ADD UNIQUE INDEX idx_users_live_email (live_email);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS storage_reservations;
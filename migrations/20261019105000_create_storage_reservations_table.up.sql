@@ -0,0 +1,11 @@
+-- Bytes held by uploads in progress, so concurrent uploads cannot exceed a
+-- storage quota together
+CREATE TABLE IF NOT EXISTS storage_reservations (
+    id CHAR(36) PRIMARY KEY,
+    owner_id CHAR(36) NOT NULL,
+    size BIGINT NOT NULL,
+    -- Reservations of an interrupted upload stop counting once expired
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_storage_reservations_owner_id_expires_at ON storage_reservations (owner_id, expires_at);
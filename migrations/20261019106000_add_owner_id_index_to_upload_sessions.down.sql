@@ -0,0 +1,2 @@
+-- The index is dropped by 20261019102000, which may have created it
+SELECT 1;
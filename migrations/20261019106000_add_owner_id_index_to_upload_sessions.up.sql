@@ -0,0 +1,14 @@
+-- Storage usage sums the pending upload sessions of an owner. Databases
+-- migrated with the original 20261019102000 already have the index.
+SET @index_exists = (
+    SELECT COUNT(*) FROM information_schema.statistics
+    WHERE table_schema = DATABASE()
+      AND table_name = 'upload_sessions'
+      AND index_name = 'idx_upload_sessions_owner_id'
+);
+SET @ddl = IF(@index_exists = 0,
+    'CREATE INDEX idx_upload_sessions_owner_id ON upload_sessions (owner_id)',
+    'SELECT 1');
+PREPARE stmt FROM @ddl;
+EXECUTE stmt;
+DEALLOCATE PREPARE stmt;
@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS storage_quotas;
+DROP TABLE IF EXISTS upload_session_parts;
+DROP TABLE IF EXISTS upload_sessions;
+DROP TABLE IF EXISTS files;
+DROP TABLE IF EXISTS user_attribute_definitions;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS user_sessions;
+DROP TABLE IF EXISTS user_roles;
+DROP TABLE IF EXISTS roles;
+DROP TABLE IF EXISTS users;
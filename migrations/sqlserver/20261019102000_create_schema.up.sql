@@ -0,0 +1,132 @@
+-- SQL Server schema, equivalent to the migrations in the parent directory up to this version.
+-- Strings are NVARCHAR since go-mssqldb sends string parameters as nvarchar.
+CREATE TABLE users (
+    id NCHAR(36) PRIMARY KEY,
+    keycloak_id NVARCHAR(255) NULL,
+    name NVARCHAR(255) NOT NULL,
+    password NVARCHAR(255) NOT NULL,
+    email NVARCHAR(255) NOT NULL UNIQUE,
+    is_active BIT NOT NULL DEFAULT 1,
+    created_at DATETIME2 NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME2 NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    created_by NCHAR(36) NULL,
+    updated_by NCHAR(36) NULL,
+    deleted_at DATETIME2 NULL,
+    version INT NOT NULL DEFAULT 1,
+    deactivate_at DATETIME2 NULL,
+    phone NVARCHAR(32) NULL,
+    department NVARCHAR(100) NULL,
+    job_title NVARCHAR(100) NULL,
+    avatar_url NVARCHAR(512) NULL,
+    locale NVARCHAR(35) NULL,
+    timezone NVARCHAR(64) NULL,
+    -- JSON object of custom attributes, see user_attribute_definitions
+    custom_attributes NVARCHAR(MAX) NULL
+);
+
+-- A UNIQUE constraint would allow a single NULL keycloak_id
+CREATE UNIQUE INDEX idx_users_keycloak_id ON users (keycloak_id) WHERE keycloak_id IS NOT NULL;
+CREATE INDEX idx_users_created_at ON users (created_at);
+-- Keyset pagination orders by (sort column, id)
+CREATE INDEX idx_users_created_at_id ON users (created_at, id);
+CREATE INDEX idx_users_name_id ON users (name, id);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX idx_users_deactivate_at ON users (deactivate_at);
+CREATE INDEX idx_users_department ON users (department);
+
+CREATE TABLE roles (
+    id NCHAR(36) PRIMARY KEY,
+    name NVARCHAR(100) NOT NULL UNIQUE
+);
+
+CREATE TABLE user_roles (
+    user_id NCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id NCHAR(36) NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE TABLE user_sessions (
+    id NCHAR(36) PRIMARY KEY,
+    user_id NCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    keycloak_session_id NVARCHAR(255) NULL,
+    auth_provider NVARCHAR(50) NULL,
+    expires_at DATETIME2 NOT NULL,
+    revoked_at DATETIME2 NULL,
+    created_at DATETIME2 NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_sessions_user_id ON user_sessions (user_id);
+CREATE INDEX idx_user_sessions_keycloak_session_id ON user_sessions (keycloak_session_id);
+
+CREATE TABLE user_identities (
+    id NCHAR(36) PRIMARY KEY,
+    user_id NCHAR(36) NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider NVARCHAR(50) NOT NULL,
+    subject NVARCHAR(255) NOT NULL,
+    email NVARCHAR(255) NULL,
+    created_at DATETIME2 NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (provider, subject)
+);
+
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
+
+CREATE TABLE user_attribute_definitions (
+    name NVARCHAR(64) PRIMARY KEY,
+    label NVARCHAR(255) NULL,
+    type NVARCHAR(20) NOT NULL,
+    required BIT NOT NULL DEFAULT 0,
+    -- JSON array of the allowed values of string attributes
+    options NVARCHAR(MAX) NULL,
+    created_at DATETIME2 NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME2 NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE files (
+    id NCHAR(36) PRIMARY KEY,
+    owner_id NCHAR(36) NOT NULL,
+    name NVARCHAR(255) NOT NULL,
+    content_type NVARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    -- Key of the content in the private bucket
+    storage_key NVARCHAR(512) NOT NULL,
+    checksum NCHAR(64) NULL,
+    created_at DATETIME2 NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_files_owner_id ON files (owner_id);
+
+CREATE TABLE upload_sessions (
+    id NCHAR(36) PRIMARY KEY,
+    owner_id NCHAR(36) NOT NULL,
+    name NVARCHAR(255) NOT NULL,
+    content_type NVARCHAR(255) NOT NULL,
+    size BIGINT NOT NULL,
+    part_size BIGINT NOT NULL,
+    checksum NCHAR(64) NULL,
+    status NVARCHAR(20) NOT NULL,
+    storage_key NVARCHAR(512) NOT NULL,
+    -- Id of the multipart upload in the bucket
+    upload_id NVARCHAR(1024) NOT NULL,
+    expires_at DATETIME2 NOT NULL,
+    created_at DATETIME2 NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_upload_sessions_status_expires_at ON upload_sessions (status, expires_at);
+CREATE INDEX idx_upload_sessions_owner_id ON upload_sessions (owner_id);
+
+CREATE TABLE upload_session_parts (
+    session_id NCHAR(36) NOT NULL REFERENCES upload_sessions(id) ON DELETE CASCADE,
+    number INT NOT NULL,
+    size BIGINT NOT NULL,
+    etag NVARCHAR(255) NOT NULL,
+    checksum NCHAR(64) NOT NULL,
+    PRIMARY KEY (session_id, number)
+);
+
+-- Custom storage quotas, the other users have the default quota
+CREATE TABLE storage_quotas (
+    user_id NCHAR(36) PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    -- 0 is unlimited
+    quota_bytes BIGINT NOT NULL,
+    updated_at DATETIME2 NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,11 @@
+-- Soft-deleted users sharing their email with another user are given a unique
+-- one, prefixed with their id, so the email of every user is unique again
+UPDATE u
+SET email = LEFT(CONCAT(u.id, '+', u.email), 255)
+FROM users u
+WHERE u.deleted_at IS NOT NULL
+  AND EXISTS (SELECT 1 FROM users o WHERE o.email = u.email AND o.id <> u.id);
+
+DROP INDEX idx_users_live_email ON users;
+
+ALTER TABLE users ADD CONSTRAINT uq_users_email UNIQUE (email);
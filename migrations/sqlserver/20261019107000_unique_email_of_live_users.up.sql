@@ -0,0 +1,14 @@
+-- The email of a soft-deleted user can be registered again, only live users
+-- need a unique email. The inline UNIQUE constraint of create_schema has a
+-- generated name, look it up to drop it.
+DECLARE @constraint NVARCHAR(128);
+SELECT @constraint = kc.name
+FROM sys.key_constraints kc
+JOIN sys.index_columns ic ON ic.object_id = kc.parent_object_id AND ic.index_id = kc.unique_index_id
+JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+WHERE kc.parent_object_id = OBJECT_ID('users') AND kc.type = 'UQ' AND c.name = 'email';
+IF @constraint IS NOT NULL
+    EXEC('ALTER TABLE users DROP CONSTRAINT ' + QUOTENAME(@constraint));
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE object_id = OBJECT_ID('users') AND name = 'idx_users_live_email')
+    CREATE UNIQUE INDEX idx_users_live_email ON users (email) WHERE deleted_at IS NULL;